@@ -0,0 +1,283 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// AlarmTime is the time of day an alarm goes off, along with the UTC
+// offset Fitbit stores it with, as in "07:15-08:00".
+type AlarmTime struct {
+	Time   ClockTime
+	Offset time.Duration
+}
+
+// ParseAlarmTime parses an HH:mm time of day followed by a ±hh:mm UTC
+// offset (or Z).
+func ParseAlarmTime(s string) (AlarmTime, error) {
+	t, err := time.Parse("15:04Z07:00", s)
+	if err != nil {
+		return AlarmTime{}, fmt.Errorf("fitbit: invalid alarm time %q: want HH:mm±hh:mm", s)
+	}
+	_, offset := t.Zone()
+	return AlarmTime{
+		Time:   ClockTime{Hour: t.Hour(), Minute: t.Minute()},
+		Offset: time.Duration(offset) * time.Second,
+	}, nil
+}
+
+// String returns the time formatted as HH:mm±hh:mm.
+func (t AlarmTime) String() string {
+	sign, offset := '+', t.Offset
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	mins := int(offset / time.Minute)
+	return fmt.Sprintf("%s%c%02d:%02d", t.Time, sign, mins/60, mins%60)
+}
+
+// validate checks that t is a real time of day with an offset Fitbit
+// accepts.
+func (t AlarmTime) validate() error {
+	if t.Time.Hour < 0 || t.Time.Hour > 23 || t.Time.Minute < 0 || t.Time.Minute > 59 {
+		return fmt.Errorf("fitbit: invalid alarm time of day %s", t.Time)
+	}
+	if t.Offset%time.Minute != 0 || t.Offset < -12*time.Hour || t.Offset > 14*time.Hour {
+		return fmt.Errorf("fitbit: invalid alarm UTC offset %s", t.Offset)
+	}
+	return nil
+}
+
+func (t AlarmTime) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *AlarmTime) UnmarshalText(b []byte) error {
+	parsed, err := ParseAlarmTime(string(b))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// WeekDays are the days a recurring alarm goes off on, which Fitbit
+// names in upper case ("MONDAY").
+type WeekDays []time.Weekday
+
+// parseWeekday parses an upper case Fitbit day name.
+func parseWeekday(name string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.ToUpper(d.String()) == name {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("fitbit: invalid week day %q", name)
+}
+
+// String returns the days as the comma separated upper case names
+// Fitbit takes.
+func (w WeekDays) String() string {
+	names := make([]string, len(w))
+	for i, d := range w {
+		names[i] = strings.ToUpper(d.String())
+	}
+	return strings.Join(names, ",")
+}
+
+func (w WeekDays) validate() error {
+	for _, d := range w {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("fitbit: invalid week day %d", d)
+		}
+	}
+	return nil
+}
+
+func (w *WeekDays) UnmarshalJSON(b []byte) error {
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return err
+	}
+	days := make(WeekDays, len(names))
+	for i, name := range names {
+		d, err := parseWeekday(name)
+		if err != nil {
+			return err
+		}
+		days[i] = d
+	}
+	*w = days
+	return nil
+}
+
+// TrackerAlarm is a silent alarm set on a tracker. SyncedToDevice is
+// false until the tracker next syncs after the alarm changed.
+type TrackerAlarm struct {
+	AlarmID        int64     `json:"alarmId"`
+	Time           AlarmTime `json:"time"`
+	Enabled        bool      `json:"enabled"`
+	Recurring      bool      `json:"recurring"`
+	WeekDays       WeekDays  `json:"weekDays"`
+	SnoozeCount    int       `json:"snoozeCount"`
+	SnoozeLength   int       `json:"snoozeLength"` // minutes
+	Vibe           string    `json:"vibe"`
+	SyncedToDevice bool      `json:"syncedToDevice"`
+	Deleted        bool      `json:"deleted"`
+}
+
+// Alarms returns the silent alarms of the tracker with the given device
+// id. Only trackers have alarms; for other devices Fitbit's error is
+// returned as an *APIError.
+func (c *Client) Alarms(ctx context.Context, trackerID string) ([]TrackerAlarm, error) {
+	var resp struct {
+		TrackerAlarms []TrackerAlarm `json:"trackerAlarms"`
+	}
+	err := c.get(ctx, fmt.Sprintf("/user/-/devices/tracker/%s/alarms.json", trackerID), &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.TrackerAlarms == nil {
+		resp.TrackerAlarms = []TrackerAlarm{}
+	}
+	return resp.TrackerAlarms, nil
+}
+
+// NewAlarm is a silent alarm to add with AddAlarm. Recurring alarms need
+// the WeekDays they go off on; one-off alarms must leave WeekDays empty.
+type NewAlarm struct {
+	Time      AlarmTime
+	Enabled   bool
+	Recurring bool
+	WeekDays  WeekDays
+}
+
+// alarmParams returns the parameters shared by adding and updating an
+// alarm.
+func alarmParams(t AlarmTime, enabled, recurring bool, days WeekDays) (url.Values, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	if recurring && len(days) == 0 {
+		return nil, errors.New("fitbit: recurring alarm needs WeekDays")
+	}
+	if !recurring && len(days) != 0 {
+		return nil, errors.New("fitbit: one-off alarm can't have WeekDays")
+	}
+	if err := days.validate(); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"time":      {t.String()},
+		"enabled":   {strconv.FormatBool(enabled)},
+		"recurring": {strconv.FormatBool(recurring)},
+	}
+	if recurring {
+		params.Set("weekDays", days.String())
+	}
+	return params, nil
+}
+
+// AddAlarm adds a silent alarm to the tracker with the given device id.
+// The created alarm isn't synced to the tracker until it next syncs.
+func (c *Client) AddAlarm(ctx context.Context, trackerID string, a NewAlarm) (TrackerAlarm, error) {
+	var resp struct {
+		TrackerAlarm TrackerAlarm `json:"trackerAlarm"`
+	}
+	params, err := alarmParams(a.Time, a.Enabled, a.Recurring, a.WeekDays)
+	if err != nil {
+		return resp.TrackerAlarm, err
+	}
+
+	urlStr := fmt.Sprintf("/user/-/devices/tracker/%s/alarms.json", trackerID)
+	err = c.postForm(ctx, "", urlStr, params, &resp)
+	return resp.TrackerAlarm, err
+}
+
+// AlarmUpdate is the full definition an alarm is replaced with by
+// UpdateAlarm; Fitbit doesn't support partial updates. SnoozeLength (in
+// minutes) and SnoozeCount are always sent, so 0 turns snoozing off.
+type AlarmUpdate struct {
+	Time         AlarmTime
+	Enabled      bool
+	Recurring    bool
+	WeekDays     WeekDays
+	SnoozeLength int
+	SnoozeCount  int
+}
+
+// Update returns the alarm's current definition, to be changed and
+// passed to UpdateAlarm.
+func (a TrackerAlarm) Update() AlarmUpdate {
+	return AlarmUpdate{
+		Time:         a.Time,
+		Enabled:      a.Enabled,
+		Recurring:    a.Recurring,
+		WeekDays:     a.WeekDays,
+		SnoozeLength: a.SnoozeLength,
+		SnoozeCount:  a.SnoozeCount,
+	}
+}
+
+// UpdateAlarm replaces the alarm with the given id on the tracker. It
+// returns an error matching ErrNotFound if there is no such alarm.
+func (c *Client) UpdateAlarm(ctx context.Context, trackerID string, alarmID int64, u AlarmUpdate) (TrackerAlarm, error) {
+	var resp struct {
+		TrackerAlarm TrackerAlarm `json:"trackerAlarm"`
+	}
+	if u.SnoozeLength < 0 || u.SnoozeCount < 0 {
+		return resp.TrackerAlarm, errors.New("fitbit: alarm SnoozeLength and SnoozeCount can't be negative")
+	}
+	params, err := alarmParams(u.Time, u.Enabled, u.Recurring, u.WeekDays)
+	if err != nil {
+		return resp.TrackerAlarm, err
+	}
+	params.Set("snoozeLength", strconv.Itoa(u.SnoozeLength))
+	params.Set("snoozeCount", strconv.Itoa(u.SnoozeCount))
+
+	urlStr := fmt.Sprintf("/user/-/devices/tracker/%s/alarms/%d.json", trackerID, alarmID)
+	err = c.postForm(ctx, "", urlStr, params, &resp)
+	return resp.TrackerAlarm, err
+}
+
+// EnableAlarm turns on the alarm with the given id, leaving the rest of
+// its definition as it is.
+func (c *Client) EnableAlarm(ctx context.Context, trackerID string, alarmID int64) (TrackerAlarm, error) {
+	return c.setAlarmEnabled(ctx, trackerID, alarmID, true)
+}
+
+// DisableAlarm turns off the alarm with the given id, leaving the rest
+// of its definition as it is.
+func (c *Client) DisableAlarm(ctx context.Context, trackerID string, alarmID int64) (TrackerAlarm, error) {
+	return c.setAlarmEnabled(ctx, trackerID, alarmID, false)
+}
+
+func (c *Client) setAlarmEnabled(ctx context.Context, trackerID string, alarmID int64, enabled bool) (TrackerAlarm, error) {
+	alarms, err := c.Alarms(ctx, trackerID)
+	if err != nil {
+		return TrackerAlarm{}, err
+	}
+	for _, a := range alarms {
+		if a.AlarmID != alarmID {
+			continue
+		}
+		u := a.Update()
+		u.Enabled = enabled
+		return c.UpdateAlarm(ctx, trackerID, alarmID, u)
+	}
+	return TrackerAlarm{}, fmt.Errorf("%w: no alarm %d on tracker %s", ErrNotFound, alarmID, trackerID)
+}
+
+// DeleteAlarm deletes the alarm with the given id from the tracker. It
+// returns an error matching ErrNotFound if there is no such alarm.
+func (c *Client) DeleteAlarm(ctx context.Context, trackerID string, alarmID int64) error {
+	return c.delete(ctx, "", fmt.Sprintf("/user/-/devices/tracker/%s/alarms/%d.json", trackerID, alarmID))
+}
@@ -0,0 +1,235 @@
+package fitbit
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateAlarmZeroSnooze(t *testing.T) {
+	var form url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /1/user/-/devices/tracker/T1/alarms/7.json", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		form = r.PostForm
+		w.Write([]byte(`{"trackerAlarm":{"alarmId":7,"time":"07:15-08:00","snoozeCount":0,"snoozeLength":0}}`))
+	})
+	c := newTestClient(t, mux)
+
+	a, err := c.UpdateAlarm(t.Context(), "T1", 7, AlarmUpdate{
+		Time: AlarmTime{Time: ClockTime{Hour: 7, Minute: 15}, Offset: -8 * time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.AlarmID != 7 {
+		t.Errorf("AlarmID = %d, want 7", a.AlarmID)
+	}
+	if got := form.Get("snoozeLength"); got != "0" {
+		t.Errorf("snoozeLength = %q, want 0", got)
+	}
+	if got := form.Get("snoozeCount"); got != "0" {
+		t.Errorf("snoozeCount = %q, want 0", got)
+	}
+}
+
+func TestUpdateAlarmNegativeSnooze(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	for _, u := range []AlarmUpdate{
+		{SnoozeLength: -1, SnoozeCount: 3},
+		{SnoozeLength: 9, SnoozeCount: -1},
+	} {
+		if _, err := c.UpdateAlarm(t.Context(), "T1", 7, u); err == nil {
+			t.Errorf("UpdateAlarm(%+v) succeeded, want an error", u)
+		}
+	}
+}
+
+// fakeAlarm is a tracker alarm as Fitbit sends it.
+type fakeAlarm struct {
+	AlarmID        int64    `json:"alarmId"`
+	Time           string   `json:"time"`
+	Enabled        bool     `json:"enabled"`
+	Recurring      bool     `json:"recurring"`
+	WeekDays       []string `json:"weekDays"`
+	SnoozeCount    int      `json:"snoozeCount"`
+	SnoozeLength   int      `json:"snoozeLength"`
+	Vibe           string   `json:"vibe"`
+	SyncedToDevice bool     `json:"syncedToDevice"`
+	Deleted        bool     `json:"deleted"`
+}
+
+// fakeAlarms fakes Fitbit's alarm endpoints for the tracker with id
+// tracker.
+type fakeAlarms struct {
+	tracker string
+	alarms  fakeStore[int64, fakeAlarm]
+}
+
+// set applies a create or update request's parameters to a.
+func (f *fakeAlarms) set(w http.ResponseWriter, r *http.Request, a *fakeAlarm) bool {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "validation", "n/a", err.Error())
+		return false
+	}
+	// Fitbit stores the time as sent, with its offset.
+	if _, err := time.Parse("15:04Z07:00", r.PostForm.Get("time")); err != nil {
+		writeError(w, http.StatusBadRequest, "validation", "time", "Invalid time")
+		return false
+	}
+	a.Time = r.PostForm.Get("time")
+	a.Enabled = r.PostForm.Get("enabled") == "true"
+	a.Recurring = r.PostForm.Get("recurring") == "true"
+	a.WeekDays = []string{}
+	if days := r.PostForm.Get("weekDays"); days != "" {
+		a.WeekDays = strings.Split(days, ",")
+	}
+	for _, key := range []string{"snoozeLength", "snoozeCount"} {
+		if !r.PostForm.Has(key) {
+			continue
+		}
+		n, err := strconv.Atoi(r.PostForm.Get(key))
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "validation", key, "Invalid "+key)
+			return false
+		}
+		if key == "snoozeLength" {
+			a.SnoozeLength = n
+		} else {
+			a.SnoozeCount = n
+		}
+	}
+	a.SyncedToDevice = false
+	return true
+}
+
+func (f *fakeAlarms) handler() http.Handler {
+	prefix := "/1/user/-/devices/tracker/" + f.tracker
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+prefix+"/alarms.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string][]fakeAlarm{"trackerAlarms": f.alarms.list(nil)})
+	})
+	mux.HandleFunc("POST "+prefix+"/alarms.json", func(w http.ResponseWriter, r *http.Request) {
+		a := fakeAlarm{SnoozeCount: 3, SnoozeLength: 9, Vibe: "DEFAULT"}
+		if !f.set(w, r, &a) {
+			return
+		}
+		a.AlarmID = f.alarms.newID()
+		f.alarms.put(a.AlarmID, a)
+		writeJSON(w, http.StatusCreated, map[string]fakeAlarm{"trackerAlarm": a})
+	})
+	mux.HandleFunc("POST "+prefix+"/alarms/{id}", func(w http.ResponseWriter, r *http.Request) {
+		a, ok := f.alarms.get(pathID(r))
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found", "alarmId", "Alarm not found")
+			return
+		}
+		if !f.set(w, r, &a) {
+			return
+		}
+		f.alarms.put(a.AlarmID, a)
+		writeJSON(w, http.StatusOK, map[string]fakeAlarm{"trackerAlarm": a})
+	})
+	mux.HandleFunc("DELETE "+prefix+"/alarms/{id}", serveDelete(&f.alarms, "alarmId", "Alarm not found"))
+	return mux
+}
+
+func TestAlarmLifecycle(t *testing.T) {
+	f := &fakeAlarms{tracker: "T1"}
+	c := newTestClient(t, f.handler())
+
+	at, err := ParseAlarmTime("06:45+05:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at.String() != "06:45+05:30" {
+		t.Errorf("ParseAlarmTime(06:45+05:30) = %s", at)
+	}
+	added, err := c.AddAlarm(t.Context(), "T1", NewAlarm{Time: at, Enabled: true, Recurring: true, WeekDays: WeekDays{time.Monday, time.Friday}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.AlarmID == 0 || added.Time != at || !added.Enabled || added.SyncedToDevice {
+		t.Errorf("added = %+v", added)
+	}
+	if len(added.WeekDays) != 2 || added.WeekDays[0] != time.Monday || added.WeekDays[1] != time.Friday {
+		t.Errorf("WeekDays = %v, want [Monday Friday]", added.WeekDays)
+	}
+	if stored, _ := f.alarms.get(added.AlarmID); stored.Time != "06:45+05:30" || strings.Join(stored.WeekDays, ",") != "MONDAY,FRIDAY" {
+		t.Errorf("sent time %q and days %v", stored.Time, stored.WeekDays)
+	}
+
+	// Make it a one-off alarm at a UTC time, with snoozing off.
+	u := added.Update()
+	u.Time = AlarmTime{Time: ClockTime{Hour: 7, Minute: 0}}
+	u.Recurring, u.WeekDays = false, nil
+	u.SnoozeCount, u.SnoozeLength = 0, 0
+	if _, err := c.UpdateAlarm(t.Context(), "T1", added.AlarmID, u); err != nil {
+		t.Fatal(err)
+	}
+	alarms, err := c.Alarms(t.Context(), "T1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alarms) != 1 {
+		t.Fatalf("got %d alarms, want 1", len(alarms))
+	}
+	a := alarms[0]
+	if a.Time.String() != "07:00+00:00" || a.Recurring || len(a.WeekDays) != 0 || a.SnoozeCount != 0 || a.SnoozeLength != 0 || !a.Enabled {
+		t.Errorf("alarm after update = %+v", a)
+	}
+	if stored, _ := f.alarms.get(added.AlarmID); stored.Time != "07:00+00:00" {
+		t.Errorf("sent time %q, want 07:00+00:00", stored.Time)
+	}
+
+	if _, err := c.DisableAlarm(t.Context(), "T1", added.AlarmID); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := f.alarms.get(added.AlarmID); stored.Enabled || stored.Time != "07:00+00:00" {
+		t.Errorf("after DisableAlarm = %+v, want only Enabled changed", stored)
+	}
+
+	if err := c.DeleteAlarm(t.Context(), "T1", added.AlarmID); err != nil {
+		t.Fatal(err)
+	}
+	if alarms, err := c.Alarms(t.Context(), "T1"); err != nil || len(alarms) != 0 {
+		t.Errorf("Alarms after delete = %+v, %v", alarms, err)
+	}
+	if err := c.DeleteAlarm(t.Context(), "T1", added.AlarmID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting it again = %v, want ErrNotFound", err)
+	}
+	if _, err := c.EnableAlarm(t.Context(), "T1", added.AlarmID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("enabling a deleted alarm = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAlarmTimeRoundTrip(t *testing.T) {
+	for _, s := range []string{"07:15-08:00", "23:59+14:00", "00:00-12:00", "06:45+05:30", "12:30+00:00"} {
+		at, err := ParseAlarmTime(s)
+		if err != nil {
+			t.Errorf("ParseAlarmTime(%q): %v", s, err)
+			continue
+		}
+		if got := at.String(); got != s {
+			t.Errorf("ParseAlarmTime(%q).String() = %q", s, got)
+		}
+	}
+	// Z is accepted, and written back as an explicit offset.
+	if at, err := ParseAlarmTime("07:15Z"); err != nil || at.String() != "07:15+00:00" {
+		t.Errorf("ParseAlarmTime(07:15Z) = %v, %v, want 07:15+00:00", at, err)
+	}
+	for _, s := range []string{"07:15", "07:15-8", "25:00+00:00"} {
+		if _, err := ParseAlarmTime(s); err == nil {
+			t.Errorf("ParseAlarmTime(%q) succeeded, want an error", s)
+		}
+	}
+}
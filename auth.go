@@ -0,0 +1,100 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// refreshingTokenSource refreshes the token when it expires, or early
+// through forceRefresh.
+type refreshingTokenSource struct {
+	cfg *oauth2.Config
+	// save, if set, persists refreshed tokens. A token that fails to save
+	// is saved again on the next call to Token.
+	save func(*oauth2.Token) error
+
+	mu      sync.Mutex
+	tok     *oauth2.Token
+	unsaved bool
+}
+
+func newRefreshingTokenSource(cfg *oauth2.Config, tok *oauth2.Token) *refreshingTokenSource {
+	return &refreshingTokenSource{cfg: cfg, tok: tok}
+}
+
+func (s *refreshingTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tok.Valid() {
+		if s.unsaved {
+			s.persist()
+		}
+		return s.tok, nil
+	}
+	tok, err := s.cfg.TokenSource(context.Background(), s.tok).Token()
+	if err != nil {
+		return nil, err
+	}
+	s.tok = tok
+	s.persist()
+	return tok, nil
+}
+
+// persist saves s.tok, if s has a save func. s.mu must be held.
+func (s *refreshingTokenSource) persist() {
+	if s.save != nil {
+		s.unsaved = s.save(s.tok) != nil
+	}
+}
+
+// forceRefresh refreshes the token unless the rejected access token was
+// already replaced; Fitbit refresh tokens only work once.
+func (s *refreshingTokenSource) forceRefresh(ctx context.Context, rejected string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tok != nil && rejected != "" && s.tok.AccessToken != rejected {
+		return nil
+	}
+
+	var refreshToken string
+	if s.tok != nil {
+		refreshToken = s.tok.RefreshToken
+	}
+	// Without an access token the token is invalid, so the source has to
+	// refresh it.
+	tok, err := s.cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return err
+	}
+	s.tok = tok
+	s.persist()
+	return nil
+}
+
+// isInvalidGrant reports whether err rejects the refresh token, in
+// either the standard error field or Fitbit's errors array.
+func isInvalidGrant(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) {
+		return false
+	}
+	if retrieveErr.ErrorCode == "invalid_grant" {
+		return true
+	}
+	var body struct {
+		Errors []ErrorDetail `json:"errors"`
+	}
+	if json.Unmarshal(retrieveErr.Body, &body) != nil {
+		return false
+	}
+	for _, d := range body.Errors {
+		if d.ErrorType == "invalid_grant" {
+			return true
+		}
+	}
+	return false
+}
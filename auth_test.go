@@ -0,0 +1,209 @@
+package fitbit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// retryServer fakes Fitbit's API under /1 and its token endpoint,
+// counting the requests each gets.
+type retryServer struct {
+	api         http.HandlerFunc
+	tokenStatus int
+	tokenBody   string
+
+	apiHits, tokenHits atomic.Int32
+}
+
+func (s *retryServer) client(t *testing.T) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/1/", func(w http.ResponseWriter, r *http.Request) {
+		s.apiHits.Add(1)
+		s.api(w, r)
+	})
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		s.tokenHits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(s.tokenStatus)
+		io.WriteString(w, s.tokenBody)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := &oauth2.Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Endpoint: oauth2.Endpoint{
+			TokenURL:  srv.URL + "/oauth2/token",
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+	}
+	c := NewConfigSource(cfg).NewClient(&oauth2.Token{
+		AccessToken:  "old",
+		RefreshToken: "r1",
+		TokenType:    "Bearer",
+	})
+	c.BaseUrl = mustParseURL(t, srv.URL+"/1")
+	c.RetryUnauthorized = true
+	return c
+}
+
+const refreshedToken = `{"access_token":"new","refresh_token":"r2","expires_in":3600,"token_type":"Bearer"}`
+
+// unauthorizedUnless answers 401 unless the request carries the access
+// token tok.
+func unauthorizedUnless(tok string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+tok {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"errors":[{"errorType":"invalid_token","message":"Access token invalid"}]}`)
+			return
+		}
+		io.WriteString(w, `{"ok":true}`)
+	}
+}
+
+func TestRetryUnauthorizedRefreshes(t *testing.T) {
+	s := &retryServer{
+		api:         unauthorizedUnless("new"),
+		tokenStatus: http.StatusOK,
+		tokenBody:   refreshedToken,
+	}
+	c := s.client(t)
+
+	var got struct{ OK bool }
+	if err := c.get(t.Context(), "/user/-/profile.json", &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.OK {
+		t.Error("retried response wasn't decoded")
+	}
+	if n := s.apiHits.Load(); n != 2 {
+		t.Errorf("API got %d requests, want 2", n)
+	}
+	if n := s.tokenHits.Load(); n != 1 {
+		t.Errorf("token endpoint got %d requests, want 1", n)
+	}
+	if c.tokens.tok.RefreshToken != "r2" {
+		t.Errorf("refresh token = %q, want r2", c.tokens.tok.RefreshToken)
+	}
+}
+
+func TestRetryUnauthorizedReplaysBody(t *testing.T) {
+	var bodies []string
+	s := &retryServer{
+		api: func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			unauthorizedUnless("new")(w, r)
+		},
+		tokenStatus: http.StatusOK,
+		tokenBody:   refreshedToken,
+	}
+	c := s.client(t)
+
+	params := map[string][]string{"amount": {"250"}, "unit": {"ml"}}
+	if err := c.postForm(t.Context(), "", "/user/-/foods/log/water.json", params, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] || bodies[1] != "amount=250&unit=ml" {
+		t.Errorf("bodies = %q, want the form sent twice", bodies)
+	}
+}
+
+func TestRetryUnauthorizedRejectedAgain(t *testing.T) {
+	s := &retryServer{
+		api:         unauthorizedUnless("never"),
+		tokenStatus: http.StatusOK,
+		tokenBody:   refreshedToken,
+	}
+	c := s.client(t)
+
+	err := c.get(t.Context(), "/user/-/profile.json", nil)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if errors.Is(err, ErrReauthorizationRequired) {
+		t.Errorf("err = %v, shouldn't match ErrReauthorizationRequired", err)
+	}
+	if n := s.apiHits.Load(); n != 2 {
+		t.Errorf("API got %d requests, want 2", n)
+	}
+	if n := s.tokenHits.Load(); n != 1 {
+		t.Errorf("token endpoint got %d requests, want 1", n)
+	}
+}
+
+func TestRetryUnauthorizedInvalidGrant(t *testing.T) {
+	s := &retryServer{
+		api:         unauthorizedUnless("new"),
+		tokenStatus: http.StatusBadRequest,
+		tokenBody:   `{"errors":[{"errorType":"invalid_grant","message":"Refresh token invalid: r1. Visit https://dev.fitbit.com/docs/oauth2 for more information on the Fitbit Web API authorization process."}],"success":false}`,
+	}
+	c := s.client(t)
+
+	err := c.get(t.Context(), "/user/-/profile.json", nil)
+	if !errors.Is(err, ErrUnauthorized) || !errors.Is(err, ErrReauthorizationRequired) {
+		t.Fatalf("err = %v, want ErrUnauthorized and ErrReauthorizationRequired", err)
+	}
+	if n := s.apiHits.Load(); n != 1 {
+		t.Errorf("API got %d requests, want 1", n)
+	}
+}
+
+func TestRetryUnauthorizedTransientRefreshFailure(t *testing.T) {
+	s := &retryServer{
+		api:         unauthorizedUnless("new"),
+		tokenStatus: http.StatusInternalServerError,
+		tokenBody:   `{"errors":[{"errorType":"system","message":"An error occurred."}]}`,
+	}
+	c := s.client(t)
+
+	err := c.get(t.Context(), "/user/-/profile.json", nil)
+	if err == nil {
+		t.Fatal("err = nil")
+	}
+	if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrReauthorizationRequired) {
+		t.Errorf("err = %v, a transient failure shouldn't ask for reauthorization", err)
+	}
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) {
+		t.Errorf("err = %v, want it to wrap the *oauth2.RetrieveError", err)
+	}
+}
+
+func TestRetryUnauthorizedUnreplayableBody(t *testing.T) {
+	s := &retryServer{
+		api:         unauthorizedUnless("new"),
+		tokenStatus: http.StatusOK,
+		tokenBody:   refreshedToken,
+	}
+	c := s.client(t)
+
+	req, err := c.NewRequest("POST", "/user/-/foods/log/water.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Body = io.NopCloser(strings.NewReader("amount=250"))
+	req.GetBody = nil
+	req.ContentLength = -1
+
+	_, err = c.Do(req, nil)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if !strings.Contains(err.Error(), "can't be replayed") {
+		t.Errorf("err = %v, want it to say why the retry was skipped", err)
+	}
+	if n := s.tokenHits.Load(); n != 0 {
+		t.Errorf("token endpoint got %d requests, want 0", n)
+	}
+}
@@ -0,0 +1,89 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// Badge is a badge the user earned. What Value counts depends on the
+// category, e.g. steps for daily steps badges and distance for lifetime
+// distance ones.
+type Badge struct {
+	EncodedID        string  `json:"encodedId"`
+	BadgeType        string  `json:"badgeType"` // e.g. DAILY_STEPS
+	Category         string  `json:"category"`
+	Name             string  `json:"name"`
+	ShortName        string  `json:"shortName"`
+	Description      string  `json:"description"`
+	ShortDescription string  `json:"shortDescription"`
+	EarnedMessage    string  `json:"earnedMessage"`
+	ShareText        string  `json:"shareText"`
+	DateTime         Date    `json:"dateTime"` // when last earned
+	TimesAchieved    int     `json:"timesAchieved"`
+	Value            float64 `json:"value"`
+	Unit             string  `json:"unit"`
+
+	// Images maps image sizes in pixels to the badge's image URL at that
+	// size, decoded from Fitbit's image50px, image75px, ... fields.
+	Images map[int]string `json:"-"`
+	// ShareImage is the 640px image meant for sharing.
+	ShareImage string `json:"shareImage640px"`
+}
+
+func (b *Badge) UnmarshalJSON(data []byte) error {
+	type badge Badge
+	if err := json.Unmarshal(data, (*badge)(b)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	b.Images = make(map[int]string)
+	for k, v := range fields {
+		if !strings.HasPrefix(k, "image") || !strings.HasSuffix(k, "px") {
+			continue
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(k, "image"), "px"))
+		if err != nil {
+			continue
+		}
+		var u string
+		if err := json.Unmarshal(v, &u); err != nil {
+			return err
+		}
+		b.Images[size] = u
+	}
+	return nil
+}
+
+// Badges returns the user's badges.
+func (c *Client) Badges(ctx context.Context) ([]Badge, error) {
+	return c.badges(ctx, "-")
+}
+
+// BadgesForUser returns the badges of the user with the given encoded
+// id, typically a friend. Users whose privacy settings hide their badges
+// result in an error matching ErrForbidden.
+func (c *Client) BadgesForUser(ctx context.Context, userID string) ([]Badge, error) {
+	return c.badges(ctx, url.PathEscape(userID))
+}
+
+func (c *Client) badges(ctx context.Context, userID string) ([]Badge, error) {
+	var resp struct {
+		Badges []Badge `json:"badges"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/user/%s/badges.json", userID), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Badges == nil {
+		resp.Badges = []Badge{}
+	}
+	return resp.Badges, nil
+}
@@ -0,0 +1,72 @@
+package fitbit
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBadges(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/badges.json", serveFixture(t, "badges.json"))
+	c := newTestClient(t, mux)
+
+	badges, err := c.Badges(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(badges) != 3 {
+		t.Fatalf("got %d badges, want 3", len(badges))
+	}
+
+	steps := badges[0]
+	if steps.BadgeType != "DAILY_STEPS" || steps.Category != "Daily Steps" || steps.ShortName != "Urban Boot" ||
+		steps.DateTime != (Date{2021, 10, 23}) || steps.TimesAchieved != 34 || steps.Value != 15000 || steps.Unit != "" {
+		t.Errorf("daily steps badge = %+v", steps)
+	}
+	if len(steps.Images) != 5 {
+		t.Errorf("got %d images, want 5: %v", len(steps.Images), steps.Images)
+	}
+	for size, suffix := range map[int]string{
+		50:  "/badges_new/badge_daily_steps15k.png",
+		75:  "/75px/badge_daily_steps15k.png",
+		300: "/300px/badge_daily_steps15k.png",
+	} {
+		if u := steps.Images[size]; len(u) < len(suffix) || u[len(u)-len(suffix):] != suffix {
+			t.Errorf("Images[%d] = %q, want it to end in %q", size, u, suffix)
+		}
+	}
+	if steps.ShareImage == "" || steps.Images[640] != "" {
+		t.Errorf("ShareImage = %q, Images[640] = %q: the share image belongs in ShareImage only", steps.ShareImage, steps.Images[640])
+	}
+
+	// Lifetime distance badges count in a unit, and weight goal ones
+	// count nothing.
+	if d := badges[1]; d.BadgeType != "LIFETIME_DISTANCE" || d.Value != 1600 || d.Unit != "KILOMETERS" {
+		t.Errorf("lifetime distance badge = %+v", d)
+	}
+	if w := badges[2]; w.Category != "Weight Goal" || w.Value != 0 || w.TimesAchieved != 1 || w.ShareText == "" {
+		t.Errorf("weight goal badge = %+v", w)
+	}
+}
+
+func TestBadgesEmpty(t *testing.T) {
+	c := newTestClient(t, &requestRecorder{Response: `{"badges":[]}`})
+	badges, err := c.Badges(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if badges == nil || len(badges) != 0 {
+		t.Errorf("badges = %#v, want an empty slice", badges)
+	}
+}
+
+func TestBadgesForUserEscapesID(t *testing.T) {
+	rec := &requestRecorder{Response: `{"badges":[]}`}
+	c := newTestClient(t, rec)
+	if _, err := c.BadgesForUser(t.Context(), "22B/../x"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.last(t).URL.EscapedPath(), "/1/user/22B%2F..%2Fx/badges.json"; got != want {
+		t.Errorf("path = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package fitbit
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// defaultBatchConcurrency is how many calls of a batch run at once
+// unless Batch.Concurrency says otherwise.
+const defaultBatchConcurrency = 4
+
+// Batch runs several calls concurrently, e.g. the requests behind one
+// dashboard. Each call stores its own result, typically through a
+// variable it closes over:
+//
+//	var devices []Device
+//	b := c.Batch(ctx)
+//	b.Add(func(ctx context.Context) (err error) {
+//		devices, err = c.Devices(ctx)
+//		return err
+//	})
+//	...
+//	errs := b.Run()
+type Batch struct {
+	// Concurrency bounds how many calls run at once; 0 means 4.
+	Concurrency int
+
+	client *Client
+	ctx    context.Context
+	calls  []func(context.Context) error
+}
+
+// Batch returns an empty batch whose calls are bound to ctx.
+func (c *Client) Batch(ctx context.Context) *Batch {
+	return &Batch{client: c, ctx: ctx}
+}
+
+// Add adds call to the batch and returns its index in Run's result.
+func (b *Batch) Add(call func(ctx context.Context) error) int {
+	b.calls = append(b.calls, call)
+	return len(b.calls) - 1
+}
+
+// Run runs the batch's calls and returns their errors in the order they
+// were added. Once the client's rate limit is exhausted, or ctx ends,
+// calls not yet started are skipped with that error.
+func (b *Batch) Run() []error {
+	errs := make([]error, len(b.calls))
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	var (
+		mu          sync.Mutex
+		rateLimited error
+		wg          sync.WaitGroup
+		sem         = make(chan struct{}, concurrency)
+	)
+	for i, call := range b.calls {
+		sem <- struct{}{}
+		mu.Lock()
+		skip := rateLimited
+		mu.Unlock()
+		if skip == nil {
+			skip = b.client.rateLimit.blocked(time.Now())
+		}
+		if skip == nil {
+			skip = b.ctx.Err()
+		}
+		if skip != nil {
+			<-sem
+			errs[i] = skip
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, call func(context.Context) error) {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			err := call(b.ctx)
+			errs[i] = err
+			if errors.Is(err, ErrRateLimited) {
+				b.client.rateLimit.limited(err, time.Now())
+				mu.Lock()
+				rateLimited = err
+				mu.Unlock()
+			}
+		}(i, call)
+	}
+	wg.Wait()
+	return errs
+}
+
+// rateGate holds back a client's batches while its rate limit is
+// exhausted.
+type rateGate struct {
+	mu    sync.Mutex
+	until time.Time
+	err   error
+}
+
+// limited blocks calls for the Retry-After period of err, a rate limit
+// error seen at now.
+func (g *rateGate) limited(err error, now time.Time) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := now.Add(apiErr.RetryAfter); until.After(g.until) {
+		g.until, g.err = until, err
+	}
+}
+
+// blocked returns the rate limit error calls are blocked by at now, or
+// nil if they aren't.
+func (g *rateGate) blocked(now time.Time) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if now.Before(g.until) {
+		return g.err
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package fitbit
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// rateLimitedHandler answers every request with a 429, with the given
+// Retry-After header if it isn't "", counting the requests.
+func rateLimitedHandler(hits *atomic.Int32, retryAfter string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{"errors": []ErrorDetail{{
+			ErrorType: "system",
+			Message:   "Too Many Requests",
+		}}})
+	}
+}
+
+func getCall(c *Client) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return c.get(ctx, "/user/-/profile.json", nil)
+	}
+}
+
+func TestBatchRunsCalls(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/1/user/-/missing.json" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{}`))
+	}))
+
+	b := c.Batch(t.Context())
+	b.Add(getCall(c))
+	missing := b.Add(func(ctx context.Context) error {
+		return c.get(ctx, "/user/-/missing.json", nil)
+	})
+	b.Add(getCall(c))
+	errs := b.Run()
+	for i, err := range errs {
+		if (i == missing) != errors.Is(err, ErrNotFound) {
+			t.Errorf("errs[%d] = %v", i, err)
+		}
+	}
+}
+
+func TestBatchCanceled(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, rateLimitedHandler(&hits, "60"))
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	b := c.Batch(ctx)
+	b.Add(getCall(c))
+	for _, err := range b.Run() {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("server got %d requests, want 0", n)
+	}
+}
+
+func TestBatchRateLimitIsShared(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, rateLimitedHandler(&hits, "60"))
+
+	b := c.Batch(t.Context())
+	b.Concurrency = 1
+	for range 3 {
+		b.Add(getCall(c))
+	}
+	for i, err := range b.Run() {
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("errs[%d] = %v, want ErrRateLimited", i, err)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("server got %d requests, want 1", n)
+	}
+
+	// Later batches, including DailyVitals', wait out the Retry-After.
+	vitals, err := c.DailyVitals(t.Context(), Date{2021, 10, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vitals.Errors) != 4 {
+		t.Errorf("Errors = %v, want all 4 metrics", vitals.Errors)
+	}
+	for metric, err := range vitals.Errors {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Minute {
+			t.Errorf("Errors[%s] = %v, want the 429", metric, err)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server got %d requests, want still 1", n)
+	}
+
+	// Once it has passed, calls are made again.
+	c.rateLimit.mu.Lock()
+	c.rateLimit.until = time.Now().Add(-time.Second)
+	c.rateLimit.mu.Unlock()
+	b = c.Batch(t.Context())
+	b.Add(getCall(c))
+	b.Run()
+	if n := hits.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+}
+
+func TestBatchRateLimitWithoutRetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, rateLimitedHandler(&hits, ""))
+
+	for range 2 {
+		b := c.Batch(t.Context())
+		b.Concurrency = 1
+		b.Add(getCall(c))
+		b.Add(getCall(c))
+		b.Run()
+	}
+	// Each batch skips its second call, but with no period to wait out,
+	// the second batch still makes its first.
+	if n := hits.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+}
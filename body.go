@@ -0,0 +1,588 @@
+package fitbit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// maxBodyLogRangeDays is the longest span Fitbit allows for a single
+// body log range request.
+const maxBodyLogRangeDays = 31
+
+// bodyLogPeriods are the periods the body log endpoints accept.
+var bodyLogPeriods = map[Period]bool{
+	Period1Day:   true,
+	Period7Days:  true,
+	Period30Days: true,
+	Period1Week:  true,
+	Period1Month: true,
+}
+
+// WeightLog is a single weigh-in.
+type WeightLog struct {
+	LogID  int64   `json:"logId"`
+	Weight float64 `json:"weight"`
+	BMI    float64 `json:"bmi"`
+	// Fat is the body fat percentage, when the weigh-in recorded one.
+	Fat  *float64 `json:"fat"`
+	Date Date     `json:"date"`
+	Time string   `json:"time"` // 23:59:59
+	// Source is where the entry came from, e.g. "API", "Aria" or
+	// "Web".
+	Source string `json:"source"`
+}
+
+// WeightLogList holds weigh-ins along with the unit system their
+// weights are in: kilograms for metric, pounds for UnitSystemUS and
+// stone for UnitSystemUK.
+type WeightLogList struct {
+	Units UnitSystem
+	Logs  []WeightLog
+}
+
+// bodyLogQuery selects body logs by a single date, by period ending on
+// date, or by the range date through end.
+type bodyLogQuery struct {
+	date   Date
+	period Period
+	end    Date
+}
+
+// urls returns the URLs to fetch query's logs of kind ("weight" or
+// "fat") from, chunking ranges longer than Fitbit allows.
+func (q bodyLogQuery) urls(kind string) ([]string, error) {
+	switch {
+	case q.period != "":
+		if !bodyLogPeriods[q.period] {
+			return nil, fmt.Errorf("fitbit: unsupported body log period %q", q.period)
+		}
+		return []string{
+			fmt.Sprintf("/user/-/body/log/%s/date/%s/%s.json", kind, q.date, q.period),
+		}, nil
+	case !q.end.IsZero():
+		chunks, err := splitDateRange(q.date, q.end, maxBodyLogRangeDays)
+		if err != nil {
+			return nil, err
+		}
+		urls := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			urls[i] = fmt.Sprintf("/user/-/body/log/%s/date/%s/%s.json", kind, chunk.Start, chunk.End)
+		}
+		return urls, nil
+	default:
+		return []string{fmt.Sprintf("/user/-/body/log/%s/date/%s.json", kind, q.date)}, nil
+	}
+}
+
+// getBodyLogs fetches query's logs of kind, calling decode with each
+// URL in date order.
+func (c *Client) getBodyLogs(kind string, q bodyLogQuery, decode func(urlStr string) error) error {
+	urls, err := q.urls(kind)
+	if err != nil {
+		return err
+	}
+	for _, urlStr := range urls {
+		if err := decode(urlStr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WeightLogs returns the weigh-ins logged on date. A day may have any
+// number of them.
+func (c *Client) WeightLogs(ctx context.Context, date Date) (WeightLogList, error) {
+	return c.weightLogs(ctx, bodyLogQuery{date: date})
+}
+
+// WeightLogsPeriod returns the weigh-ins logged in period ending on
+// date. period must be one of 1d, 7d, 30d, 1w or 1m.
+func (c *Client) WeightLogsPeriod(ctx context.Context, date Date, period Period) (WeightLogList, error) {
+	return c.weightLogs(ctx, bodyLogQuery{date: date, period: period})
+}
+
+// WeightLogsRange returns the weigh-ins logged between start and end,
+// inclusive. Ranges longer than Fitbit's 31 day cap are fetched in
+// chunks.
+func (c *Client) WeightLogsRange(ctx context.Context, start, end Date) (WeightLogList, error) {
+	return c.weightLogs(ctx, bodyLogQuery{date: start, end: end})
+}
+
+// weightLogs fetches the weigh-ins selected by q, sorted by date and
+// time.
+func (c *Client) weightLogs(ctx context.Context, q bodyLogQuery) (WeightLogList, error) {
+	list := WeightLogList{Units: c.unitSystem()}
+	err := c.getBodyLogs("weight", q, func(urlStr string) error {
+		var resp struct {
+			Weight []WeightLog `json:"weight"`
+		}
+		if err := c.get(ctx, urlStr, &resp); err != nil {
+			return err
+		}
+		list.Logs = append(list.Logs, resp.Weight...)
+		return nil
+	})
+	if err != nil {
+		return list, err
+	}
+
+	sort.SliceStable(list.Logs, func(i, j int) bool {
+		a, b := list.Logs[i], list.Logs[j]
+		if a.Date != b.Date {
+			return a.Date.Before(b.Date)
+		}
+		return a.Time < b.Time
+	})
+	return list, nil
+}
+
+// FatLog is a single body fat measurement.
+type FatLog struct {
+	LogID int64 `json:"logId"`
+	// Fat is the body fat percentage.
+	Fat  float64 `json:"fat"`
+	Date Date    `json:"date"`
+	Time string  `json:"time"` // 23:59:59
+	// Source is as for WeightLog; "Aria" for scale readings.
+	Source string `json:"source"`
+}
+
+// FatLogs returns the body fat measurements logged on date.
+func (c *Client) FatLogs(ctx context.Context, date Date) ([]FatLog, error) {
+	return c.fatLogs(ctx, bodyLogQuery{date: date})
+}
+
+// FatLogsPeriod returns the body fat measurements logged in period
+// ending on date. period must be one of 1d, 7d, 30d, 1w or 1m.
+func (c *Client) FatLogsPeriod(ctx context.Context, date Date, period Period) ([]FatLog, error) {
+	return c.fatLogs(ctx, bodyLogQuery{date: date, period: period})
+}
+
+// FatLogsRange returns the body fat measurements logged between start
+// and end, inclusive. Ranges longer than Fitbit's 31 day cap are fetched
+// in chunks.
+func (c *Client) FatLogsRange(ctx context.Context, start, end Date) ([]FatLog, error) {
+	return c.fatLogs(ctx, bodyLogQuery{date: start, end: end})
+}
+
+// fatLogs fetches the body fat measurements selected by q, sorted by
+// date and time.
+func (c *Client) fatLogs(ctx context.Context, q bodyLogQuery) ([]FatLog, error) {
+	var logs []FatLog
+	err := c.getBodyLogs("fat", q, func(urlStr string) error {
+		var resp struct {
+			Fat []FatLog `json:"fat"`
+		}
+		if err := c.get(ctx, urlStr, &resp); err != nil {
+			return err
+		}
+		logs = append(logs, resp.Fat...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].Date != logs[j].Date {
+			return logs[i].Date.Before(logs[j].Date)
+		}
+		return logs[i].Time < logs[j].Time
+	})
+	return logs, nil
+}
+
+// NewWeightLog is a weigh-in to log.
+type NewWeightLog struct {
+	// Weight is in the unit system passed to LogWeight.
+	Weight float64
+	Date   Date
+	// Time is the optional time of day of the weigh-in, as HH:mm:ss.
+	Time string
+}
+
+func (l NewWeightLog) params() (url.Values, error) {
+	if l.Weight <= 0 {
+		return nil, errors.New("fitbit: weight must be positive")
+	}
+	if l.Date.IsZero() {
+		return nil, errors.New("fitbit: weight log date is required")
+	}
+	if isFutureDate(l.Date) {
+		return nil, fmt.Errorf("fitbit: weight log date %s is in the future", l.Date)
+	}
+
+	params := url.Values{
+		"weight": {strconv.FormatFloat(l.Weight, 'f', -1, 64)},
+		"date":   {l.Date.String()},
+	}
+	if l.Time != "" {
+		if _, err := time.Parse("15:04:05", l.Time); err != nil {
+			return nil, fmt.Errorf("fitbit: weight log time %q is not HH:mm:ss", l.Time)
+		}
+		params.Set("time", l.Time)
+	}
+	return params, nil
+}
+
+// LogWeight logs a weigh-in, with its weight in units. units is
+// required, since a bare weight of 80 is only kilograms or pounds
+// according to the request's unit system; it applies to this request
+// only, regardless of c.UnitSystem. The created log includes the BMI
+// Fitbit computed for it.
+func (c *Client) LogWeight(ctx context.Context, l NewWeightLog, units UnitSystem) (WeightLog, error) {
+	var resp struct {
+		WeightLog WeightLog `json:"weightLog"`
+	}
+	if err := units.validate(); err != nil {
+		return resp.WeightLog, err
+	}
+	params, err := l.params()
+	if err != nil {
+		return resp.WeightLog, err
+	}
+
+	err = c.postForm(ctx, "", "/user/-/body/log/weight.json", params, &resp, withUnits(units))
+	return resp.WeightLog, err
+}
+
+// ErrAriaLogNotDeletable matches (with errors.Is) the error
+// DeleteWeightLog returns for weigh-ins recorded by an Aria scale, which
+// Fitbit doesn't allow to be deleted through the API.
+var ErrAriaLogNotDeletable = errors.New("fitbit: weight logs recorded by an Aria scale can't be deleted")
+
+// DeleteWeightLog deletes the weigh-in with the given id. It returns an
+// error matching ErrNotFound if there is no such log (or it belongs to
+// someone else), and one matching ErrAriaLogNotDeletable if it was
+// recorded by a scale.
+func (c *Client) DeleteWeightLog(ctx context.Context, logID int64) error {
+	err := c.delete(ctx, "", fmt.Sprintf("/user/-/body/log/weight/%d.json", logID))
+	// Fitbit refuses to delete a scale's weigh-in with a validation error
+	// on logId.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && apiErr.hasError("validation", "logId") {
+		return fmt.Errorf("%w: %w", ErrAriaLogNotDeletable, apiErr)
+	}
+	return err
+}
+
+// Fitbit's bounds for a body fat percentage.
+const (
+	minBodyFat = 1
+	maxBodyFat = 75
+)
+
+// NewFatLog is a body fat measurement to log.
+type NewFatLog struct {
+	// Fat is the body fat percentage, between 1 and 75.
+	Fat  float64
+	Date Date
+	// Time is the optional time of day of the measurement, as
+	// HH:mm:ss. When it's empty Fitbit records the measurement at the
+	// last second of the day, 23:59:59.
+	Time string
+}
+
+func (l NewFatLog) params() (url.Values, error) {
+	if l.Fat < minBodyFat || l.Fat > maxBodyFat {
+		return nil, fmt.Errorf("fitbit: body fat must be between %d and %d percent", minBodyFat, maxBodyFat)
+	}
+	if l.Date.IsZero() {
+		return nil, errors.New("fitbit: body fat log date is required")
+	}
+
+	params := url.Values{
+		"fat":  {strconv.FormatFloat(l.Fat, 'f', -1, 64)},
+		"date": {l.Date.String()},
+	}
+	if l.Time != "" {
+		if _, err := time.Parse("15:04:05", l.Time); err != nil {
+			return nil, fmt.Errorf("fitbit: body fat log time %q is not HH:mm:ss", l.Time)
+		}
+		params.Set("time", l.Time)
+	}
+	return params, nil
+}
+
+// LogBodyFat logs a body fat measurement. Body fat is a percentage, so
+// unlike LogWeight it takes no unit system.
+func (c *Client) LogBodyFat(ctx context.Context, l NewFatLog) (FatLog, error) {
+	var resp struct {
+		FatLog FatLog `json:"fatLog"`
+	}
+	params, err := l.params()
+	if err != nil {
+		return resp.FatLog, err
+	}
+
+	err = c.postForm(ctx, "", "/user/-/body/log/fat.json", params, &resp)
+	return resp.FatLog, err
+}
+
+// DeleteFatLog deletes the body fat measurement with the given id. It
+// returns an error matching ErrNotFound if there is no such log.
+func (c *Client) DeleteFatLog(ctx context.Context, logID int64) error {
+	return c.delete(ctx, "", fmt.Sprintf("/user/-/body/log/fat/%d.json", logID))
+}
+
+// WeightGoalType is the direction of a weight goal.
+type WeightGoalType string
+
+const (
+	WeightGoalLose     WeightGoalType = "LOSE"
+	WeightGoalGain     WeightGoalType = "GAIN"
+	WeightGoalMaintain WeightGoalType = "MAINTAIN"
+)
+
+// WeightGoal is the user's weight goal. Weights are in Units.
+type WeightGoal struct {
+	Units       UnitSystem
+	GoalType    WeightGoalType
+	StartDate   Date
+	StartWeight float64
+	// Weight is the target weight.
+	Weight float64
+	// WeightThreshold is the tolerance around a MAINTAIN goal, when
+	// Fitbit reports one.
+	WeightThreshold *float64
+}
+
+type weightGoalResponse struct {
+	Goal struct {
+		GoalType        WeightGoalType `json:"goalType"`
+		StartDate       Date           `json:"startDate"`
+		StartWeight     float64        `json:"startWeight"`
+		Weight          *float64       `json:"weight"`
+		WeightThreshold *float64       `json:"weightThreshold"`
+	} `json:"goal"`
+}
+
+// goal converts the response, returning ErrNoGoal if it has no target
+// weight.
+func (r weightGoalResponse) goal(units UnitSystem) (WeightGoal, error) {
+	g := r.Goal
+	if g.Weight == nil {
+		return WeightGoal{Units: units}, ErrNoGoal
+	}
+	return WeightGoal{
+		Units:           units,
+		GoalType:        g.GoalType,
+		StartDate:       g.StartDate,
+		StartWeight:     g.StartWeight,
+		Weight:          *g.Weight,
+		WeightThreshold: g.WeightThreshold,
+	}, nil
+}
+
+// WeightGoal returns the user's weight goal, or ErrNoGoal if they
+// haven't set one.
+func (c *Client) WeightGoal(ctx context.Context) (WeightGoal, error) {
+	var resp weightGoalResponse
+	if err := c.get(ctx, "/user/-/body/log/weight/goal.json", &resp); err != nil {
+		return WeightGoal{Units: c.unitSystem()}, err
+	}
+	return resp.goal(c.unitSystem())
+}
+
+// WeightGoalUpdate holds the weight goal fields to change. Zero fields
+// are left out of the request.
+type WeightGoalUpdate struct {
+	StartDate   Date
+	StartWeight float64
+	// TargetWeight is the goal weight.
+	TargetWeight float64
+}
+
+func (u WeightGoalUpdate) params() (url.Values, error) {
+	if u.StartWeight < 0 || u.TargetWeight < 0 {
+		return nil, errors.New("fitbit: goal weights must be positive")
+	}
+
+	params := url.Values{}
+	if !u.StartDate.IsZero() {
+		params.Set("startDate", u.StartDate.String())
+	}
+	if u.StartWeight > 0 {
+		params.Set("startWeight", strconv.FormatFloat(u.StartWeight, 'f', -1, 64))
+	}
+	if u.TargetWeight > 0 {
+		params.Set("weight", strconv.FormatFloat(u.TargetWeight, 'f', -1, 64))
+	}
+	if len(params) == 0 {
+		return nil, errors.New("fitbit: weight goal update has no fields set")
+	}
+	return params, nil
+}
+
+// SetWeightGoal updates the user's weight goal, with the weights in u
+// given in units. As with LogWeight, units is required and applies to
+// this request only.
+func (c *Client) SetWeightGoal(ctx context.Context, u WeightGoalUpdate, units UnitSystem) (WeightGoal, error) {
+	if err := units.validate(); err != nil {
+		return WeightGoal{Units: units}, err
+	}
+	params, err := u.params()
+	if err != nil {
+		return WeightGoal{Units: units}, err
+	}
+
+	var resp weightGoalResponse
+	err = c.postForm(ctx, "", "/user/-/body/log/weight/goal.json", params, &resp, withUnits(units))
+	if err != nil {
+		return WeightGoal{Units: units}, err
+	}
+	return resp.goal(units)
+}
+
+// FatGoal is the user's body fat percentage goal.
+type FatGoal struct {
+	Fat float64
+}
+
+type fatGoalResponse struct {
+	Goal struct {
+		Fat *float64 `json:"fat"`
+	} `json:"goal"`
+}
+
+func (r fatGoalResponse) goal() (FatGoal, error) {
+	if r.Goal.Fat == nil {
+		return FatGoal{}, ErrNoGoal
+	}
+	return FatGoal{Fat: *r.Goal.Fat}, nil
+}
+
+// FatGoal returns the user's body fat goal, or ErrNoGoal if they
+// haven't set one.
+func (c *Client) FatGoal(ctx context.Context) (FatGoal, error) {
+	var resp fatGoalResponse
+	if err := c.get(ctx, "/user/-/body/log/fat/goal.json", &resp); err != nil {
+		return FatGoal{}, err
+	}
+	return resp.goal()
+}
+
+// SetFatGoal sets the user's body fat goal to percent, which must be
+// between 1 and 75.
+func (c *Client) SetFatGoal(ctx context.Context, percent float64) (FatGoal, error) {
+	if percent < minBodyFat || percent > maxBodyFat {
+		return FatGoal{}, fmt.Errorf("fitbit: body fat goal must be between %d and %d percent", minBodyFat, maxBodyFat)
+	}
+
+	var resp fatGoalResponse
+	params := url.Values{"fat": {strconv.FormatFloat(percent, 'f', -1, 64)}}
+	if err := c.postForm(ctx, "", "/user/-/body/log/fat/goal.json", params, &resp); err != nil {
+		return FatGoal{}, err
+	}
+	return resp.goal()
+}
+
+// maxBodySeriesRangeDays is the longest span Fitbit allows for a single
+// body time series range request.
+const maxBodySeriesRangeDays = 1095
+
+// BodyResource is a body time series resource.
+type BodyResource string
+
+const (
+	BodyResourceWeight BodyResource = "body/weight"
+	BodyResourceBMI    BodyResource = "body/bmi"
+	BodyResourceFat    BodyResource = "body/fat"
+)
+
+// BodySeriesPoint is one day of a body time series.
+type BodySeriesPoint struct {
+	DateTime Date
+	Value    float64
+	// Measured is set by MarkMeasured for days with an actual logged
+	// measurement. Fitbit fills days without one by carrying the last
+	// measured value forward.
+	Measured bool
+}
+
+// BodySeries is a body time series. Weights are in Units; BMI and fat
+// are unitless.
+type BodySeries struct {
+	Resource BodyResource
+	Units    UnitSystem
+	Points   []BodySeriesPoint
+}
+
+func (c *Client) bodySeries(resource BodyResource, points []rawSeriesPoint) (BodySeries, error) {
+	series := BodySeries{
+		Resource: resource,
+		Units:    c.unitSystem(),
+		Points:   make([]BodySeriesPoint, len(points)),
+	}
+	for i, p := range points {
+		v, err := p.float()
+		if err != nil {
+			return series, err
+		}
+		series.Points[i] = BodySeriesPoint{DateTime: p.DateTime, Value: v}
+	}
+	return series, nil
+}
+
+// BodyTimeSeries returns resource's time series for period ending on
+// date. Days without a measurement carry the previous value forward; use
+// MarkMeasured to tell them apart.
+func (c *Client) BodyTimeSeries(ctx context.Context, resource BodyResource, date Date, period Period) (BodySeries, error) {
+	points, err := c.timeSeries(ctx, string(resource), date, period)
+	if err != nil {
+		return BodySeries{Resource: resource, Units: c.unitSystem()}, err
+	}
+	return c.bodySeries(resource, points)
+}
+
+// BodyTimeSeriesRange returns resource's time series between start and
+// end, inclusive, fetching spans over Fitbit's 1095 day cap in chunks.
+// As with BodyTimeSeries, missing days carry the previous value forward.
+func (c *Client) BodyTimeSeriesRange(ctx context.Context, resource BodyResource, start, end Date) (BodySeries, error) {
+	points, err := c.timeSeriesRange(ctx, string(resource), start, end, maxBodySeriesRangeDays)
+	if err != nil {
+		return BodySeries{Resource: resource, Units: c.unitSystem()}, err
+	}
+	return c.bodySeries(resource, points)
+}
+
+// MarkMeasured sets Measured on the points of s that have a logged
+// measurement, by fetching the weight logs (for weight and BMI series)
+// or fat logs (for fat series) over the series' span.
+func (c *Client) MarkMeasured(ctx context.Context, s *BodySeries) error {
+	if len(s.Points) == 0 {
+		return nil
+	}
+	start, end := s.Points[0].DateTime, s.Points[len(s.Points)-1].DateTime
+
+	measured := map[Date]bool{}
+	if s.Resource == BodyResourceFat {
+		logs, err := c.FatLogsRange(ctx, start, end)
+		if err != nil {
+			return err
+		}
+		for _, l := range logs {
+			measured[l.Date] = true
+		}
+	} else {
+		list, err := c.WeightLogsRange(ctx, start, end)
+		if err != nil {
+			return err
+		}
+		for _, l := range list.Logs {
+			measured[l.Date] = true
+		}
+	}
+
+	for i := range s.Points {
+		s.Points[i].Measured = measured[s.Points[i].DateTime]
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+package fitbit
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDeleteWeightLog(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		handler  http.Handler
+		wantErr  bool
+		aria     bool
+		notFound bool
+	}{
+		{"deleted", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}), false, false, false},
+		{"recorded by a scale", serveError(t, http.StatusBadRequest, "weight_log_aria.json"), true, true, false},
+		{"no such log", serveError(t, http.StatusNotFound, "weight_log_not_found.json"), true, false, true},
+		{"other bad request", serveError(t, http.StatusBadRequest, "invalid_request.json"), true, false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("DELETE /1/user/-/body/log/weight/1330991999000.json", tt.handler)
+			c := newTestClient(t, mux)
+			err := c.DeleteWeightLog(t.Context(), 1330991999000)
+			if (err != nil) != tt.wantErr ||
+				errors.Is(err, ErrAriaLogNotDeletable) != tt.aria ||
+				errors.Is(err, ErrNotFound) != tt.notFound {
+				t.Errorf("err = %v", err)
+			}
+			if tt.aria {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) {
+					t.Errorf("err = %v, want it to wrap the *APIError", err)
+				}
+			}
+		})
+	}
+}
+
+func TestLogWeightUnitsDontLeak(t *testing.T) {
+	for _, clientUnits := range []UnitSystem{"", UnitSystemUS} {
+		rec := &requestRecorder{Response: `{}`}
+		c := newTestClient(t, rec)
+		c.UnitSystem = clientUnits
+
+		if _, err := c.LogWeight(t.Context(), NewWeightLog{Weight: 80, Date: Date{2020, 2, 21}}, UnitSystemMetric); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.last(t).Header.Get("Accept-Language"); got != string(UnitSystemMetric) {
+			t.Errorf("client units %q: LogWeight Accept-Language = %q, want METRIC", clientUnits, got)
+		}
+
+		if _, err := c.WeightLogs(t.Context(), Date{2020, 2, 21}); err != nil {
+			t.Fatal(err)
+		}
+		var want []string
+		if clientUnits != "" {
+			want = []string{string(clientUnits)}
+		}
+		if got := rec.last(t).Header.Values("Accept-Language"); !equalStrings(got, want) {
+			t.Errorf("client units %q: later Accept-Language = %q, want %q", clientUnits, got, want)
+		}
+		if c.UnitSystem != clientUnits {
+			t.Errorf("UnitSystem = %q, want %q", c.UnitSystem, clientUnits)
+		}
+	}
+}
+
+// fakeBody fakes the weight and fat log endpoints for a user 180cm
+// tall, keeping weights in kilograms.
+type fakeBody struct {
+	weight fakeStore[int64, WeightLog] // in kg
+	fat    fakeStore[int64, FatLog]
+}
+
+// kilogramsPer returns how many kilograms one unit of weight is in the
+// request's unit system.
+func kilogramsPer(r *http.Request) float64 {
+	switch UnitSystem(r.Header.Get("Accept-Language")) {
+	case UnitSystemUS:
+		return kilogramsPerPound
+	case UnitSystemUK:
+		return kilogramsPerStone
+	}
+	return 1
+}
+
+func (f *fakeBody) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/body/log/weight/date/{date}", func(w http.ResponseWriter, r *http.Request) {
+		date := strings.TrimSuffix(r.PathValue("date"), ".json")
+		list := f.weight.list(func(l WeightLog) bool { return l.Date.String() == date })
+		for i := range list {
+			list[i].Weight = math.Round(list[i].Weight/kilogramsPer(r)*100) / 100
+		}
+		writeJSON(w, http.StatusOK, map[string][]WeightLog{"weight": list})
+	})
+	mux.HandleFunc("GET /1/user/-/body/log/fat/date/{date}", func(w http.ResponseWriter, r *http.Request) {
+		date := strings.TrimSuffix(r.PathValue("date"), ".json")
+		writeJSON(w, http.StatusOK, map[string][]FatLog{"fat": f.fat.list(func(l FatLog) bool { return l.Date.String() == date })})
+	})
+	mux.HandleFunc("POST /1/user/-/body/log/weight.json", func(w http.ResponseWriter, r *http.Request) {
+		weight, err1 := strconv.ParseFloat(r.FormValue("weight"), 64)
+		date, err2 := ParseDate(r.FormValue("date"))
+		if errors.Join(err1, err2) != nil {
+			writeError(w, http.StatusBadRequest, "validation", "weight", "invalid weight log")
+			return
+		}
+		kg := weight * kilogramsPer(r)
+		l := WeightLog{LogID: f.weight.newID(), Weight: kg, BMI: ComputeBMI(kg, 180, UnitSystemMetric), Date: date, Time: "23:59:59", Source: "API"}
+		if t := r.FormValue("time"); t != "" {
+			l.Time = t
+		}
+		f.weight.put(l.LogID, l)
+		l.Weight = weight
+		writeJSON(w, http.StatusCreated, map[string]WeightLog{"weightLog": l})
+	})
+	mux.HandleFunc("POST /1/user/-/body/log/fat.json", func(w http.ResponseWriter, r *http.Request) {
+		fat, err1 := strconv.ParseFloat(r.FormValue("fat"), 64)
+		date, err2 := ParseDate(r.FormValue("date"))
+		if errors.Join(err1, err2) != nil {
+			writeError(w, http.StatusBadRequest, "validation", "fat", "invalid fat log")
+			return
+		}
+		l := FatLog{LogID: f.fat.newID(), Fat: fat, Date: date, Time: "23:59:59", Source: "API"}
+		if t := r.FormValue("time"); t != "" {
+			l.Time = t
+		}
+		f.fat.put(l.LogID, l)
+		writeJSON(w, http.StatusCreated, map[string]FatLog{"fatLog": l})
+	})
+	mux.HandleFunc("DELETE /1/user/-/body/log/weight/{id}", serveDelete(&f.weight, "logId", "Log not found"))
+	mux.HandleFunc("DELETE /1/user/-/body/log/fat/{id}", serveDelete(&f.fat, "logId", "Log not found"))
+	return mux
+}
+
+func TestBodyLogLifecycle(t *testing.T) {
+	f := &fakeBody{}
+	c := newTestClient(t, f.handler())
+	c.UnitSystem = UnitSystemMetric
+	day := Date{2021, 10, 25}
+
+	// Logged in pounds, read back in kilograms.
+	w, err := c.LogWeight(t.Context(), NewWeightLog{Weight: 176.37, Date: day, Time: "07:30:00"}, UnitSystemUS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.LogID == 0 || w.Weight != 176.37 || w.BMI != 24.7 || w.Time != "07:30:00" {
+		t.Errorf("logged weight = %+v", w)
+	}
+	fat, err := c.LogBodyFat(t.Context(), NewFatLog{Fat: 18.5, Date: day})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fat.LogID == 0 || fat.Fat != 18.5 || fat.Time != "23:59:59" {
+		t.Errorf("logged fat = %+v", fat)
+	}
+
+	weights, err := c.WeightLogs(t.Context(), day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights.Units != UnitSystemMetric || len(weights.Logs) != 1 || weights.Logs[0].Weight != 80 {
+		t.Errorf("WeightLogs = %+v, want one of 80kg", weights)
+	}
+	fats, err := c.FatLogs(t.Context(), day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fats) != 1 || fats[0].LogID != fat.LogID {
+		t.Errorf("FatLogs = %+v", fats)
+	}
+
+	if err := c.DeleteWeightLog(t.Context(), w.LogID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteFatLog(t.Context(), fat.LogID); err != nil {
+		t.Fatal(err)
+	}
+	if weights, err := c.WeightLogs(t.Context(), day); err != nil || len(weights.Logs) != 0 {
+		t.Errorf("WeightLogs after delete = %+v, %v", weights, err)
+	}
+	if fats, err := c.FatLogs(t.Context(), day); err != nil || len(fats) != 0 {
+		t.Errorf("FatLogs after delete = %+v, %v", fats, err)
+	}
+	if err := c.DeleteWeightLog(t.Context(), w.LogID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting the weight log again = %v, want ErrNotFound", err)
+	}
+	if err := c.DeleteFatLog(t.Context(), fat.LogID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting the fat log again = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFatLogs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/body/log/fat/date/2021-10-25.json", serveFixture(t, "fat_logs.json"))
+	c := newTestClient(t, mux)
+
+	logs, err := c.FatLogs(t.Context(), Date{2021, 10, 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sorted by time, the Aria weigh-in first.
+	want := []FatLog{
+		{LogID: 1635148324000, Fat: 19.1, Date: Date{2021, 10, 25}, Time: "07:52:04", Source: "Aria"},
+		{LogID: 1635179400000, Fat: 18.8, Date: Date{2021, 10, 25}, Time: "16:30:00", Source: "Web"},
+		{LogID: 1635206399000, Fat: 18.4, Date: Date{2021, 10, 25}, Time: "23:59:59", Source: "API"},
+	}
+	if !reflect.DeepEqual(logs, want) {
+		t.Errorf("FatLogs = %+v, want %+v", logs, want)
+	}
+}
+
+func TestMarkMeasured(t *testing.T) {
+	mux := http.NewServeMux()
+	// Fitbit carries the 73.0 of the 1st forward over the 2nd and 3rd, and
+	// the 72.4 of the 4th over the 5th.
+	mux.HandleFunc("GET /1/user/-/body/weight/date/2021-10-01/7d.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string][]map[string]string{"body-weight": {
+			{"dateTime": "2021-09-25", "value": "73.2"},
+			{"dateTime": "2021-09-26", "value": "73.0"},
+			{"dateTime": "2021-09-27", "value": "73.0"},
+			{"dateTime": "2021-09-28", "value": "73.0"},
+			{"dateTime": "2021-09-29", "value": "72.4"},
+			{"dateTime": "2021-09-30", "value": "72.4"},
+			{"dateTime": "2021-10-01", "value": "72.9"},
+		}})
+	})
+	mux.HandleFunc("GET /1/user/-/body/log/weight/date/2021-09-25/2021-10-01.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string][]map[string]interface{}{"weight": {
+			{"logId": 1, "date": "2021-09-25", "time": "07:00:00", "weight": 73.2},
+			{"logId": 2, "date": "2021-09-26", "time": "07:00:00", "weight": 73.0},
+			{"logId": 3, "date": "2021-09-29", "time": "07:10:00", "weight": 72.4},
+			// Two weigh-ins on the same day mark it once.
+			{"logId": 4, "date": "2021-10-01", "time": "07:00:00", "weight": 72.8},
+			{"logId": 5, "date": "2021-10-01", "time": "21:30:00", "weight": 73.0},
+		}})
+	})
+	c := newTestClient(t, mux)
+
+	series, err := c.BodyTimeSeries(t.Context(), BodyResourceWeight, Date{2021, 10, 1}, Period7Days)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkMeasured(t.Context(), &series); err != nil {
+		t.Fatal(err)
+	}
+	want := []bool{true, true, false, false, true, false, true}
+	if len(series.Points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(series.Points), len(want))
+	}
+	for i, p := range series.Points {
+		if p.Measured != want[i] {
+			t.Errorf("%s (%v): Measured = %t, want %t", p.DateTime, p.Value, p.Measured, want[i])
+		}
+	}
+	if p := series.Points[3]; p.Value != 73 {
+		t.Errorf("carried forward value = %v, want 73", p.Value)
+	}
+}
+
+func TestWeightLogsPeriodAndRange(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	// One weigh-in on the first and last day of each request's span, the
+	// later one first.
+	serveSpan := func(w http.ResponseWriter, r *http.Request, start, end Date) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string][]WeightLog{"weight": {
+			{LogID: 2, Weight: 72.5, Date: end, Time: "07:00:00", Source: "Aria"},
+			{LogID: 1, Weight: 73, Date: start, Time: "07:00:00", Source: "API"},
+		}})
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/body/log/weight/date/2021-10-25/7d.json", func(w http.ResponseWriter, r *http.Request) {
+		serveSpan(w, r, Date{2021, 10, 19}, Date{2021, 10, 25})
+	})
+	mux.HandleFunc("GET /1/user/-/body/log/weight/date/{start}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		start, err1 := ParseDate(r.PathValue("start"))
+		end, err2 := ParseDate(strings.TrimSuffix(r.PathValue("file"), ".json"))
+		if err1 != nil || err2 != nil {
+			http.Error(w, "bad range", http.StatusBadRequest)
+			return
+		}
+		serveSpan(w, r, start, end)
+	})
+	c := newTestClient(t, mux)
+	c.UnitSystem = UnitSystemMetric
+
+	list, err := c.WeightLogsPeriod(t.Context(), Date{2021, 10, 25}, Period7Days)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Units != UnitSystemMetric || len(list.Logs) != 2 || list.Logs[0].Date != (Date{2021, 10, 19}) || list.Logs[1].Source != "Aria" {
+		t.Errorf("WeightLogsPeriod = %+v", list)
+	}
+	if _, err := c.WeightLogsPeriod(t.Context(), Date{2021, 10, 25}, Period3Months); err == nil {
+		t.Error("WeightLogsPeriod accepted 3m")
+	}
+
+	requests = nil
+	list, err = c.WeightLogsRange(t.Context(), Date{2021, 9, 1}, Date{2021, 10, 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRequests := []string{
+		"/1/user/-/body/log/weight/date/2021-09-01/2021-10-01.json",
+		"/1/user/-/body/log/weight/date/2021-10-02/2021-10-25.json",
+	}
+	if !equalStrings(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+	var dates []Date
+	for _, l := range list.Logs {
+		dates = append(dates, l.Date)
+	}
+	wantDates := []Date{{2021, 9, 1}, {2021, 10, 1}, {2021, 10, 2}, {2021, 10, 25}}
+	if !reflect.DeepEqual(dates, wantDates) {
+		t.Errorf("WeightLogsRange dates = %v, want %v", dates, wantDates)
+	}
+}
+
+func TestWeightGoal(t *testing.T) {
+	for _, tt := range []struct {
+		name, body string
+		want       *WeightGoal // nil for ErrNoGoal
+	}{
+		{
+			"lose",
+			`{"goal":{"goalType":"LOSE","startDate":"2021-09-01","startWeight":180.2,"weight":165}}`,
+			&WeightGoal{Units: UnitSystemUS, GoalType: WeightGoalLose, StartDate: Date{2021, 9, 1}, StartWeight: 180.2, Weight: 165},
+		},
+		{
+			"maintain",
+			`{"goal":{"goalType":"MAINTAIN","startDate":"2021-09-01","startWeight":165,"weight":165,"weightThreshold":2}}`,
+			&WeightGoal{Units: UnitSystemUS, GoalType: WeightGoalMaintain, StartDate: Date{2021, 9, 1}, StartWeight: 165, Weight: 165, WeightThreshold: Float64(2)},
+		},
+		{"none", `{"goal":{}}`, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /1/user/-/body/log/weight/goal.json", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			})
+			c := newTestClient(t, mux)
+			c.UnitSystem = UnitSystemUS
+
+			goal, err := c.WeightGoal(t.Context())
+			if tt.want == nil {
+				if !errors.Is(err, ErrNoGoal) {
+					t.Errorf("WeightGoal = %+v, %v, want ErrNoGoal", goal, err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(goal, *tt.want) {
+				t.Errorf("WeightGoal = %+v, %v, want %+v", goal, err, *tt.want)
+			}
+		})
+	}
+}
+
+func TestSetWeightGoal(t *testing.T) {
+	rec := &requestRecorder{Response: `{"goal":{"goalType":"LOSE","startDate":"2021-10-01","startWeight":82.5,"weight":76}}`}
+	c := newTestClient(t, rec)
+	c.UnitSystem = UnitSystemUS
+
+	goal, err := c.SetWeightGoal(t.Context(), WeightGoalUpdate{StartDate: Date{2021, 10, 1}, StartWeight: 82.5, TargetWeight: 76}, UnitSystemMetric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goal.Units != UnitSystemMetric || goal.Weight != 76 || goal.GoalType != WeightGoalLose {
+		t.Errorf("goal = %+v", goal)
+	}
+	req := rec.last(t)
+	form, err := url.ParseQuery(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{"startDate": {"2021-10-01"}, "startWeight": {"82.5"}, "weight": {"76"}}
+	if req.Method != "POST" || req.URL.Path != "/1/user/-/body/log/weight/goal.json" || !reflect.DeepEqual(form, want) {
+		t.Errorf("sent %s %s with %v, want POST /1/user/-/body/log/weight/goal.json with %v", req.Method, req.URL.Path, form, want)
+	}
+	if got := req.Header.Get("Accept-Language"); got != string(UnitSystemMetric) {
+		t.Errorf("Accept-Language = %q, want %q", got, UnitSystemMetric)
+	}
+
+	for _, tt := range []struct {
+		u     WeightGoalUpdate
+		units UnitSystem
+	}{
+		{WeightGoalUpdate{TargetWeight: 76}, ""},
+		{WeightGoalUpdate{}, UnitSystemMetric},
+		{WeightGoalUpdate{TargetWeight: -1}, UnitSystemMetric},
+	} {
+		if _, err := c.SetWeightGoal(t.Context(), tt.u, tt.units); err == nil {
+			t.Errorf("SetWeightGoal(%+v, %q) succeeded", tt.u, tt.units)
+		}
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.reqs) != 1 {
+		t.Errorf("sent %d requests, want 1", len(rec.reqs))
+	}
+}
+
+func TestFatGoal(t *testing.T) {
+	var goal string // a JSON number, or "" for none
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/body/log/fat/goal.json", func(w http.ResponseWriter, r *http.Request) {
+		if goal == "" {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"goal": map[string]interface{}{}})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"goal":{"fat":` + goal + `}}`))
+	})
+	mux.HandleFunc("POST /1/user/-/body/log/fat/goal.json", func(w http.ResponseWriter, r *http.Request) {
+		goal = r.FormValue("fat")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"goal":{"fat":` + goal + `}}`))
+	})
+	c := newTestClient(t, mux)
+
+	if _, err := c.FatGoal(t.Context()); !errors.Is(err, ErrNoGoal) {
+		t.Fatalf("FatGoal before setting one = %v, want ErrNoGoal", err)
+	}
+	set, err := c.SetFatGoal(t.Context(), 18.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Fat != 18.5 || goal != "18.5" {
+		t.Errorf("SetFatGoal = %+v, sent %q", set, goal)
+	}
+	if got, err := c.FatGoal(t.Context()); err != nil || got.Fat != 18.5 {
+		t.Errorf("FatGoal = %+v, %v, want 18.5", got, err)
+	}
+
+	for _, percent := range []float64{0, 0.5, 75.5, -10} {
+		if _, err := c.SetFatGoal(t.Context(), percent); err == nil {
+			t.Errorf("SetFatGoal(%v) succeeded", percent)
+		}
+	}
+	if goal != "18.5" {
+		t.Errorf("goal = %q after rejected updates, want 18.5", goal)
+	}
+}
@@ -0,0 +1,122 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"golang.org/x/net/context"
+)
+
+// BreathingRate is the average breathing rate, in breaths per minute,
+// during the sleep attributed to DateTime.
+type BreathingRate struct {
+	DateTime Date               `json:"dateTime"`
+	Value    BreathingRateValue `json:"value"`
+}
+
+type BreathingRateValue struct {
+	BreathingRate float64 `json:"breathingRate"`
+}
+
+type breathingRateResponse struct {
+	BR []BreathingRate `json:"br"`
+}
+
+// BreathingRateByDate returns the breathing rate summary for date. It
+// returns nil (and no error) when there is no reading for that night.
+func (c *Client) BreathingRateByDate(ctx context.Context, date Date) (*BreathingRate, error) {
+	var resp breathingRateResponse
+	err := c.get(ctx, fmt.Sprintf("/user/-/br/date/%s.json", date), &resp)
+	if err != nil || len(resp.BR) == 0 {
+		return nil, err
+	}
+	return &resp.BR[0], nil
+}
+
+// BreathingRateRange returns the breathing rate summaries between start
+// and end, inclusive, sorted by date. Ranges longer than Fitbit's 30 day
+// cap are fetched in chunks, and nights without a reading are absent
+// from the result.
+func (c *Client) BreathingRateRange(ctx context.Context, start, end Date) ([]BreathingRate, error) {
+	var entries []BreathingRate
+	err := c.getWellnessRange(ctx, "br", start, end, func(urlStr string) error {
+		var resp breathingRateResponse
+		if err := c.get(ctx, urlStr, &resp); err != nil {
+			return err
+		}
+		entries = append(entries, resp.BR...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DateTime.Before(entries[j].DateTime)
+	})
+	return entries, nil
+}
+
+// IntradayBreathingRate breaks a night's breathing rate down by sleep
+// stage. A stage is nil when Fitbit didn't have enough data in it,
+// which it reports either by omitting the stage or with a rate of -1.
+type IntradayBreathingRate struct {
+	DateTime   Date
+	DeepSleep  *float64
+	REMSleep   *float64
+	LightSleep *float64
+	FullSleep  *float64
+}
+
+type stageBreathingRate struct {
+	BreathingRate float64 `json:"breathingRate"`
+}
+
+// rate returns the stage's breathing rate, or nil if it's missing or
+// reported as -1.
+func (s *stageBreathingRate) rate() *float64 {
+	if s == nil || s.BreathingRate < 0 {
+		return nil
+	}
+	r := s.BreathingRate
+	return &r
+}
+
+func (r *IntradayBreathingRate) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		DateTime Date `json:"dateTime"`
+		Value    struct {
+			DeepSleepSummary  *stageBreathingRate `json:"deepSleepSummary"`
+			RemSleepSummary   *stageBreathingRate `json:"remSleepSummary"`
+			LightSleepSummary *stageBreathingRate `json:"lightSleepSummary"`
+			FullSleepSummary  *stageBreathingRate `json:"fullSleepSummary"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*r = IntradayBreathingRate{
+		DateTime:   raw.DateTime,
+		DeepSleep:  raw.Value.DeepSleepSummary.rate(),
+		REMSleep:   raw.Value.RemSleepSummary.rate(),
+		LightSleep: raw.Value.LightSleepSummary.rate(),
+		FullSleep:  raw.Value.FullSleepSummary.rate(),
+	}
+	return nil
+}
+
+// IntradayBreathingRate returns the per sleep stage breathing rates for
+// date. It returns nil (and no error) when there is no reading for that
+// night.
+func (c *Client) IntradayBreathingRate(ctx context.Context, date Date) (*IntradayBreathingRate, error) {
+	var resp struct {
+		BR []IntradayBreathingRate `json:"br"`
+	}
+	err := c.get(ctx, fmt.Sprintf("/user/-/br/date/%s/all.json", date), &resp)
+	if err != nil || len(resp.BR) == 0 {
+		return nil, err
+	}
+	return &resp.BR[0], nil
+}
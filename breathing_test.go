@@ -0,0 +1,128 @@
+package fitbit
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIntradayBreathingRate(t *testing.T) {
+	rate := func(r float64) *float64 { return &r }
+	tests := []struct {
+		fixture string
+		date    Date
+		want    IntradayBreathingRate
+	}{
+		{"br_intraday.json", Date{2021, 10, 25}, IntradayBreathingRate{
+			DateTime:   Date{2021, 10, 25},
+			DeepSleep:  rate(15.2),
+			REMSleep:   rate(17.6),
+			LightSleep: rate(16.4),
+			FullSleep:  rate(16.8),
+		}},
+		// Deep sleep is left out and REM reported as -1: both are missing.
+		{"br_intraday_partial.json", Date{2021, 10, 26}, IntradayBreathingRate{
+			DateTime:   Date{2021, 10, 26},
+			LightSleep: rate(15.4),
+			FullSleep:  rate(15.9),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("GET /1/user/-/br/date/"+tt.date.String()+"/all.json", serveFixture(t, tt.fixture))
+			c := newTestClient(t, mux)
+
+			got, err := c.IntradayBreathingRate(t.Context(), tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil {
+				t.Fatal("got nil")
+			}
+			if got.DateTime != tt.want.DateTime {
+				t.Errorf("DateTime = %s, want %s", got.DateTime, tt.want.DateTime)
+			}
+			for _, stage := range []struct {
+				name      string
+				got, want *float64
+			}{
+				{"DeepSleep", got.DeepSleep, tt.want.DeepSleep},
+				{"REMSleep", got.REMSleep, tt.want.REMSleep},
+				{"LightSleep", got.LightSleep, tt.want.LightSleep},
+				{"FullSleep", got.FullSleep, tt.want.FullSleep},
+			} {
+				switch {
+				case stage.want == nil && stage.got != nil:
+					t.Errorf("%s = %v, want nil", stage.name, *stage.got)
+				case stage.want != nil && (stage.got == nil || *stage.got != *stage.want):
+					t.Errorf("%s = %v, want %v", stage.name, stage.got, *stage.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIntradayBreathingRateNoReading(t *testing.T) {
+	c := newTestClient(t, &requestRecorder{Response: `{"br":[]}`})
+	got, err := c.IntradayBreathingRate(t.Context(), Date{2021, 10, 25})
+	if err != nil || got != nil {
+		t.Errorf("got %+v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestBreathingRateRange(t *testing.T) {
+	// Odd days have no reading, and each chunk comes back newest first.
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/br/date/{start}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		start, err1 := ParseDate(r.PathValue("start"))
+		end, err2 := ParseDate(strings.TrimSuffix(r.PathValue("file"), ".json"))
+		if err1 != nil || err2 != nil {
+			http.Error(w, "bad range", http.StatusBadRequest)
+			return
+		}
+		br := []BreathingRate{}
+		for d := end; !d.Before(start); d = d.AddDays(-1) {
+			if d.Day%2 == 0 {
+				br = append(br, BreathingRate{d, BreathingRateValue{14 + float64(d.Day)/10}})
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"br": br})
+	})
+	c := newTestClient(t, mux)
+
+	entries, err := c.BreathingRateRange(t.Context(), Date{2021, 12, 20}, Date{2022, 1, 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRequests := []string{
+		"/1/user/-/br/date/2021-12-20/2022-01-18.json",
+		"/1/user/-/br/date/2022-01-19/2022-01-25.json",
+	}
+	if !equalStrings(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+
+	var want []BreathingRate
+	for d := (Date{2021, 12, 20}); !d.After(Date{2022, 1, 25}); d = d.AddDays(1) {
+		if d.Day%2 == 0 {
+			want = append(want, BreathingRate{d, BreathingRateValue{14 + float64(d.Day)/10}})
+		}
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entries[%d] = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
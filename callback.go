@@ -0,0 +1,175 @@
+package fitbit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// ErrAccessDenied matches (with errors.Is) the *AuthorizationError for a
+// user declining to authorize the app.
+var ErrAccessDenied = errors.New("fitbit: authorization denied by the user")
+
+// ErrInvalidState matches (with errors.Is) the error CallbackHandler
+// reports for a callback whose state parameter fails verification.
+var ErrInvalidState = errors.New("fitbit: invalid oauth state")
+
+// AuthorizationError is an error Fitbit redirected to the callback with
+// instead of an authorization code.
+type AuthorizationError struct {
+	Code        string // e.g. access_denied
+	Description string
+}
+
+func (e *AuthorizationError) Error() string {
+	if e.Description == "" {
+		return "fitbit: authorization failed: " + e.Code
+	}
+	return fmt.Sprintf("fitbit: authorization failed: %s: %s", e.Code, e.Description)
+}
+
+func (e *AuthorizationError) Is(target error) bool {
+	return target == ErrAccessDenied && e.Code == "access_denied"
+}
+
+// TokenUserID returns the Fitbit user id a token was issued for, from
+// the user_id field of Fitbit's token response. It is "" for tokens not
+// straight from a token response, such as ones loaded from storage.
+func TokenUserID(tok *oauth2.Token) string {
+	id, _ := tok.Extra("user_id").(string)
+	return id
+}
+
+// CallbackOptions configures CallbackHandler.
+type CallbackOptions struct {
+	// States verifies the callback's state parameter, which must then
+	// have been made by its Sign (see NewStateSigner). The verified
+	// payload is available to OnSuccess through StatePayload.
+	States *StateSigner
+
+	// VerifyState, if set, checks the callback's state parameter against
+	// the one the authorization was started with instead of States. One
+	// of the two is required.
+	VerifyState func(r *http.Request, state string) error
+
+	// OnSuccess is called once the user's token is stored. By default the
+	// handler responds with a plain text confirmation.
+	OnSuccess func(w http.ResponseWriter, r *http.Request, userID string, tok *oauth2.Token)
+
+	// OnError is called when the callback fails. By default the handler
+	// responds with the error and a status of 403 for ErrAccessDenied,
+	// 400 for other bad callbacks and 500 for failing to exchange the
+	// code or store the token.
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// callbackRequestError is a callback that is malformed rather than one
+// that failed on the server side.
+type callbackRequestError struct {
+	err error
+}
+
+func (e callbackRequestError) Error() string { return e.err.Error() }
+func (e callbackRequestError) Unwrap() error { return e.err }
+
+// CallbackHandler returns the handler for the OAuth redirect URL of cfg:
+// it verifies the state, exchanges the code for a token and saves the
+// token in store under the user's Fitbit user id. It fails if opts has
+// no way to verify states.
+func CallbackHandler(cfg *ConfigSource, store TokenStore, opts CallbackOptions) (http.Handler, error) {
+	if opts.VerifyState == nil {
+		if opts.States == nil {
+			return nil, errors.New("fitbit: CallbackOptions needs States (see NewStateSigner) or VerifyState")
+		}
+		if len(opts.States.Key) == 0 {
+			return nil, errors.New("fitbit: state signer has no key")
+		}
+	}
+	return &callbackHandler{cfg: cfg, store: store, opts: opts}, nil
+}
+
+type callbackHandler struct {
+	cfg   *ConfigSource
+	store TokenStore
+	opts  CallbackOptions
+}
+
+func (h *callbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, userID, tok, err := h.handle(r)
+	if err != nil {
+		if h.opts.OnError != nil {
+			h.opts.OnError(w, r, err)
+			return
+		}
+		status := http.StatusInternalServerError
+		var reqErr callbackRequestError
+		switch {
+		case errors.Is(err, ErrAccessDenied):
+			status = http.StatusForbidden
+		case errors.As(err, &reqErr):
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if h.opts.OnSuccess != nil {
+		h.opts.OnSuccess(w, r, userID, tok)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "Fitbit account linked.")
+}
+
+// handle processes the callback r, verifying its state before anything
+// else so a forged error can't pass for the user's answer.
+func (h *callbackHandler) handle(r *http.Request) (*http.Request, string, *oauth2.Token, error) {
+	q := r.URL.Query()
+	r, err := h.verifyState(r, q.Get("state"))
+	if err != nil {
+		return r, "", nil, callbackRequestError{err}
+	}
+	if code := q.Get("error"); code != "" {
+		return r, "", nil, callbackRequestError{&AuthorizationError{
+			Code:        code,
+			Description: q.Get("error_description"),
+		}}
+	}
+	code := q.Get("code")
+	if code == "" {
+		return r, "", nil, callbackRequestError{errors.New("fitbit: callback has no authorization code")}
+	}
+
+	tok, err := h.cfg.cfg.Exchange(r.Context(), code)
+	if err != nil {
+		return r, "", nil, err
+	}
+	userID := TokenUserID(tok)
+	if userID == "" {
+		return r, "", nil, errors.New("fitbit: token response has no user_id")
+	}
+	if err := h.store.SaveToken(userID, tok); err != nil {
+		return r, "", nil, err
+	}
+	return r, userID, tok, nil
+}
+
+// verifyState verifies state with the configured verifier. For States it
+// returns r with the state's payload in its context.
+func (h *callbackHandler) verifyState(r *http.Request, state string) (*http.Request, error) {
+	if h.opts.VerifyState != nil {
+		if err := h.opts.VerifyState(r, state); err != nil {
+			return r, fmt.Errorf("%w: %w", ErrInvalidState, err)
+		}
+		return r, nil
+	}
+
+	payload, err := h.opts.States.Verify(state)
+	if err != nil {
+		return r, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), statePayloadKey{}, payload)), nil
+}
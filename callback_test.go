@@ -0,0 +1,245 @@
+package fitbit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// callbackTest is a CallbackHandler whose fake token endpoint only
+// accepts the code "good", for user 9XQ7CM.
+type callbackTest struct {
+	handler   http.Handler
+	states    *StateSigner
+	store     *memTokenStore
+	tokenHits atomic.Int32
+}
+
+func newCallbackTest(t *testing.T, opts CallbackOptions) *callbackTest {
+	t.Helper()
+	ct := &callbackTest{
+		states: &StateSigner{Key: []byte("state key")},
+		store:  newMemTokenStore(),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ct.tokenHits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != "POST" || r.URL.Path != "/oauth2/token" ||
+			r.FormValue("grant_type") != "authorization_code" || r.FormValue("code") != "good" {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"errors":[{"errorType":"invalid_grant","message":"Authorization code invalid: bad"}],"success":false}`)
+			return
+		}
+		io.WriteString(w, `{"access_token":"access","expires_in":28800,"refresh_token":"refresh","scope":"activity profile","token_type":"Bearer","user_id":"9XQ7CM"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := NewConfigSource(&oauth2.Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Endpoint: oauth2.Endpoint{
+			TokenURL:  srv.URL + "/oauth2/token",
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+	})
+	if opts.VerifyState == nil {
+		opts.States = ct.states
+	}
+	h, err := CallbackHandler(cfg, ct.store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct.handler = h
+	return ct
+}
+
+// state returns a valid state carrying payload.
+func (ct *callbackTest) state(t *testing.T, payload string) string {
+	t.Helper()
+	state, err := ct.states.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+// call sends the handler a callback with the query params.
+func (ct *callbackTest) call(params url.Values) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	ct.handler.ServeHTTP(w, httptest.NewRequest("GET", "/callback?"+params.Encode(), nil))
+	return w
+}
+
+func TestCallbackSuccess(t *testing.T) {
+	var gotUser, gotPayload string
+	ct := newCallbackTest(t, CallbackOptions{
+		OnSuccess: func(w http.ResponseWriter, r *http.Request, userID string, tok *oauth2.Token) {
+			gotUser = userID
+			gotPayload, _ = StatePayload(r.Context())
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	w := ct.call(url.Values{"code": {"good"}, "state": {ct.state(t, "app-user-42")}})
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204: %s", w.Code, w.Body)
+	}
+	if gotUser != "9XQ7CM" || gotPayload != "app-user-42" {
+		t.Errorf("OnSuccess got user %q and payload %q, want 9XQ7CM and app-user-42", gotUser, gotPayload)
+	}
+	tok, err := ct.store.LoadToken("9XQ7CM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "access" || tok.RefreshToken != "refresh" || TokenUserID(tok) != "9XQ7CM" {
+		t.Errorf("saved token = %+v", tok)
+	}
+}
+
+func TestCallbackSuccessDefaultResponse(t *testing.T) {
+	ct := newCallbackTest(t, CallbackOptions{})
+	w := ct.call(url.Values{"code": {"good"}, "state": {ct.state(t, "")}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if _, err := ct.store.LoadToken("9XQ7CM"); err != nil {
+		t.Errorf("token not saved: %v", err)
+	}
+}
+
+func TestCallbackDenied(t *testing.T) {
+	var gotErr error
+	ct := newCallbackTest(t, CallbackOptions{})
+	params := url.Values{
+		"error":             {"access_denied"},
+		"error_description": {"The user denied the request."},
+		"state":             {ct.state(t, "app-user-42")},
+	}
+
+	w := ct.call(params)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403: %s", w.Code, w.Body)
+	}
+
+	ct = newCallbackTest(t, CallbackOptions{
+		OnError: func(w http.ResponseWriter, r *http.Request, err error) { gotErr = err },
+	})
+	params.Set("state", ct.state(t, "app-user-42"))
+	ct.call(params)
+	var authErr *AuthorizationError
+	if !errors.Is(gotErr, ErrAccessDenied) || !errors.As(gotErr, &authErr) || authErr.Description != "The user denied the request." {
+		t.Errorf("OnError got %v, want the user's denial", gotErr)
+	}
+	if n := ct.tokenHits.Load(); n != 0 {
+		t.Errorf("token endpoint hit %d times, want none", n)
+	}
+}
+
+func TestCallbackErrorStatuses(t *testing.T) {
+	ct := newCallbackTest(t, CallbackOptions{})
+	tests := []struct {
+		name   string
+		params url.Values
+		want   int
+	}{
+		{"denied", url.Values{"error": {"access_denied"}, "state": {ct.state(t, "")}}, http.StatusForbidden},
+		{"other error", url.Values{"error": {"invalid_scope"}, "state": {ct.state(t, "")}}, http.StatusBadRequest},
+		{"no code", url.Values{"state": {ct.state(t, "")}}, http.StatusBadRequest},
+		{"rejected code", url.Values{"code": {"bad"}, "state": {ct.state(t, "")}}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := ct.call(tt.params); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+	if _, err := ct.store.LoadToken("9XQ7CM"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("a token was saved: %v", err)
+	}
+}
+
+func TestCallbackBadState(t *testing.T) {
+	ct := newCallbackTest(t, CallbackOptions{})
+	var gotErr error
+	withOnError := newCallbackTest(t, CallbackOptions{
+		OnError: func(w http.ResponseWriter, r *http.Request, err error) { gotErr = err },
+	})
+
+	otherState, err := (&StateSigner{Key: []byte("other key")}).Sign("app-user-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := ct.state(t, "app-user-42")
+	dot := strings.IndexByte(good, '.')
+	tampered := good[:dot+1] + flipByte(t, good[dot+1:], 0)
+
+	states := []struct {
+		name, state string
+	}{
+		{"missing", ""},
+		{"garbage", "not-a-state"},
+		{"tampered", tampered},
+		{"wrong key", otherState},
+	}
+	for _, st := range states {
+		// A callback carrying an error is no exception: without a valid
+		// state it can't be told apart from a forged one.
+		for _, params := range []url.Values{
+			{"code": {"good"}, "state": {st.state}},
+			{"error": {"access_denied"}, "state": {st.state}},
+		} {
+			t.Run(st.name+"/"+params.Encode(), func(t *testing.T) {
+				if w := ct.call(params); w.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+				}
+				gotErr = nil
+				withOnError.call(params)
+				if !errors.Is(gotErr, ErrInvalidState) || errors.Is(gotErr, ErrAccessDenied) {
+					t.Errorf("OnError got %v, want only ErrInvalidState", gotErr)
+				}
+			})
+		}
+	}
+
+	if n := ct.tokenHits.Load() + withOnError.tokenHits.Load(); n != 0 {
+		t.Errorf("token endpoint hit %d times, want none", n)
+	}
+	if _, err := ct.store.LoadToken("9XQ7CM"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("a token was saved: %v", err)
+	}
+}
+
+func TestCallbackVerifyState(t *testing.T) {
+	ct := newCallbackTest(t, CallbackOptions{
+		VerifyState: func(r *http.Request, state string) error {
+			if state != "expected" {
+				return errors.New("state mismatch")
+			}
+			return nil
+		},
+	})
+	for _, tt := range []struct {
+		params url.Values
+		want   int
+	}{
+		{url.Values{"error": {"access_denied"}, "state": {"forged"}}, http.StatusBadRequest},
+		{url.Values{"code": {"good"}, "state": {"forged"}}, http.StatusBadRequest},
+		{url.Values{"error": {"access_denied"}, "state": {"expected"}}, http.StatusForbidden},
+		{url.Values{"code": {"good"}, "state": {"expected"}}, http.StatusOK},
+	} {
+		if w := ct.call(tt.params); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.params.Encode(), w.Code, tt.want, w.Body)
+		}
+	}
+	if n := ct.tokenHits.Load(); n != 1 {
+		t.Errorf("token endpoint hit %d times, want once", n)
+	}
+}
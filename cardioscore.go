@@ -0,0 +1,111 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// VO2Max is a cardio fitness score. Fitbit reports a single value
+// ("47") for users with GPS runs and a range ("44-48") otherwise; for a
+// single value Low and High are equal.
+type VO2Max struct {
+	Raw     string
+	Low     float64
+	High    float64
+	IsRange bool
+}
+
+// ParseVO2Max parses a vo2Max value in either its single or range form.
+func ParseVO2Max(s string) (VO2Max, error) {
+	v := VO2Max{Raw: s}
+	low, high := s, s
+	if i := strings.Index(s, "-"); i > 0 {
+		low, high = s[:i], s[i+1:]
+		v.IsRange = true
+	}
+
+	var err error
+	if v.Low, err = strconv.ParseFloat(strings.TrimSpace(low), 64); err != nil {
+		return VO2Max{}, fmt.Errorf("fitbit: invalid vo2Max %q", s)
+	}
+	if v.High, err = strconv.ParseFloat(strings.TrimSpace(high), 64); err != nil {
+		return VO2Max{}, fmt.Errorf("fitbit: invalid vo2Max %q", s)
+	}
+	return v, nil
+}
+
+func (v VO2Max) String() string {
+	return v.Raw
+}
+
+func (v *VO2Max) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	parsed, err := ParseVO2Max(s)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+func (v VO2Max) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Raw)
+}
+
+// CardioScore is the cardio fitness score for one day.
+type CardioScore struct {
+	DateTime Date             `json:"dateTime"`
+	Value    CardioScoreValue `json:"value"`
+}
+
+type CardioScoreValue struct {
+	VO2Max VO2Max `json:"vo2Max"`
+}
+
+type cardioScoreResponse struct {
+	CardioScore []CardioScore `json:"cardioScore"`
+}
+
+// CardioScoreByDate returns the cardio fitness score for date. It
+// returns nil (and no error) when there is no score for that day.
+func (c *Client) CardioScoreByDate(ctx context.Context, date Date) (*CardioScore, error) {
+	var resp cardioScoreResponse
+	err := c.get(ctx, fmt.Sprintf("/user/-/cardioscore/date/%s.json", date), &resp)
+	if err != nil || len(resp.CardioScore) == 0 {
+		return nil, err
+	}
+	return &resp.CardioScore[0], nil
+}
+
+// CardioScoreRange returns the cardio fitness scores between start and
+// end, inclusive, sorted by date. Ranges longer than Fitbit's 30 day cap
+// are fetched in consecutive, non-overlapping chunks; days without a
+// score are absent from the result.
+func (c *Client) CardioScoreRange(ctx context.Context, start, end Date) ([]CardioScore, error) {
+	var entries []CardioScore
+	err := c.getWellnessRange(ctx, "cardioscore", start, end, func(urlStr string) error {
+		var resp cardioScoreResponse
+		if err := c.get(ctx, urlStr, &resp); err != nil {
+			return err
+		}
+		entries = append(entries, resp.CardioScore...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DateTime.Before(entries[j].DateTime)
+	})
+	return entries, nil
+}
@@ -0,0 +1,104 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCardioScoreRangeChunks(t *testing.T) {
+	// Every day has a score, so that a day fetched by two chunks would
+	// show up twice.
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/cardioscore/date/{start}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		start, err1 := ParseDate(r.PathValue("start"))
+		end, err2 := ParseDate(strings.TrimSuffix(r.PathValue("file"), ".json"))
+		if err1 != nil || err2 != nil {
+			http.Error(w, "bad range", http.StatusBadRequest)
+			return
+		}
+		scores := []map[string]interface{}{}
+		for d := start; !d.After(end); d = d.AddDays(1) {
+			scores = append(scores, map[string]interface{}{
+				"dateTime": d.String(),
+				"value":    map[string]string{"vo2Max": fmt.Sprintf("%d-%d", 40+d.Day%5, 44+d.Day%5)},
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"cardioScore": scores})
+	})
+	c := newTestClient(t, mux)
+
+	for _, tt := range []struct {
+		name       string
+		start, end Date
+		requests   []string
+	}{
+		{"one day", Date{2022, 1, 1}, Date{2022, 1, 1}, []string{
+			"/1/user/-/cardioscore/date/2022-01-01/2022-01-01.json",
+		}},
+		{"exactly the cap", Date{2022, 1, 1}, Date{2022, 1, 30}, []string{
+			"/1/user/-/cardioscore/date/2022-01-01/2022-01-30.json",
+		}},
+		{"a day over the cap", Date{2022, 1, 1}, Date{2022, 1, 31}, []string{
+			"/1/user/-/cardioscore/date/2022-01-01/2022-01-30.json",
+			"/1/user/-/cardioscore/date/2022-01-31/2022-01-31.json",
+		}},
+		{"across a year", Date{2021, 12, 15}, Date{2022, 2, 20}, []string{
+			"/1/user/-/cardioscore/date/2021-12-15/2022-01-13.json",
+			"/1/user/-/cardioscore/date/2022-01-14/2022-02-12.json",
+			"/1/user/-/cardioscore/date/2022-02-13/2022-02-20.json",
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			scores, err := c.CardioScoreRange(t.Context(), tt.start, tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalStrings(requests, tt.requests) {
+				t.Errorf("requests = %v, want %v", requests, tt.requests)
+			}
+			if n := tt.start.DaysUntil(tt.end) + 1; len(scores) != n {
+				t.Fatalf("got %d scores, want %d", len(scores), n)
+			}
+			for i, s := range scores {
+				if want := tt.start.AddDays(i); s.DateTime != want {
+					t.Errorf("scores[%d] is for %s, want %s", i, s.DateTime, want)
+				}
+				if !s.Value.VO2Max.IsRange || s.Value.VO2Max.High-s.Value.VO2Max.Low != 4 {
+					t.Errorf("scores[%d] = %+v", i, s.Value.VO2Max)
+				}
+			}
+		})
+	}
+}
+
+func TestParseVO2Max(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want VO2Max
+	}{
+		{"47", VO2Max{Raw: "47", Low: 47, High: 47}},
+		{"44-48", VO2Max{Raw: "44-48", Low: 44, High: 48, IsRange: true}},
+		{"44.5 - 48.5", VO2Max{Raw: "44.5 - 48.5", Low: 44.5, High: 48.5, IsRange: true}},
+	} {
+		got, err := ParseVO2Max(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseVO2Max(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "-", "44-", "abc"} {
+		if _, err := ParseVO2Max(in); err == nil {
+			t.Errorf("ParseVO2Max(%q) succeeded", in)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package fitbit
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// localDateTimeLayout matches Fitbit's offset-less timestamps, e.g.
+	// "2021-10-25T09:10:00.000".
+	localDateTimeLayout = "2006-01-02T15:04:05"
+)
+
+// Date is a calendar day as used throughout the Fitbit API, serialized
+// as yyyy-MM-dd. It carries no time of day or location.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// ParseDate parses a yyyy-MM-dd string into a Date.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return Date{}, err
+	}
+	return DateOf(t), nil
+}
+
+// DateOf returns the Date that t falls on in t's location.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// String returns the date formatted as yyyy-MM-dd.
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// IsZero reports whether d is the zero Date.
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// In returns midnight at the start of d in loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// AddDays returns the date n days after d (n may be negative).
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.In(time.UTC).AddDate(0, 0, n))
+}
+
+// Before reports whether d is before o.
+func (d Date) Before(o Date) bool {
+	return d.In(time.UTC).Before(o.In(time.UTC))
+}
+
+// After reports whether d is after o.
+func (d Date) After(o Date) bool {
+	return o.Before(d)
+}
+
+// DaysUntil returns the number of days from d to o, negative if o is
+// before d.
+func (d Date) DaysUntil(o Date) int {
+	return int(o.In(time.UTC).Sub(d.In(time.UTC)).Hours() / 24)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a yyyy-MM-dd date. An empty string, which
+// Fitbit sends for some unset dates, leaves d as the zero Date.
+func (d *Date) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*d = Date{}
+		return nil
+	}
+	parsed, err := ParseDate(string(b))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// parseLocalDateTime parses an offset-less Fitbit timestamp as a wall
+// clock time in loc.
+func parseLocalDateTime(s string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(localDateTimeLayout, s, loc)
+}
+
+// dateRange is an inclusive span of days.
+type dateRange struct {
+	Start, End Date
+}
+
+// splitDateRange splits [start, end] into chunks of at most maxDays
+// days.
+func splitDateRange(start, end Date, maxDays int) ([]dateRange, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("fitbit: end date %s is before start date %s", end, start)
+	}
+
+	var chunks []dateRange
+	for s := start; !s.After(end); s = s.AddDays(maxDays) {
+		e := s.AddDays(maxDays - 1)
+		if e.After(end) {
+			e = end
+		}
+		chunks = append(chunks, dateRange{Start: s, End: e})
+	}
+	return chunks, nil
+}
+
+// ClockTime is a time of day, to the minute, with no date or location.
+type ClockTime struct {
+	Hour   int
+	Minute int
+}
+
+// ParseClockTime parses an HH:mm time of day.
+func ParseClockTime(s string) (ClockTime, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return ClockTime{}, err
+	}
+	return ClockTime{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
+
+// String returns the time formatted as HH:mm.
+func (t ClockTime) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// Minutes returns the number of minutes since midnight.
+func (t ClockTime) Minutes() int {
+	return t.Hour*60 + t.Minute
+}
+
+// latestZone is the timezone furthest ahead of UTC, used to decide
+// whether a date is in the future for every user.
+var latestZone = time.FixedZone("UTC+14", 14*60*60)
+
+// isFutureDate reports whether d hasn't started yet anywhere on earth.
+func isFutureDate(d Date) bool {
+	return d.After(DateOf(time.Now().In(latestZone)))
+}
@@ -0,0 +1,78 @@
+package fitbit
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// checkDateChunks checks that chunks cover [start, end] in order, all
+// but the last spanning maxDays days.
+func checkDateChunks(t *testing.T, start, end Date, maxDays int, chunks []dateRange) {
+	t.Helper()
+	days := start.DaysUntil(end) + 1
+	if want := (days + maxDays - 1) / maxDays; len(chunks) != want {
+		t.Fatalf("split(%s, %s, %d): got %d chunks, want %d", start, end, maxDays, len(chunks), want)
+	}
+	next := start
+	for i, c := range chunks {
+		if c.Start != next {
+			t.Fatalf("split(%s, %s, %d): chunk %d starts %s, want %s", start, end, maxDays, i, c.Start, next)
+		}
+		n := c.Start.DaysUntil(c.End) + 1
+		if n < 1 || n > maxDays {
+			t.Fatalf("split(%s, %s, %d): chunk %d spans %d days", start, end, maxDays, i, n)
+		}
+		if n < maxDays && i != len(chunks)-1 {
+			t.Fatalf("split(%s, %s, %d): chunk %d spans %d days but isn't the last", start, end, maxDays, i, n)
+		}
+		next = c.End.AddDays(1)
+	}
+	if last := chunks[len(chunks)-1].End; last != end {
+		t.Fatalf("split(%s, %s, %d): last chunk ends %s, want %s", start, end, maxDays, last, end)
+	}
+}
+
+func TestSplitDateRange(t *testing.T) {
+	start := Date{2020, 2, 28}
+	for _, tt := range []struct {
+		name    string
+		days    int
+		maxDays int
+	}{
+		{"single day", 1, 100},
+		{"single day of a one day cap", 1, 1},
+		{"exactly one cap", 100, 100},
+		{"one over the cap", 101, 100},
+		{"exactly two caps", 200, 100},
+		{"one day cap", 5, 1},
+		{"across a leap day and year end", 1200, 30},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			end := start.AddDays(tt.days - 1)
+			chunks, err := splitDateRange(start, end, tt.maxDays)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDateChunks(t, start, end, tt.maxDays, chunks)
+		})
+	}
+
+	if _, err := splitDateRange(start, start.AddDays(-1), 100); err == nil {
+		t.Error("split with end before start succeeded, want an error")
+	}
+}
+
+func TestSplitDateRangeRandom(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	base := Date{2019, 1, 1}
+	for range 1000 {
+		start := base.AddDays(r.IntN(2000))
+		end := start.AddDays(r.IntN(1000))
+		maxDays := 1 + r.IntN(120)
+		chunks, err := splitDateRange(start, end, maxDays)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkDateChunks(t, start, end, maxDays, chunks)
+	}
+}
@@ -0,0 +1,110 @@
+package fitbit
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// DeviceType is the kind of a paired device.
+type DeviceType string
+
+const (
+	DeviceTypeTracker DeviceType = "TRACKER"
+	DeviceTypeScale   DeviceType = "SCALE"
+)
+
+// Device is a device paired with the user's account. Scales don't
+// report BatteryLevel or MAC.
+//
+// LastSyncTime is the raw local timestamp Fitbit sends, empty for a
+// device that never synced. LastSync is it in the user's timezone, or
+// zero if the device never synced or the timezone is unknown (see
+// Client.Location).
+type Device struct {
+	ID            string     `json:"id"`
+	DeviceVersion string     `json:"deviceVersion"` // e.g. Charge 2
+	Type          DeviceType `json:"type"`
+	Battery       string     `json:"battery"` // High, Medium, Low or Empty
+	BatteryLevel  int        `json:"batteryLevel"`
+	LastSyncTime  string     `json:"lastSyncTime"`
+	MAC           string     `json:"mac"`
+	Features      []string   `json:"features"`
+
+	LastSync time.Time `json:"-"`
+}
+
+// HasSynced reports whether the device ever synced.
+func (d Device) HasSynced() bool {
+	return d.LastSyncTime != ""
+}
+
+// SyncedWithin reports whether the device last synced no more than dur
+// before now. It is false for a device that never synced, or whose
+// LastSync is unknown.
+func (d Device) SyncedWithin(dur time.Duration, now time.Time) bool {
+	return d.HasSynced() && !d.LastSync.IsZero() && now.Sub(d.LastSync) <= dur
+}
+
+// Devices returns the devices paired with the user's account.
+func (c *Client) Devices(ctx context.Context) ([]Device, error) {
+	var devices []Device
+	if err := c.get(ctx, "/user/-/devices.json", &devices); err != nil {
+		return nil, err
+	}
+	if devices == nil {
+		devices = []Device{}
+	}
+	return devices, c.localizeDevices(ctx, devices)
+}
+
+// localizeDevices sets the parsed LastSync of each of devices that
+// synced, unless the user's timezone can't be had, in which case they
+// are left zero.
+func (c *Client) localizeDevices(ctx context.Context, devices []Device) error {
+	var loc *time.Location
+	for i := range devices {
+		if devices[i].LastSyncTime == "" {
+			continue
+		}
+		if loc == nil {
+			var err error
+			if loc, err = c.location(ctx); err != nil {
+				// The devices are of use without their parsed LastSync.
+				return nil
+			}
+		}
+		t, err := parseLocalDateTime(devices[i].LastSyncTime, loc)
+		if err != nil {
+			return err
+		}
+		devices[i].LastSync = t
+	}
+	return nil
+}
+
+// StaleDevices returns the user's devices that haven't synced within
+// threshold, including those that never synced. It fails if the user's
+// timezone is unknown, as the sync times can't be placed without it.
+func (c *Client) StaleDevices(ctx context.Context, threshold time.Duration) ([]Device, error) {
+	devices, err := c.Devices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		if d.HasSynced() && d.LastSync.IsZero() {
+			if _, err := c.location(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	now := time.Now()
+	stale := []Device{}
+	for _, d := range devices {
+		if !d.SyncedWithin(threshold, now) {
+			stale = append(stale, d)
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,117 @@
+package fitbit
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func devicesHandler(t *testing.T, profileStatus int, profileBody string) http.Handler {
+	var hits atomic.Int32
+	mux := profileHandler(t, &hits, profileStatus, profileBody).(*http.ServeMux)
+	mux.Handle("GET /1/user/-/devices.json", serveFixture(t, "devices.json"))
+	return mux
+}
+
+func TestDevices(t *testing.T) {
+	c := newTestClient(t, devicesHandler(t, http.StatusOK, `{"user":{"timezone":"Europe/Berlin"}}`))
+	devices, err := c.Devices(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("got %d devices, want 2", len(devices))
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	if want := time.Date(2021, 6, 7, 8, 56, 21, 0, berlin); !devices[0].LastSync.Equal(want) {
+		t.Errorf("LastSync = %v, want %v", devices[0].LastSync, want)
+	}
+	if !devices[0].HasSynced() || devices[1].HasSynced() || !devices[1].LastSync.IsZero() {
+		t.Errorf("HasSynced = %t, %t, want true, false", devices[0].HasSynced(), devices[1].HasSynced())
+	}
+}
+
+func TestDevicesWithoutProfileScope(t *testing.T) {
+	c := newTestClient(t, devicesHandler(t, http.StatusForbidden, profileForbidden))
+	devices, err := c.Devices(t.Context())
+	if err != nil {
+		t.Fatalf("Devices failed for want of the profile: %v", err)
+	}
+	// Without the timezone the sync time can't be placed.
+	if !devices[0].LastSync.IsZero() {
+		t.Errorf("LastSync = %v, want zero", devices[0].LastSync)
+	}
+	if !devices[0].HasSynced() {
+		t.Error("HasSynced = false for a device that synced")
+	}
+	if devices[0].SyncedWithin(24*time.Hour, time.Date(2021, 6, 7, 9, 0, 0, 0, time.UTC)) {
+		t.Error("SyncedWithin = true for an unknown sync time")
+	}
+}
+
+func TestStaleDevices(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().In(berlin)
+	devices := func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []map[string]string{
+			{"id": "fresh", "lastSyncTime": now.Add(-10 * time.Minute).Format(localDateTimeLayout)},
+			{"id": "old", "lastSyncTime": now.Add(-72 * time.Hour).Format(localDateTimeLayout)},
+			{"id": "never", "lastSyncTime": ""},
+		})
+	}
+
+	var hits atomic.Int32
+	mux := profileHandler(t, &hits, http.StatusOK, `{"user":{"timezone":"Europe/Berlin"}}`).(*http.ServeMux)
+	mux.HandleFunc("GET /1/user/-/devices.json", devices)
+	c := newTestClient(t, mux)
+	stale, err := c.StaleDevices(t.Context(), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 2 || stale[0].ID != "old" || stale[1].ID != "never" {
+		t.Errorf("stale = %+v, want old and never", stale)
+	}
+
+	mux = profileHandler(t, &hits, http.StatusForbidden, profileForbidden).(*http.ServeMux)
+	mux.HandleFunc("GET /1/user/-/devices.json", devices)
+	c = newTestClient(t, mux)
+	if stale, err := c.StaleDevices(t.Context(), 24*time.Hour); !errors.Is(err, ErrForbidden) {
+		t.Errorf("StaleDevices without the timezone = %+v, %v, want ErrForbidden", stale, err)
+	}
+}
+
+func TestDevicesDecode(t *testing.T) {
+	c := newTestClient(t, devicesHandler(t, http.StatusOK, `{"user":{"timezone":"Europe/Berlin"}}`))
+	devices, err := c.Devices(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("got %d devices, want 2", len(devices))
+	}
+	tracker := devices[0]
+	if tracker.ID != "1145281385" || tracker.Type != DeviceTypeTracker || tracker.DeviceVersion != "Charge 4" ||
+		tracker.Battery != "High" || tracker.BatteryLevel != 95 || tracker.MAC != "A2B3C4D5E6F7" || tracker.Features == nil {
+		t.Errorf("tracker = %+v", tracker)
+	}
+	// Scales have no battery level or MAC.
+	scale := devices[1]
+	if scale.ID != "S3FD9C2A4" || scale.Type != DeviceTypeScale || scale.DeviceVersion != "Aria Air" ||
+		scale.BatteryLevel != 0 || scale.MAC != "" {
+		t.Errorf("scale = %+v", scale)
+	}
+}
+
+func TestDevicesWithoutSettingsScope(t *testing.T) {
+	c := newTestClient(t, serveError(t, http.StatusForbidden, "insufficient_scope_settings.json"))
+	_, err := c.Devices(t.Context())
+	var scopeErr *ScopeError
+	if !errors.As(err, &scopeErr) || scopeErr.Scope != ScopeSettings || !errors.Is(err, ErrForbidden) {
+		t.Errorf("Devices = %v, want a ScopeError for settings", err)
+	}
+}
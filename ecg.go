@@ -0,0 +1,182 @@
+package fitbit
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// maxECGLimit is the largest page size the ECG list endpoint accepts.
+const maxECGLimit = 10
+
+// ECGClassification is the result of an ECG reading.
+type ECGClassification string
+
+const (
+	ECGNormalSinusRhythm      ECGClassification = "Normal Sinus Rhythm"
+	ECGAtrialFibrillation     ECGClassification = "Atrial Fibrillation"
+	ECGInconclusive           ECGClassification = "Inconclusive"
+	ECGInconclusiveHighHeart  ECGClassification = "Inconclusive: High heart rate"
+	ECGInconclusiveLowHeart   ECGClassification = "Inconclusive: Low heart rate"
+	ECGInconclusivePoorSignal ECGClassification = "Inconclusive: Poor reading"
+	ECGUnreadable             ECGClassification = "Unreadable"
+)
+
+// ECGReading is a single ECG recording. StartTime is the time the
+// recording began, in the user's timezone (see Client.Location).
+type ECGReading struct {
+	StartTime               time.Time
+	AverageHeartRate        int
+	ResultClassification    ECGClassification
+	WaveformSamples         []int
+	SamplingFrequencyHz     int
+	ScalingFactor           int
+	NumberOfWaveformSamples int
+	LeadNumber              int
+	FeatureVersion          string
+	DeviceName              string
+	FirmwareVersion         string
+}
+
+type ecgReadingJSON struct {
+	StartTime               string            `json:"startTime"`
+	AverageHeartRate        int               `json:"averageHeartRate"`
+	ResultClassification    ECGClassification `json:"resultClassification"`
+	WaveformSamples         []int             `json:"waveformSamples"`
+	SamplingFrequencyHz     int               `json:"samplingFrequencyHz,string"`
+	ScalingFactor           int               `json:"scalingFactor"`
+	NumberOfWaveformSamples int               `json:"numberOfWaveformSamples"`
+	LeadNumber              int               `json:"leadNumber"`
+	FeatureVersion          string            `json:"featureVersion"`
+	DeviceName              string            `json:"deviceName"`
+	FirmwareVersion         string            `json:"firmwareVersion"`
+}
+
+// ecgReadingMetaJSON skips over waveformSamples rather than decoding
+// them, for callers that only want the metadata.
+type ecgReadingMetaJSON struct {
+	ecgReadingJSON
+	WaveformSamples skipJSON `json:"waveformSamples"`
+}
+
+// skipJSON discards whatever JSON value it is decoded from.
+type skipJSON struct{}
+
+func (skipJSON) UnmarshalJSON([]byte) error { return nil }
+
+func (r ecgReadingJSON) reading() (ECGReading, error) {
+	start, err := parseLocalDateTime(r.StartTime, time.UTC)
+	if err != nil {
+		return ECGReading{}, err
+	}
+	return ECGReading{
+		StartTime:               start,
+		AverageHeartRate:        r.AverageHeartRate,
+		ResultClassification:    r.ResultClassification,
+		WaveformSamples:         r.WaveformSamples,
+		SamplingFrequencyHz:     r.SamplingFrequencyHz,
+		ScalingFactor:           r.ScalingFactor,
+		NumberOfWaveformSamples: r.NumberOfWaveformSamples,
+		LeadNumber:              r.LeadNumber,
+		FeatureVersion:          r.FeatureVersion,
+		DeviceName:              r.DeviceName,
+		FirmwareVersion:         r.FirmwareVersion,
+	}, nil
+}
+
+// ECGListOptions are the parameters for ECGLogs. Limit may be at most
+// 10.
+type ECGListOptions struct {
+	ListOptions
+	// OmitWaveforms skips decoding each reading's (large)
+	// WaveformSamples, leaving it nil.
+	OmitWaveforms bool
+}
+
+// ECGLogPage is one page of ECG readings.
+type ECGLogPage struct {
+	Readings   []ECGReading
+	Pagination Pagination
+
+	client        *Client
+	omitWaveforms bool
+}
+
+// ECGLogs returns the first page of the user's ECG readings matching
+// opts.
+func (c *Client) ECGLogs(ctx context.Context, opts ECGListOptions) (*ECGLogPage, error) {
+	q, err := opts.values(maxECGLimit)
+	if err != nil {
+		return nil, err
+	}
+	return c.ecgLogPage(ctx, "", "/user/-/ecg/list.json?"+q.Encode(), opts.OmitWaveforms)
+}
+
+func (c *Client) ecgLogPage(ctx context.Context, version, urlStr string, omitWaveforms bool) (*ECGLogPage, error) {
+	page := &ECGLogPage{client: c, omitWaveforms: omitWaveforms}
+	var readings []ecgReadingJSON
+	if omitWaveforms {
+		var resp struct {
+			ECGReadings []ecgReadingMetaJSON `json:"ecgReadings"`
+			Pagination  Pagination           `json:"pagination"`
+		}
+		if err := c.getVersion(ctx, version, urlStr, &resp); err != nil {
+			return nil, err
+		}
+		for _, r := range resp.ECGReadings {
+			readings = append(readings, r.ecgReadingJSON)
+		}
+		page.Pagination = resp.Pagination
+	} else {
+		var resp struct {
+			ECGReadings []ecgReadingJSON `json:"ecgReadings"`
+			Pagination  Pagination       `json:"pagination"`
+		}
+		if err := c.getVersion(ctx, version, urlStr, &resp); err != nil {
+			return nil, err
+		}
+		readings, page.Pagination = resp.ECGReadings, resp.Pagination
+	}
+
+	page.Readings = make([]ECGReading, 0, len(readings))
+	for _, r := range readings {
+		reading, err := r.reading()
+		if err != nil {
+			return nil, err
+		}
+		page.Readings = append(page.Readings, reading)
+	}
+
+	times := make([]*time.Time, len(page.Readings))
+	for i := range page.Readings {
+		times[i] = &page.Readings[i].StartTime
+	}
+	c.inLocation(ctx, times...)
+	return page, nil
+}
+
+// HasNext reports whether there is a page after p.
+func (p *ECGLogPage) HasNext() bool {
+	return p.Pagination.HasNext()
+}
+
+// Next fetches the page after p, or returns ErrNoMorePages.
+func (p *ECGLogPage) Next(ctx context.Context) (*ECGLogPage, error) {
+	if !p.HasNext() {
+		return nil, ErrNoMorePages
+	}
+	version, urlStr, err := p.client.pageURL(p.Pagination.Next)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.ecgLogPage(ctx, version, urlStr, p.omitWaveforms)
+}
+
+// NextPage implements Pager.
+func (p *ECGLogPage) NextPage(ctx context.Context) (Pager, error) {
+	next, err := p.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
@@ -0,0 +1,43 @@
+package fitbit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestECGLogs(t *testing.T) {
+	for _, omit := range []bool{false, true} {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /1/user/-/ecg/list.json", func(w http.ResponseWriter, r *http.Request) {
+			if q := r.URL.RawQuery; q != "afterDate=2022-09-28&limit=1&offset=0&sort=asc" {
+				t.Errorf("query = %s", q)
+			}
+			serveFixture(t, "ecg_list.json").ServeHTTP(w, r)
+		})
+		c := newTestClient(t, mux)
+		paris, _ := time.LoadLocation("Europe/Paris")
+		c.Location = paris
+
+		page, err := c.ECGLogs(t.Context(), ECGListOptions{
+			ListOptions:   ListOptions{AfterDate: Date{2022, 9, 28}, Limit: 1},
+			OmitWaveforms: omit,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Readings) != 1 || page.HasNext() {
+			t.Fatalf("page = %+v", page)
+		}
+		r := page.Readings[0]
+		if want := time.Date(2022, 9, 28, 17, 12, 30, 222e6, paris); !r.StartTime.Equal(want) || r.StartTime.Location() != paris {
+			t.Errorf("StartTime = %v, want %v", r.StartTime, want)
+		}
+		if r.ResultClassification != ECGNormalSinusRhythm || r.SamplingFrequencyHz != 250 || r.ScalingFactor != 10922 {
+			t.Errorf("reading = %+v", r)
+		}
+		if omit != (r.WaveformSamples == nil) {
+			t.Errorf("OmitWaveforms %t: WaveformSamples = %v", omit, r.WaveformSamples)
+		}
+	}
+}
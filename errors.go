@@ -0,0 +1,219 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrForbidden matches (with errors.Is) an *APIError for a 403 response,
+// such as a privacy setting denying access to another user's data. A
+// *ScopeError for a missing scope matches it too.
+var ErrForbidden = errors.New("fitbit: access forbidden")
+
+// ErrNotFound matches (with errors.Is) an *APIError for a 404 response,
+// e.g. for a log that was already deleted or belongs to someone else.
+var ErrNotFound = errors.New("fitbit: resource not found")
+
+// ErrUnauthorized matches (with errors.Is) an *APIError for a 401
+// response, and the error Do returns when refreshing the token after one
+// fails (see Client.RetryUnauthorized).
+var ErrUnauthorized = errors.New("fitbit: unauthorized")
+
+// ErrReauthorizationRequired matches (with errors.Is) the error for a
+// token refresh Fitbit rejected with invalid_grant: the refresh token was
+// revoked or already used, and the user has to authorize the app again.
+var ErrReauthorizationRequired = errors.New("fitbit: refresh token rejected; the user must authorize again")
+
+// ErrNoGoal is returned by the goal getters when the user hasn't set
+// that goal, so that it isn't mistaken for a goal of zero.
+var ErrNoGoal = errors.New("fitbit: no goal set")
+
+// ErrRateLimited matches (with errors.Is) an *APIError for a request
+// Fitbit rejected because the user's rate limit was exhausted.
+var ErrRateLimited = errors.New("fitbit: rate limit exceeded")
+
+// ErrorDetail is a single entry of the "errors" array Fitbit includes
+// in failed responses.
+type ErrorDetail struct {
+	ErrorType string `json:"errorType"`
+	FieldName string `json:"fieldName"`
+	Message   string `json:"message"`
+}
+
+// APIError is returned by Do for any non-2xx response.
+type APIError struct {
+	StatusCode int
+	Errors     []ErrorDetail
+	// Body is the raw response body, kept for responses that don't
+	// follow the usual errors array shape.
+	Body []byte
+	// RetryAfter is how long Fitbit asked the client to wait before
+	// retrying, from the Retry-After header of 429 responses.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("fitbit: http request failed with status %d", e.StatusCode)
+	}
+	msgs := make([]string, len(e.Errors))
+	for i, d := range e.Errors {
+		msgs[i] = d.ErrorType + ": " + d.Message
+	}
+	return fmt.Sprintf(
+		"fitbit: http request failed with status %d: %s",
+		e.StatusCode,
+		strings.Join(msgs, "; "),
+	)
+}
+
+// Is lets errors.Is match an *APIError against the sentinel errors for
+// the status codes it represents.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// hasErrorType reports whether any of the error details is of typ.
+func (e *APIError) hasErrorType(typ string) bool {
+	for _, d := range e.Errors {
+		if d.ErrorType == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// hasError reports whether any of the error details is of typ and
+// about the request field named field.
+func (e *APIError) hasError(typ, field string) bool {
+	for _, d := range e.Errors {
+		if d.ErrorType == typ && d.FieldName == field {
+			return true
+		}
+	}
+	return false
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(secs) * time.Second
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return apiErr
+	}
+	apiErr.Body = body
+
+	var parsed struct {
+		Errors []ErrorDetail `json:"errors"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		apiErr.Errors = parsed.Errors
+	}
+	return apiErr
+}
+
+// Scope is an OAuth 2.0 scope a Fitbit token may be granted.
+type Scope string
+
+const (
+	ScopeActivity                     Scope = "activity"
+	ScopeCardioFitness                Scope = "cardio_fitness"
+	ScopeElectrocardiogram            Scope = "electrocardiogram"
+	ScopeHeartRate                    Scope = "heartrate"
+	ScopeIrregularRhythmNotifications Scope = "irregular_rhythm_notifications"
+	ScopeLocation                     Scope = "location"
+	ScopeNutrition                    Scope = "nutrition"
+	ScopeOxygenSaturation             Scope = "oxygen_saturation"
+	ScopeProfile                      Scope = "profile"
+	ScopeRespiratoryRate              Scope = "respiratory_rate"
+	ScopeSettings                     Scope = "settings"
+	ScopeSleep                        Scope = "sleep"
+	ScopeSocial                       Scope = "social"
+	ScopeTemperature                  Scope = "temperature"
+	ScopeWeight                       Scope = "weight"
+)
+
+// resourceScopes maps the first path segment of a user resource (the
+// part after /user/{user-id}/) to the scope Fitbit requires for it.
+var resourceScopes = map[string]Scope{
+	"activities":  ScopeActivity,
+	"body":        ScopeWeight,
+	"br":          ScopeRespiratoryRate,
+	"cardioscore": ScopeCardioFitness,
+	"devices":     ScopeSettings,
+	"ecg":         ScopeElectrocardiogram,
+	"foods":       ScopeNutrition,
+	"friends":     ScopeSocial,
+	"hrv":         ScopeHeartRate,
+	"irn":         ScopeIrregularRhythmNotifications,
+	"leaderboard": ScopeSocial,
+	"profile":     ScopeProfile,
+	"sleep":       ScopeSleep,
+	"spo2":        ScopeOxygenSaturation,
+	"temp":        ScopeTemperature,
+}
+
+// scopeForPath returns the scope required for the API path p, or "" if
+// it isn't known.
+func scopeForPath(p string) Scope {
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	for i, s := range segments {
+		if s == "user" && i+2 < len(segments) {
+			return resourceScopes[strings.TrimSuffix(segments[i+2], ".json")]
+		}
+	}
+	if len(segments) > 1 {
+		return resourceScopes[strings.TrimSuffix(segments[1], ".json")]
+	}
+	return ""
+}
+
+// ScopeError is returned when a request fails because the token was
+// not granted the scope the resource requires.
+type ScopeError struct {
+	// Scope is the missing scope, or "" if it couldn't be determined
+	// from the request path.
+	Scope Scope
+	Err   *APIError
+}
+
+func (e *ScopeError) Error() string {
+	if e.Scope == "" {
+		return "fitbit: token is missing a required scope: " + e.Err.Error()
+	}
+	return fmt.Sprintf("fitbit: token is missing the %q scope: %s", e.Scope, e.Err)
+}
+
+func (e *ScopeError) Unwrap() error {
+	return e.Err
+}
+
+// errorForResponse converts a failed response into the most specific
+// error type available.
+func errorForResponse(req *http.Request, resp *http.Response) error {
+	apiErr := newAPIError(resp)
+	if apiErr.hasErrorType("insufficient_scope") ||
+		apiErr.hasErrorType("insufficient_permissions") {
+		return &ScopeError{Scope: scopeForPath(req.URL.Path), Err: apiErr}
+	}
+	return apiErr
+}
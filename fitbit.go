@@ -3,10 +3,15 @@ package fitbit
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
@@ -49,6 +54,13 @@ const (
 	USER_AGENT = "go-fitbit-api:v0.0.1"
 )
 
+// Versions of the API other than BaseUrl's own (1) that some resources
+// live under.
+const (
+	apiVersion1_1 = "1.1"
+	apiVersion1_2 = "1.2"
+)
+
 var (
 	baseURL, _ = url.Parse(BASE_URL)
 )
@@ -56,6 +68,51 @@ var (
 type Client struct {
 	Client  *http.Client
 	BaseUrl *url.URL
+
+	// UnitSystem, if set, is sent as the Accept-Language header of every
+	// request and governs the units of measurements Fitbit returns.
+	UnitSystem UnitSystem
+
+	// FoodLocale, if set, is sent as the Accept-Locale header of every
+	// request and selects the food database (e.g. "en_US") searches and
+	// food lookups use.
+	FoodLocale string
+
+	// SubscriberID, if set, is sent as the X-Fitbit-Subscriber-Id header
+	// of subscription requests, to pick one of an app's subscriber
+	// endpoints rather than its default.
+	SubscriberID string
+
+	// CoalesceGets makes concurrent identical GET requests share a single
+	// response, e.g. for webhook notifications arriving at once.
+	CoalesceGets bool
+
+	// RetryUnauthorized makes a request rejected with 401 refresh the
+	// token and retry once. It only applies to clients made by
+	// ConfigSource; a refresh rejected with invalid_grant matches
+	// ErrReauthorizationRequired.
+	RetryUnauthorized bool
+
+	// Location, if set, is the timezone local timestamps such as sleep
+	// start times are parsed in. Otherwise the timezone from the user's
+	// profile is used. If neither can be had, e.g. for want of the
+	// profile scope, sleep start and end times are left zero and other
+	// timestamps are in UTC.
+	Location *time.Location
+
+	locMu      sync.Mutex
+	profileLoc *time.Location
+
+	// catalogMu guards the static food catalogs cached by FoodUnits and
+	// FoodLocales.
+	catalogMu   sync.Mutex
+	foodUnits   []FoodUnit
+	foodLocales []FoodLocale
+
+	flight    flightGroup
+	rateLimit rateGate
+
+	tokens *refreshingTokenSource
 }
 
 type tokenSource oauth2.Token
@@ -77,15 +134,38 @@ func NewConfigSource(cfg *oauth2.Config) *ConfigSource {
 func (c *ConfigSource) NewClient(tok *oauth2.Token) *Client {
 	// TODO(ttacon): allow the config to have deadlines/timeouts
 	// (for the context)?
+	tokens := newRefreshingTokenSource(c.cfg, tok)
 	return &Client{
-		Client:  c.cfg.Client(context.Background(), tok),
+		// Not oauth2.NewClient: it wraps the source in a ReuseTokenSource,
+		// whose cached token would hide the one forceRefresh replaces it
+		// with. tokens does its own caching.
+		Client:  &http.Client{Transport: &oauth2.Transport{Source: tokens}},
 		BaseUrl: baseURL,
+		tokens:  tokens,
 	}
 }
 
 // NewRequest creates an *http.Request with the given method, url and
-// request body (if one is passed).
-func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Request, error) {
+// request body (if one is passed), which is sent JSON encoded. Headers
+// from opts override the client's, which override the defaults.
+func (c *Client) NewRequest(method, urlStr string, body interface{}, opts ...RequestOption) (*http.Request, error) {
+	return c.newRequest(method, "", urlStr, body, opts...)
+}
+
+// versionBase returns BaseUrl with its version segment swapped for
+// version, if set.
+func (c *Client) versionBase(version string) string {
+	if version == "" {
+		return c.BaseUrl.String()
+	}
+	u := *c.BaseUrl
+	u.Path = path.Join(path.Dir(u.Path), version)
+	return u.String()
+}
+
+// newRequest is NewRequest for urlStr under the given API version (see
+// versionBase).
+func (c *Client) newRequest(method, version, urlStr string, body interface{}, opts ...RequestOption) (*http.Request, error) {
 	// this method is based off
 	// https://github.com/google/go-github/blob/master/github/github.go:
 	// NewRequest as it's a very nice way of doing this
@@ -98,7 +178,7 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 	// BASE_URL and the download url (TODO(ttacon): insert download url)
 	// this seems to be failing to work not RFC3986 (url resolution)
 	//	resolvedUrl := c.BaseUrl.ResolveReference(parsedUrl)
-	resolvedUrl, err := url.Parse(c.BaseUrl.String() + urlStr)
+	resolvedUrl, err := url.Parse(c.versionBase(version) + urlStr)
 	if err != nil {
 		return nil, err
 	}
@@ -114,9 +194,53 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 		return nil, err
 	}
 
-	// TODO(ttacon): identify which headers we should add
-	// e.g. "Accept", "Content-Type", "User-Agent", etc.
 	req.Header.Add("User-Agent", USER_AGENT)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.UnitSystem != "" {
+		req.Header.Set("Accept-Language", string(c.UnitSystem))
+	}
+	if c.FoodLocale != "" {
+		req.Header.Set("Accept-Locale", c.FoodLocale)
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req, nil
+}
+
+// NewFormRequest creates an *http.Request with the given method and url
+// whose params are sent form encoded (with their keys sorted) in the
+// body, which is how nearly all of Fitbit's write endpoints take them;
+// they reject JSON bodies. Headers are set as for NewRequest.
+func (c *Client) NewFormRequest(method, urlStr string, params url.Values, opts ...RequestOption) (*http.Request, error) {
+	return c.newFormRequest(method, "", urlStr, params, opts...)
+}
+
+// newFormRequest is NewFormRequest for urlStr under the given API
+// version.
+func (c *Client) newFormRequest(
+	method, version, urlStr string,
+	params url.Values,
+	opts ...RequestOption,
+) (*http.Request, error) {
+	req, err := c.newRequest(method, version, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := params.Encode()
+	req.Body = ioutil.NopCloser(strings.NewReader(encoded))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(encoded)), nil
+	}
+	req.ContentLength = int64(len(encoded))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, opt := range opts {
+		opt(req)
+	}
 	return req, nil
 }
 
@@ -127,19 +251,139 @@ func (c *Client) Do(req *http.Request, respStr interface{}) (*http.Response, err
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode == http.StatusUnauthorized && c.RetryUnauthorized && c.tokens != nil {
+		if resp, err = c.retryUnauthorized(req, resp); err != nil {
+			return nil, err
+		}
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode > 299 || resp.StatusCode < 200 {
-		return nil, errors.New(fmt.Sprintf("http request failed, resp: %#v", resp))
+		return nil, errorForResponse(req, resp)
 	}
 
 	// TODO(ttacon): maybe support passing in io.Writer as resp (downloads)?
-	if respStr != nil {
+	if respStr != nil && resp.StatusCode != http.StatusNoContent {
 		err = json.NewDecoder(resp.Body).Decode(respStr)
+		// Several write endpoints answer 200 or 201 with an empty body.
+		if err == io.EOF {
+			err = nil
+		}
 	}
 	return resp, err
 }
 
+// retryUnauthorized refreshes the token and sends req, rejected with
+// resp, once more.
+func (c *Client) retryUnauthorized(req *http.Request, resp *http.Response) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil, fmt.Errorf(
+			"%w: not retrying, as the request body can't be replayed (it has no GetBody): %w",
+			ErrUnauthorized, errorForResponse(req, resp),
+		)
+	}
+	var rejected string
+	if resp.Request != nil {
+		rejected = strings.TrimPrefix(resp.Request.Header.Get("Authorization"), "Bearer ")
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if err := c.tokens.forceRefresh(req.Context(), rejected); err != nil {
+		if isInvalidGrant(err) {
+			return nil, fmt.Errorf("%w: %w: %w", ErrUnauthorized, ErrReauthorizationRequired, err)
+		}
+		return nil, fmt.Errorf("fitbit: refreshing the token after a 401: %w", err)
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return c.Client.Do(retry)
+}
+
+// get issues a GET request for urlStr, bound to ctx, and decodes the
+// (json) response body into v.
+func (c *Client) get(ctx context.Context, urlStr string, v interface{}, opts ...RequestOption) error {
+	return c.getVersion(ctx, "", urlStr, v, opts...)
+}
+
+// getVersion is get for urlStr under the given API version.
+func (c *Client) getVersion(ctx context.Context, version, urlStr string, v interface{}, opts ...RequestOption) error {
+	req, err := c.newRequest("GET", version, urlStr, nil, opts...)
+	if err != nil {
+		return err
+	}
+	if c.CoalesceGets {
+		return c.doCoalesced(req.WithContext(ctx), v)
+	}
+
+	_, err = c.Do(req.WithContext(ctx), v)
+	return err
+}
+
+// RequestOption adjusts a single request before it is sent, taking
+// precedence over the client's and the library's defaults.
+type RequestOption func(*http.Request)
+
+// WithHeader sets the header key to value on a request.
+func WithHeader(key, value string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
+// withUnits sends a request in units rather than c.UnitSystem.
+func withUnits(units UnitSystem) RequestOption {
+	return WithHeader("Accept-Language", string(units))
+}
+
+// postForm POSTs params form encoded and decodes the response into v.
+func (c *Client) postForm(
+	ctx context.Context,
+	version, urlStr string,
+	params url.Values,
+	v interface{},
+	opts ...RequestOption,
+) error {
+	req, err := c.newFormRequest("POST", version, urlStr, params, opts...)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req.WithContext(ctx), v)
+	return err
+}
+
+// postJSON POSTs body JSON encoded and decodes the response into v.
+// Only a few endpoints take JSON; most want postForm.
+func (c *Client) postJSON(ctx context.Context, version, urlStr string, body, v interface{}, opts ...RequestOption) error {
+	req, err := c.newRequest("POST", version, urlStr, body, opts...)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req.WithContext(ctx), v)
+	return err
+}
+
+// delete issues a DELETE request under version for urlStr, bound to
+// ctx, ignoring any response body.
+func (c *Client) delete(ctx context.Context, version, urlStr string, opts ...RequestOption) error {
+	req, err := c.newRequest("DELETE", version, urlStr, nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req.WithContext(ctx), nil)
+	return err
+}
+
 // yyyy-MM-dd
 func (c *Client) ActivitySummaryForDay(dayString string) (ActivitySummary, error) {
 	var summary ActivitySummary
@@ -174,6 +418,7 @@ func (c *Client) UserProfile() (UserProfile, error) {
 	}
 	resp.Body.Close()
 
+	profile.User.Units = c.unitSystem()
 	return profile, nil
 }
 
@@ -209,6 +454,87 @@ type User struct {
 	StrideLengthWalkingType string  `json:"strideLengthWalkingType"`
 	DisplayName             string  `json:"displayName"`
 
-	// topBadges
-	// features
+	FirstName                string `json:"firstName"`
+	LastName                 string `json:"lastName"`
+	DisplayNameSetting       string `json:"displayNameSetting"` // name or username
+	Avatar640                string `json:"avatar640"`
+	ClockTimeDisplayFormat   string `json:"clockTimeDisplayFormat"` // 12hour or 24hour
+	LanguageLocale           string `json:"languageLocale"`
+	SwimUnit                 string `json:"swimUnit"`
+	WaterUnit                string `json:"waterUnit"`
+	WaterUnitName            string `json:"waterUnitName"`
+	TemperatureUnit          string `json:"temperatureUnit"`
+	SleepTracking            string `json:"sleepTracking"` // Normal or Sensitive
+	AutoStrideEnabled        bool   `json:"autoStrideEnabled"`
+	Ambassador               bool   `json:"ambassador"`
+	ChallengesBeta           bool   `json:"challengesBeta"`
+	IsChild                  bool   `json:"isChild"`
+	IsCoach                  bool   `json:"isCoach"`
+	LegalTermsAcceptRequired bool   `json:"legalTermsAcceptRequired"`
+	MFAEnabled               bool   `json:"mfaEnabled"`
+	SDKDeveloper             bool   `json:"sdkDeveloper"`
+
+	// City, State and AboutMe are empty unless the user has set them and
+	// they are visible to the requester.
+	City    string `json:"city"`
+	State   string `json:"state"`
+	AboutMe string `json:"aboutMe"`
+
+	// Units is the unit system Weight, Height and the stride lengths
+	// are in, which is that of the request rather than the profile's own
+	// unit settings.
+	Units UnitSystem `json:"-"`
+
+	// Reduced is set on profiles of other users that only include their
+	// public fields (see UserProfileFor). Fields missing from a reduced
+	// profile, such as Weight and Height, are left zero and are not real
+	// values.
+	Reduced bool `json:"-"`
+
+	// TopBadges are the badges the user showcases on their profile.
+	TopBadges []Badge `json:"topBadges"`
+
+	Features Features `json:"features"`
+}
+
+// Features are the feature flags of a user's account.
+type Features struct {
+	ExerciseGoal bool
+	// Other holds the flags this package doesn't know about yet, keyed by
+	// their JSON name.
+	Other map[string]json.RawMessage
+}
+
+func (f *Features) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	*f = Features{}
+	for k, v := range fields {
+		switch k {
+		case "exerciseGoal":
+			if err := json.Unmarshal(v, &f.ExerciseGoal); err != nil {
+				return err
+			}
+		default:
+			if f.Other == nil {
+				f.Other = make(map[string]json.RawMessage)
+			}
+			f.Other[k] = v
+		}
+	}
+	return nil
+}
+
+func (u *User) UnmarshalJSON(b []byte) error {
+	type user User
+	if err := json.Unmarshal(b, (*user)(u)); err != nil {
+		return err
+	}
+	// Users without badges have no topBadges at all.
+	if u.TopBadges == nil {
+		u.TopBadges = []Badge{}
+	}
+	return nil
 }
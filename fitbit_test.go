@@ -0,0 +1,347 @@
+package fitbit
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestClient returns a client for a test server serving handler.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &Client{
+		Client:  srv.Client(),
+		BaseUrl: mustParseURL(t, srv.URL+"/1"),
+	}
+}
+
+// serveFixture returns a handler answering every request with the given
+// testdata file as JSON.
+func serveFixture(t *testing.T, name string) http.Handler {
+	t.Helper()
+	data := fixture(t, name)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
+
+// serveError returns a handler answering every request with the given
+// status and testdata/errors file, an error body as Fitbit sends it.
+func serveError(t *testing.T, status int, name string) http.Handler {
+	t.Helper()
+	data := fixture(t, filepath.Join("errors", name))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(data)
+	})
+}
+
+// fixture returns the contents of the given testdata file.
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// capturedRequest is a request as a test server received it.
+type capturedRequest struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   string
+}
+
+// requestRecorder is a handler recording the requests it receives and
+// answering each with Response as JSON.
+type requestRecorder struct {
+	Response string
+
+	mu   sync.Mutex
+	reqs []capturedRequest
+}
+
+func (rr *requestRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rr.mu.Lock()
+	rr.reqs = append(rr.reqs, capturedRequest{
+		Method: r.Method,
+		URL:    r.URL,
+		Header: r.Header.Clone(),
+		Body:   string(body),
+	})
+	rr.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, rr.Response)
+}
+
+// last returns the most recent request, failing the test if there was
+// none.
+func (rr *requestRecorder) last(t *testing.T) capturedRequest {
+	t.Helper()
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if len(rr.reqs) == 0 {
+		t.Fatal("no request received")
+	}
+	return rr.reqs[len(rr.reqs)-1]
+}
+
+func TestPostJSONBody(t *testing.T) {
+	rec := &requestRecorder{Response: `{"meal":{"id":9,"name":"Breakfast"}}`}
+	c := newTestClient(t, rec)
+
+	_, err := c.CreateMeal(t.Context(), NewMeal{
+		Name:  "Breakfast",
+		Foods: []MealFoodInput{{FoodID: 1, UnitID: 2, Amount: 1.5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := rec.last(t)
+	if req.Method != "POST" || req.URL.Path != "/1/user/-/meals.json" {
+		t.Errorf("request = %s %s, want POST /1/user/-/meals.json", req.Method, req.URL.Path)
+	}
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	want := `{"name":"Breakfast","mealFoods":[{"foodId":1,"unitId":2,"amount":1.5}]}` + "\n"
+	if req.Body != want {
+		t.Errorf("body = %q, want %q", req.Body, want)
+	}
+}
+
+func TestPostFormBody(t *testing.T) {
+	rec := &requestRecorder{Response: `{"waterLog":{"logId":5,"amount":8.5}}`}
+	c := newTestClient(t, rec)
+
+	if _, err := c.LogWater(t.Context(), Date{2020, 2, 21}, 8.5, WaterUnitFlOz); err != nil {
+		t.Fatal(err)
+	}
+	req := rec.last(t)
+	if req.Method != "POST" || req.URL.Path != "/1/user/-/foods/log/water.json" {
+		t.Errorf("request = %s %s, want POST /1/user/-/foods/log/water.json", req.Method, req.URL.Path)
+	}
+	if got := req.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %q, want application/x-www-form-urlencoded", got)
+	}
+	// Keys are sorted and values escaped.
+	if want := "amount=8.5&date=2020-02-21&unit=fl+oz"; req.Body != want {
+		t.Errorf("body = %q, want %q", req.Body, want)
+	}
+}
+
+func TestNewFormRequestReplayableBody(t *testing.T) {
+	c := &Client{BaseUrl: mustParseURL(t, "https://api.fitbit.com/1")}
+	req, err := c.NewFormRequest("POST", "/user/-/foods/log/water.json", url.Values{"b": {"2 & 3"}, "a": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "a=1&b=2+%26+3"
+	if req.ContentLength != int64(len(want)) {
+		t.Errorf("ContentLength = %d, want %d", req.ContentLength, len(want))
+	}
+	for i := range 2 {
+		body, err := req.GetBody()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(body)
+		if string(b) != want {
+			t.Errorf("GetBody #%d = %q, want %q", i, b, want)
+		}
+	}
+}
+
+func TestRequestHeaderPrecedence(t *testing.T) {
+	c := &Client{
+		BaseUrl:    mustParseURL(t, "https://api.fitbit.com/1"),
+		UnitSystem: UnitSystemUS,
+		FoodLocale: "en_US",
+	}
+	newJSON := func(opts ...RequestOption) (*http.Request, error) {
+		return c.NewRequest("POST", "/user/-/meals.json", struct{}{}, opts...)
+	}
+	newForm := func(opts ...RequestOption) (*http.Request, error) {
+		return c.NewFormRequest("POST", "/user/-/foods/log/water.json", url.Values{"amount": {"1"}}, opts...)
+	}
+	for _, tt := range []struct {
+		name        string
+		new         func(...RequestOption) (*http.Request, error)
+		contentType string
+	}{
+		{"json", newJSON, "application/json"},
+		{"form", newForm, "application/x-www-form-urlencoded"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := tt.new()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range map[string]string{
+				"User-Agent":      USER_AGENT,
+				"Accept":          "application/json",
+				"Content-Type":    tt.contentType,
+				"Accept-Language": "en_US",
+				"Accept-Locale":   "en_US",
+			} {
+				if got := req.Header.Get(key); got != want {
+					t.Errorf("default %s = %q, want %q", key, got, want)
+				}
+			}
+
+			req, err = tt.new(
+				WithHeader("Accept-Language", "en_GB"),
+				WithHeader("Accept-Locale", "fr_FR"),
+				WithHeader("Accept", "text/plain"),
+				WithHeader("Content-Type", "text/plain"),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range map[string]string{
+				"Accept-Language": "en_GB",
+				"Accept-Locale":   "fr_FR",
+				"Accept":          "text/plain",
+				"Content-Type":    "text/plain",
+			} {
+				if got := req.Header.Values(key); len(got) != 1 || got[0] != want {
+					t.Errorf("overridden %s = %q, want [%q]", key, got, want)
+				}
+			}
+			// The client is left alone.
+			if c.UnitSystem != UnitSystemUS || c.FoodLocale != "en_US" {
+				t.Errorf("client changed to %q, %q", c.UnitSystem, c.FoodLocale)
+			}
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with an error body as Fitbit sends it.
+func writeError(w http.ResponseWriter, status int, errorType, fieldName, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors":  []ErrorDetail{{ErrorType: errorType, FieldName: fieldName, Message: message}},
+		"success": false,
+	})
+}
+
+// pathID returns the numeric id of a request path ending in
+// /{id}.json, as matched by a ServeMux pattern.
+func pathID(r *http.Request) int64 {
+	id, _ := strconv.ParseInt(strings.TrimSuffix(r.PathValue("id"), ".json"), 10, 64)
+	return id
+}
+
+// fakeStore holds the records of a fake Fitbit collection, such as a
+// user's meals or alarms, by id.
+type fakeStore[K cmp.Ordered, V any] struct {
+	mu     sync.Mutex
+	items  map[K]V
+	nextID int64
+}
+
+// newID returns an unused numeric id, counting up from nextID.
+func (s *fakeStore[K, V]) newID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	return s.nextID
+}
+
+func (s *fakeStore[K, V]) put(id K, v V) {
+	s.do(func(items map[K]V) { items[id] = v })
+}
+
+func (s *fakeStore[K, V]) get(id K) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.items[id]
+	return v, ok
+}
+
+// remove deletes the record with the given id, reporting whether there
+// was one.
+func (s *fakeStore[K, V]) remove(id K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.items[id]
+	delete(s.items, id)
+	return ok
+}
+
+// list returns the records keep accepts, or all of them if keep is nil,
+// ordered by id.
+func (s *fakeStore[K, V]) list(keep func(V) bool) []V {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]K, 0, len(s.items))
+	for id, v := range s.items {
+		if keep == nil || keep(v) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	list := make([]V, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, s.items[id])
+	}
+	return list
+}
+
+// do calls fn with the records under the store's lock, for changes that
+// depend on the other records.
+func (s *fakeStore[K, V]) do(fn func(items map[K]V)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.items == nil {
+		s.items = make(map[K]V)
+	}
+	fn(s.items)
+}
+
+// serveDelete handles DELETE .../{id}.json for the records of s,
+// answering a missing id as Fitbit does.
+func serveDelete[V any](s *fakeStore[int64, V], fieldName, message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.remove(pathID(r)) {
+			writeError(w, http.StatusNotFound, "not_found", fieldName, message)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,823 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+// MealType is the meal a food log entry belongs to.
+type MealType int
+
+const (
+	Breakfast      MealType = 1
+	MorningSnack   MealType = 2
+	Lunch          MealType = 3
+	AfternoonSnack MealType = 4
+	Dinner         MealType = 5
+	Anytime        MealType = 7
+)
+
+func (m MealType) valid() bool {
+	switch m {
+	case Breakfast, MorningSnack, Lunch, AfternoonSnack, Dinner, Anytime:
+		return true
+	}
+	return false
+}
+
+// FoodLog is the food logged on one day.
+type FoodLog struct {
+	Foods   []FoodLogEntry `json:"foods"`
+	Summary FoodLogSummary `json:"summary"`
+	// Goals is nil when the user has no calorie goal.
+	Goals *FoodLogGoals `json:"goals"`
+}
+
+// FoodLogEntry is a single logged food.
+type FoodLogEntry struct {
+	IsFavorite bool       `json:"isFavorite"`
+	LogDate    Date       `json:"logDate"`
+	LogID      int64      `json:"logId"`
+	LoggedFood LoggedFood `json:"loggedFood"`
+	// NutritionalValues is nil for entries Fitbit has no nutrition data
+	// for.
+	NutritionalValues *NutritionalValues `json:"nutritionalValues"`
+}
+
+// LoggedFood describes the food of a log entry and how much of it was
+// logged. Quick calorie entries have no FoodID or Unit.
+type LoggedFood struct {
+	AccessLevel string    `json:"accessLevel"` // PUBLIC or PRIVATE
+	Amount      float64   `json:"amount"`
+	Brand       string    `json:"brand"`
+	Calories    int       `json:"calories"`
+	FoodID      int64     `json:"foodId"`
+	Locale      string    `json:"locale"`
+	MealTypeID  MealType  `json:"mealTypeId"`
+	Name        string    `json:"name"`
+	Unit        *FoodUnit `json:"unit"`
+	// Units are the ids of the units the food may be logged in.
+	Units []int `json:"units"`
+}
+
+type FoodUnit struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Plural string `json:"plural"`
+}
+
+// NutritionalValues are a food's nutrition facts. Carbs, fat, fiber and
+// protein are in grams and sodium in milligrams.
+type NutritionalValues struct {
+	Calories float64 `json:"calories"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+	Fiber    float64 `json:"fiber"`
+	Protein  float64 `json:"protein"`
+	Sodium   float64 `json:"sodium"`
+}
+
+// FoodLogSummary totals a day's food log, plus the water logged that
+// day.
+type FoodLogSummary struct {
+	Calories float64 `json:"calories"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+	Fiber    float64 `json:"fiber"`
+	Protein  float64 `json:"protein"`
+	Sodium   float64 `json:"sodium"`
+	Water    float64 `json:"water"`
+}
+
+type FoodLogGoals struct {
+	Calories int `json:"calories"`
+}
+
+// FoodLog returns the food logged on date.
+func (c *Client) FoodLog(ctx context.Context, date Date) (FoodLog, error) {
+	var log FoodLog
+	err := c.get(ctx, fmt.Sprintf("/user/-/foods/log/date/%s.json", date), &log)
+	return log, err
+}
+
+// NewFoodLog is a food log entry to create. Set either FoodID, UnitID
+// and Amount to log a food from the database, or FoodName and Calories
+// for a quick entry.
+type NewFoodLog struct {
+	FoodID int64
+	UnitID int
+	Amount float64
+
+	FoodName  string
+	BrandName string
+	Calories  int
+
+	MealType MealType
+	Date     Date
+	// Favorite also adds the food to the user's favorites.
+	Favorite bool
+}
+
+func (l NewFoodLog) params() (url.Values, error) {
+	byID := l.FoodID != 0
+	byName := l.FoodName != ""
+	if byID == byName {
+		return nil, errors.New("fitbit: food log needs exactly one of FoodID or FoodName")
+	}
+	if !l.MealType.valid() {
+		return nil, fmt.Errorf("fitbit: invalid meal type %d", l.MealType)
+	}
+	if l.Date.IsZero() {
+		return nil, errors.New("fitbit: food log date is required")
+	}
+
+	params := url.Values{
+		"mealTypeId": {strconv.Itoa(int(l.MealType))},
+		"date":       {l.Date.String()},
+	}
+	if byID {
+		if l.UnitID == 0 || l.Amount <= 0 {
+			return nil, errors.New("fitbit: food log by FoodID needs a UnitID and a positive Amount")
+		}
+		params.Set("foodId", strconv.FormatInt(l.FoodID, 10))
+		params.Set("unitId", strconv.Itoa(l.UnitID))
+		params.Set("amount", strconv.FormatFloat(l.Amount, 'f', 2, 64))
+	} else {
+		if l.Calories <= 0 {
+			return nil, errors.New("fitbit: quick food log needs positive Calories")
+		}
+		params.Set("foodName", l.FoodName)
+		params.Set("calories", strconv.Itoa(l.Calories))
+		if l.BrandName != "" {
+			params.Set("brandName", l.BrandName)
+		}
+	}
+	if l.Favorite {
+		params.Set("favorite", "true")
+	}
+	return params, nil
+}
+
+// LogFood creates a food log entry. The created entry includes the
+// nutritional values Fitbit computed for the logged amount.
+func (c *Client) LogFood(ctx context.Context, l NewFoodLog) (FoodLogEntry, error) {
+	var resp struct {
+		FoodLog FoodLogEntry `json:"foodLog"`
+	}
+	params, err := l.params()
+	if err != nil {
+		return resp.FoodLog, err
+	}
+
+	err = c.postForm(ctx, "", "/user/-/foods/log.json", params, &resp)
+	return resp.FoodLog, err
+}
+
+// FoodLogUpdate holds the food log entry fields to change. Zero fields
+// are left out of the request.
+type FoodLogUpdate struct {
+	MealType MealType
+	UnitID   int
+	Amount   float64
+	// Calories changes a quick entry's calories.
+	Calories int
+	// FoodID can't be changed by the API; UpdateFoodLog rejects updates
+	// that set it. Delete the entry and log the new food instead.
+	FoodID int64
+}
+
+func (u FoodLogUpdate) params() (url.Values, error) {
+	if u.FoodID != 0 {
+		return nil, errors.New("fitbit: a food log's food can't be changed; delete it and log the new food")
+	}
+
+	params := url.Values{}
+	if u.MealType != 0 {
+		if !u.MealType.valid() {
+			return nil, fmt.Errorf("fitbit: invalid meal type %d", u.MealType)
+		}
+		params.Set("mealTypeId", strconv.Itoa(int(u.MealType)))
+	}
+	if u.UnitID != 0 {
+		params.Set("unitId", strconv.Itoa(u.UnitID))
+	}
+	if u.Amount < 0 || u.Calories < 0 {
+		return nil, errors.New("fitbit: food log amount and calories must be positive")
+	}
+	if u.Amount > 0 {
+		params.Set("amount", strconv.FormatFloat(u.Amount, 'f', 2, 64))
+	}
+	if u.Calories > 0 {
+		params.Set("calories", strconv.Itoa(u.Calories))
+	}
+	if len(params) == 0 {
+		return nil, errors.New("fitbit: food log update has no fields set")
+	}
+	return params, nil
+}
+
+// UpdateFoodLog changes the food log entry with the given id.
+func (c *Client) UpdateFoodLog(ctx context.Context, logID int64, u FoodLogUpdate) (FoodLogEntry, error) {
+	var resp struct {
+		FoodLog FoodLogEntry `json:"foodLog"`
+	}
+	params, err := u.params()
+	if err != nil {
+		return resp.FoodLog, err
+	}
+
+	err = c.postForm(ctx, "", fmt.Sprintf("/user/-/foods/log/%d.json", logID), params, &resp)
+	return resp.FoodLog, err
+}
+
+// DeleteFoodLog deletes the food log entry with the given id. It
+// returns an error matching ErrNotFound if there is no such entry.
+func (c *Client) DeleteFoodLog(ctx context.Context, logID int64) error {
+	return c.delete(ctx, "", fmt.Sprintf("/user/-/foods/log/%d.json", logID))
+}
+
+// Food is a food from Fitbit's database, or one of the user's own
+// PRIVATE foods.
+type Food struct {
+	FoodID             int64     `json:"foodId"`
+	Name               string    `json:"name"`
+	Brand              string    `json:"brand"`
+	AccessLevel        string    `json:"accessLevel"` // PUBLIC or PRIVATE
+	Calories           int       `json:"calories"`
+	DefaultServingSize float64   `json:"defaultServingSize"`
+	DefaultUnit        *FoodUnit `json:"defaultUnit"`
+	Locale             string    `json:"locale"`
+	IsGeneric          bool      `json:"isGeneric"`
+	// Units are the ids of the units the food may be logged in.
+	Units []int `json:"units"`
+
+	// Servings and NutritionalValues are only returned by Food.
+	Servings          []FoodServing          `json:"servings"`
+	NutritionalValues *FoodNutritionalValues `json:"nutritionalValues"`
+}
+
+// FoodServing is a unit a food may be logged in. Multiplier scales the
+// food's nutritional values (given for its default serving) to one
+// ServingSize of Unit.
+type FoodServing struct {
+	UnitID      int       `json:"unitId"`
+	Unit        *FoodUnit `json:"unit"`
+	Multiplier  float64   `json:"multiplier"`
+	ServingSize float64   `json:"servingSize"`
+}
+
+// FoodNutritionalValues are a food's full nutrition facts per default
+// serving. Masses are in grams except cholesterol, sodium and potassium,
+// which are in milligrams; vitamins and minerals are percentages of the
+// daily value. Missing values are zero.
+type FoodNutritionalValues struct {
+	Calories          float64 `json:"calories"`
+	CaloriesFromFat   float64 `json:"caloriesFromFat"`
+	TotalFat          float64 `json:"totalFat"`
+	TransFat          float64 `json:"transFat"`
+	SaturatedFat      float64 `json:"saturatedFat"`
+	Cholesterol       float64 `json:"cholesterol"`
+	Sodium            float64 `json:"sodium"`
+	Potassium         float64 `json:"potassium"`
+	TotalCarbohydrate float64 `json:"totalCarbohydrate"`
+	DietaryFiber      float64 `json:"dietaryFiber"`
+	Sugars            float64 `json:"sugars"`
+	Protein           float64 `json:"protein"`
+	VitaminA          float64 `json:"vitaminA"`
+	VitaminB6         float64 `json:"vitaminB6"`
+	VitaminB12        float64 `json:"vitaminB12"`
+	VitaminC          float64 `json:"vitaminC"`
+	VitaminD          float64 `json:"vitaminD"`
+	VitaminE          float64 `json:"vitaminE"`
+	Biotin            float64 `json:"biotin"`
+	FolicAcid         float64 `json:"folicAcid"`
+	Niacin            float64 `json:"niacin"`
+	PantothenicAcid   float64 `json:"pantothenicAcid"`
+	Riboflavin        float64 `json:"riboflavin"`
+	Thiamin           float64 `json:"thiamin"`
+	Calcium           float64 `json:"calcium"`
+	Copper            float64 `json:"copper"`
+	Iron              float64 `json:"iron"`
+	Magnesium         float64 `json:"magnesium"`
+	Phosphorus        float64 `json:"phosphorus"`
+	Iodine            float64 `json:"iodine"`
+	Zinc              float64 `json:"zinc"`
+}
+
+// SearchFoods searches the food database, including the user's private
+// foods, for query. Results are in the order Fitbit ranks them, from the
+// database selected by c.FoodLocale.
+func (c *Client) SearchFoods(ctx context.Context, query string) ([]Food, error) {
+	var resp struct {
+		Foods []Food `json:"foods"`
+	}
+	q := url.Values{"query": {query}}
+	if err := c.get(ctx, "/foods/search.json?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Foods, nil
+}
+
+// Food returns the food with the given id, including its servings and
+// full nutritional values. Another user's PRIVATE food results in an
+// error matching ErrForbidden, which Fitbit answers with a 403, and an
+// unknown food in one matching ErrNotFound.
+func (c *Client) Food(ctx context.Context, foodID int64) (Food, error) {
+	var resp struct {
+		Food Food `json:"food"`
+	}
+	err := c.get(ctx, fmt.Sprintf("/foods/%d.json", foodID), &resp)
+	return resp.Food, err
+}
+
+// FoodUnits returns every unit foods may be logged in. The list is large
+// but static, so it is fetched once and cached on the client; use
+// InvalidateFoodUnits to fetch it again.
+func (c *Client) FoodUnits(ctx context.Context) ([]FoodUnit, error) {
+	for {
+		c.catalogMu.Lock()
+		cached := c.foodUnits
+		c.catalogMu.Unlock()
+		if cached != nil {
+			units := make([]FoodUnit, len(cached))
+			copy(units, cached)
+			return units, nil
+		}
+
+		// The fetch is made without holding c.catalogMu, and caches the
+		// list before it ends; if it is invalidated meanwhile, fetch anew.
+		_, err := c.flight.do(ctx, "food units", func() (json.RawMessage, error) {
+			var units []FoodUnit
+			if err := c.get(ctx, "/foods/units.json", &units); err != nil {
+				return nil, err
+			}
+			if units == nil {
+				units = []FoodUnit{}
+			}
+			c.catalogMu.Lock()
+			c.foodUnits = units
+			c.catalogMu.Unlock()
+			return nil, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// InvalidateFoodUnits drops the units cached by FoodUnits.
+func (c *Client) InvalidateFoodUnits() {
+	c.catalogMu.Lock()
+	c.foodUnits = nil
+	c.catalogMu.Unlock()
+}
+
+// FoodFormType describes the physical form of a food.
+type FoodFormType string
+
+const (
+	FoodFormLiquid FoodFormType = "LIQUID"
+	FoodFormDry    FoodFormType = "DRY"
+)
+
+// NewFood is a private food to create with CreateFood. Name,
+// DefaultFoodMeasurementUnitID, a positive DefaultServingSize and
+// Calories are required; the nutrition fields are optional and sent only
+// when set (see Float64), in the same units as FoodNutritionalValues.
+type NewFood struct {
+	Name                         string
+	DefaultFoodMeasurementUnitID int
+	DefaultServingSize           float64
+	Calories                     int
+	FormType                     FoodFormType
+	Description                  string
+
+	CaloriesFromFat   *float64
+	TotalFat          *float64
+	TransFat          *float64
+	SaturatedFat      *float64
+	Cholesterol       *float64
+	Sodium            *float64
+	Potassium         *float64
+	TotalCarbohydrate *float64
+	DietaryFiber      *float64
+	Sugars            *float64
+	Protein           *float64
+	VitaminA          *float64
+	VitaminB6         *float64
+	VitaminB12        *float64
+	VitaminC          *float64
+	VitaminD          *float64
+	VitaminE          *float64
+	Biotin            *float64
+	FolicAcid         *float64
+	Niacin            *float64
+	PantothenicAcid   *float64
+	Riboflavin        *float64
+	Thiamin           *float64
+	Calcium           *float64
+	Copper            *float64
+	Iron              *float64
+	Magnesium         *float64
+	Phosphorus        *float64
+	Iodine            *float64
+	Zinc              *float64
+}
+
+// Float64 returns a pointer to v, for the optional fields of NewFood.
+func Float64(v float64) *float64 {
+	return &v
+}
+
+func (f NewFood) params() (url.Values, error) {
+	if f.Name == "" {
+		return nil, errors.New("fitbit: new food needs a Name")
+	}
+	if f.DefaultFoodMeasurementUnitID == 0 || f.DefaultServingSize <= 0 {
+		return nil, errors.New("fitbit: new food needs a DefaultFoodMeasurementUnitID and a positive DefaultServingSize")
+	}
+	if f.Calories < 0 {
+		return nil, errors.New("fitbit: new food calories must not be negative")
+	}
+	switch f.FormType {
+	case "", FoodFormLiquid, FoodFormDry:
+	default:
+		return nil, fmt.Errorf("fitbit: invalid food form type %q", f.FormType)
+	}
+
+	params := url.Values{
+		"name":                         {f.Name},
+		"defaultFoodMeasurementUnitId": {strconv.Itoa(f.DefaultFoodMeasurementUnitID)},
+		"defaultServingSize":           {strconv.FormatFloat(f.DefaultServingSize, 'f', -1, 64)},
+		"calories":                     {strconv.Itoa(f.Calories)},
+	}
+	if f.FormType != "" {
+		params.Set("formType", string(f.FormType))
+	}
+	if f.Description != "" {
+		params.Set("description", f.Description)
+	}
+
+	optional := []struct {
+		name  string
+		value *float64
+	}{
+		{"caloriesFromFat", f.CaloriesFromFat},
+		{"totalFat", f.TotalFat},
+		{"transFat", f.TransFat},
+		{"saturatedFat", f.SaturatedFat},
+		{"cholesterol", f.Cholesterol},
+		{"sodium", f.Sodium},
+		{"potassium", f.Potassium},
+		{"totalCarbohydrate", f.TotalCarbohydrate},
+		{"dietaryFiber", f.DietaryFiber},
+		{"sugars", f.Sugars},
+		{"protein", f.Protein},
+		{"vitaminA", f.VitaminA},
+		{"vitaminB6", f.VitaminB6},
+		{"vitaminB12", f.VitaminB12},
+		{"vitaminC", f.VitaminC},
+		{"vitaminD", f.VitaminD},
+		{"vitaminE", f.VitaminE},
+		{"biotin", f.Biotin},
+		{"folicAcid", f.FolicAcid},
+		{"niacin", f.Niacin},
+		{"pantothenicAcid", f.PantothenicAcid},
+		{"riboflavin", f.Riboflavin},
+		{"thiamin", f.Thiamin},
+		{"calcium", f.Calcium},
+		{"copper", f.Copper},
+		{"iron", f.Iron},
+		{"magnesium", f.Magnesium},
+		{"phosphorus", f.Phosphorus},
+		{"iodine", f.Iodine},
+		{"zinc", f.Zinc},
+	}
+	for _, o := range optional {
+		if o.value == nil {
+			continue
+		}
+		if *o.value < 0 {
+			return nil, fmt.Errorf("fitbit: new food %s must not be negative", o.name)
+		}
+		params.Set(o.name, strconv.FormatFloat(*o.value, 'f', -1, 64))
+	}
+	return params, nil
+}
+
+// CreateFood creates a private food for the user and returns it with the
+// food id Fitbit assigned.
+func (c *Client) CreateFood(ctx context.Context, f NewFood) (Food, error) {
+	var resp struct {
+		Food Food `json:"food"`
+	}
+	params, err := f.params()
+	if err != nil {
+		return resp.Food, err
+	}
+
+	err = c.postForm(ctx, "", "/user/-/foods.json", params, &resp)
+	return resp.Food, err
+}
+
+// DeleteFood deletes one of the user's own PRIVATE foods. Public foods
+// can't be deleted; Fitbit's refusal is returned as an *APIError matching
+// ErrForbidden, with its explanation in Errors.
+func (c *Client) DeleteFood(ctx context.Context, foodID int64) error {
+	return c.delete(ctx, "", fmt.Sprintf("/user/-/foods/%d.json", foodID))
+}
+
+// FavoriteFoods returns the user's favorite foods, in the order Fitbit
+// lists them.
+func (c *Client) FavoriteFoods(ctx context.Context) ([]Food, error) {
+	var foods []Food
+	if err := c.get(ctx, "/user/-/foods/log/favorite.json", &foods); err != nil {
+		return nil, err
+	}
+	if foods == nil {
+		foods = []Food{}
+	}
+	return foods, nil
+}
+
+// AddFavoriteFood adds the food with the given id to the user's
+// favorites. Adding a food that already is a favorite isn't an error.
+func (c *Client) AddFavoriteFood(ctx context.Context, foodID int64) error {
+	urlStr := fmt.Sprintf("/user/-/foods/log/favorite/%d.json", foodID)
+	err := c.postForm(ctx, "", urlStr, url.Values{}, nil)
+	// Fitbit rejects a food that already is a favorite with a validation
+	// error on foodId; unknown foods are a 404.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && apiErr.hasError("validation", "foodId") {
+		return nil
+	}
+	return err
+}
+
+// RemoveFavoriteFood removes the food with the given id from the user's
+// favorites. It returns an error matching ErrNotFound if the food isn't a
+// favorite.
+func (c *Client) RemoveFavoriteFood(ctx context.Context, foodID int64) error {
+	err := c.delete(ctx, "", fmt.Sprintf("/user/-/foods/log/favorite/%d.json", foodID))
+	// As for AddFavoriteFood, Fitbit reports the food not being a
+	// favorite as a validation error on foodId.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && apiErr.hasError("validation", "foodId") {
+		return fmt.Errorf("%w: %w", ErrNotFound, apiErr)
+	}
+	return err
+}
+
+// FrequentFood is one of the foods the user logs most often, with the
+// amount, unit and meal it is usually logged with.
+type FrequentFood struct {
+	FoodID      int64     `json:"foodId"`
+	Name        string    `json:"name"`
+	Brand       string    `json:"brand"`
+	AccessLevel string    `json:"accessLevel"` // PUBLIC or PRIVATE
+	Calories    int       `json:"calories"`
+	Amount      float64   `json:"amount"`
+	Unit        *FoodUnit `json:"unit"`
+	MealTypeID  MealType  `json:"mealTypeId"`
+	// Units are the ids of the units the food may be logged in.
+	Units []int `json:"units"`
+}
+
+// NewLog returns a log entry for the food on date with its usual amount,
+// unit and meal.
+func (f FrequentFood) NewLog(date Date) NewFoodLog {
+	l := NewFoodLog{
+		FoodID:   f.FoodID,
+		Amount:   f.Amount,
+		MealType: f.MealTypeID,
+		Date:     date,
+	}
+	if f.Unit != nil {
+		l.UnitID = f.Unit.ID
+	}
+	return l
+}
+
+// FrequentFoods returns the foods the user logs most often.
+func (c *Client) FrequentFoods(ctx context.Context) ([]FrequentFood, error) {
+	var foods []FrequentFood
+	if err := c.get(ctx, "/user/-/foods/log/frequent.json", &foods); err != nil {
+		return nil, err
+	}
+	if foods == nil {
+		foods = []FrequentFood{}
+	}
+	return foods, nil
+}
+
+// RecentFood is a food the user logged recently, with the amount and
+// unit it was last logged in. A food logged in several units appears
+// once per unit.
+type RecentFood struct {
+	FoodID        int64     `json:"foodId"`
+	Name          string    `json:"name"`
+	Brand         string    `json:"brand"`
+	AccessLevel   string    `json:"accessLevel"` // PUBLIC or PRIVATE
+	Calories      int       `json:"calories"`
+	Amount        float64   `json:"amount"`
+	Unit          *FoodUnit `json:"unit"`
+	DateLastEaten Date      `json:"dateLastEaten"`
+	// Units are the ids of the units the food may be logged in.
+	Units []int `json:"units"`
+}
+
+// RecentFoods returns the foods the user logged recently, in the order
+// Fitbit lists them.
+func (c *Client) RecentFoods(ctx context.Context) ([]RecentFood, error) {
+	var foods []RecentFood
+	if err := c.get(ctx, "/user/-/foods/log/recent.json", &foods); err != nil {
+		return nil, err
+	}
+	if foods == nil {
+		foods = []RecentFood{}
+	}
+	return foods, nil
+}
+
+// FoodPlanIntensity is how aggressively a food plan cuts calories.
+type FoodPlanIntensity string
+
+const (
+	FoodPlanMaintenance FoodPlanIntensity = "MAINTENANCE"
+	FoodPlanEasier      FoodPlanIntensity = "EASIER"
+	FoodPlanMedium      FoodPlanIntensity = "MEDIUM"
+	FoodPlanKindaHard   FoodPlanIntensity = "KINDAHARD"
+	FoodPlanHarder      FoodPlanIntensity = "HARDER"
+)
+
+// FoodGoals is the user's daily calorie intake goal.
+type FoodGoals struct {
+	Calories int
+	// FoodPlan is nil unless the user is on a food plan.
+	FoodPlan *FoodPlan
+}
+
+// FoodPlan is the plan a food goal was derived from. EstimatedDate is
+// when the plan expects the user to reach their weight goal.
+type FoodPlan struct {
+	Intensity     FoodPlanIntensity `json:"intensity"`
+	EstimatedDate Date              `json:"estimatedDate"`
+	Personalized  bool              `json:"personalized"`
+}
+
+type foodGoalsResponse struct {
+	Goals struct {
+		Calories *int `json:"calories"`
+	} `json:"goals"`
+	FoodPlan *FoodPlan `json:"foodPlan"`
+}
+
+func (r foodGoalsResponse) goals() (FoodGoals, error) {
+	if r.Goals.Calories == nil {
+		return FoodGoals{}, ErrNoGoal
+	}
+	return FoodGoals{Calories: *r.Goals.Calories, FoodPlan: r.FoodPlan}, nil
+}
+
+// FoodGoals returns the user's calorie intake goal, or ErrNoGoal if they
+// haven't set one.
+func (c *Client) FoodGoals(ctx context.Context) (FoodGoals, error) {
+	var resp foodGoalsResponse
+	if err := c.get(ctx, "/user/-/foods/log/goal.json", &resp); err != nil {
+		return FoodGoals{}, err
+	}
+	return resp.goals()
+}
+
+// FoodGoalUpdate sets the calorie intake goal either to Calories
+// directly or from a food plan Intensity, but not both. Personalized only
+// applies to an Intensity.
+type FoodGoalUpdate struct {
+	Calories     int
+	Intensity    FoodPlanIntensity
+	Personalized bool
+}
+
+func (u FoodGoalUpdate) params() (url.Values, error) {
+	if (u.Calories != 0) == (u.Intensity != "") {
+		return nil, errors.New("fitbit: food goal update needs exactly one of Calories or Intensity")
+	}
+	if u.Calories != 0 {
+		if u.Calories < 0 {
+			return nil, errors.New("fitbit: food goal calories must be positive")
+		}
+		if u.Personalized {
+			return nil, errors.New("fitbit: Personalized only applies to a food goal Intensity")
+		}
+		return url.Values{"calories": {strconv.Itoa(u.Calories)}}, nil
+	}
+
+	switch u.Intensity {
+	case FoodPlanMaintenance, FoodPlanEasier, FoodPlanMedium, FoodPlanKindaHard, FoodPlanHarder:
+	default:
+		return nil, fmt.Errorf("fitbit: invalid food plan intensity %q", u.Intensity)
+	}
+	return url.Values{
+		"intensity":    {string(u.Intensity)},
+		"personalized": {strconv.FormatBool(u.Personalized)},
+	}, nil
+}
+
+// SetFoodGoal sets the user's calorie intake goal and returns the
+// resulting goals, including the calories Fitbit computed for an
+// Intensity.
+func (c *Client) SetFoodGoal(ctx context.Context, u FoodGoalUpdate) (FoodGoals, error) {
+	params, err := u.params()
+	if err != nil {
+		return FoodGoals{}, err
+	}
+
+	var resp foodGoalsResponse
+	if err := c.postForm(ctx, "", "/user/-/foods/log/goal.json", params, &resp); err != nil {
+		return FoodGoals{}, err
+	}
+	return resp.goals()
+}
+
+// FoodLocale is a food database locale that can be selected with
+// Client.FoodLocale.
+type FoodLocale struct {
+	Value string `json:"value"` // e.g. en_US
+	Label string `json:"label"`
+	// Barcode and ImageUpload report whether foods in the locale can be
+	// looked up by barcode and by photo.
+	Barcode     bool `json:"barcode"`
+	ImageUpload bool `json:"imageUpload"`
+}
+
+// FoodLocales returns the food database locales Fitbit supports. Like
+// FoodUnits the list is cached on the client; use InvalidateFoodLocales
+// to fetch it again.
+func (c *Client) FoodLocales(ctx context.Context) ([]FoodLocale, error) {
+	for {
+		c.catalogMu.Lock()
+		cached := c.foodLocales
+		c.catalogMu.Unlock()
+		if cached != nil {
+			locales := make([]FoodLocale, len(cached))
+			copy(locales, cached)
+			return locales, nil
+		}
+
+		// Fetched as in FoodUnits.
+		_, err := c.flight.do(ctx, "food locales", func() (json.RawMessage, error) {
+			var locales []FoodLocale
+			if err := c.get(ctx, "/foods/locales.json", &locales); err != nil {
+				return nil, err
+			}
+			if locales == nil {
+				locales = []FoodLocale{}
+			}
+			c.catalogMu.Lock()
+			c.foodLocales = locales
+			c.catalogMu.Unlock()
+			return nil, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// InvalidateFoodLocales drops the locales cached by FoodLocales.
+func (c *Client) InvalidateFoodLocales() {
+	c.catalogMu.Lock()
+	c.foodLocales = nil
+	c.catalogMu.Unlock()
+}
+
+// quickCaloriesFoodName is the food name quick calorie entries are
+// logged under.
+const quickCaloriesFoodName = "Quick Calories"
+
+// maxQuickCalories bounds the calories of a single quick entry.
+const maxQuickCalories = 10000
+
+// LogQuickCalories logs calories on date without picking a food, as a
+// quick entry named "Quick Calories". A zero mealType logs it as
+// Anytime.
+func (c *Client) LogQuickCalories(ctx context.Context, date Date, mealType MealType, calories int) (FoodLogEntry, error) {
+	if calories <= 0 || calories > maxQuickCalories {
+		return FoodLogEntry{}, fmt.Errorf("fitbit: quick calories must be between 1 and %d", maxQuickCalories)
+	}
+	if mealType == 0 {
+		mealType = Anytime
+	}
+	return c.LogFood(ctx, NewFoodLog{
+		FoodName: quickCaloriesFoodName,
+		Calories: calories,
+		MealType: mealType,
+		Date:     date,
+	})
+}
@@ -0,0 +1,719 @@
+package fitbit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAddFavoriteFood(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		handler http.Handler
+		check   func(error) bool
+	}{
+		{"added", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}), func(err error) bool { return err == nil }},
+		{"already a favorite", serveError(t, http.StatusBadRequest, "favorite_already_added.json"),
+			func(err error) bool { return err == nil }},
+		{"unknown food", serveError(t, http.StatusNotFound, "food_not_found.json"),
+			func(err error) bool { return errors.Is(err, ErrNotFound) }},
+		// Mentions "already", but isn't about the food.
+		{"other bad request", serveError(t, http.StatusBadRequest, "invalid_request.json"),
+			func(err error) bool { var apiErr *APIError; return errors.As(err, &apiErr) && apiErr.StatusCode == 400 }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("POST /1/user/-/foods/log/favorite/82782.json", tt.handler)
+			c := newTestClient(t, mux)
+			if err := c.AddFavoriteFood(t.Context(), 82782); !tt.check(err) {
+				t.Errorf("err = %v", err)
+			}
+		})
+	}
+}
+
+func TestRemoveFavoriteFood(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		handler  http.Handler
+		wantErr  bool
+		notFound bool
+	}{
+		{"removed", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}), false, false},
+		{"not a favorite", serveError(t, http.StatusBadRequest, "favorite_not_found.json"), true, true},
+		{"unknown food", serveError(t, http.StatusNotFound, "food_not_found.json"), true, true},
+		{"other bad request", serveError(t, http.StatusBadRequest, "invalid_request.json"), true, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("DELETE /1/user/-/foods/log/favorite/82782.json", tt.handler)
+			c := newTestClient(t, mux)
+			err := c.RemoveFavoriteFood(t.Context(), 82782)
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrNotFound) != tt.notFound {
+				t.Errorf("err = %v, want an error: %t, matching ErrNotFound: %t", err, tt.wantErr, tt.notFound)
+			}
+		})
+	}
+}
+
+func TestFood(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/foods/81137.json", serveFixture(t, "food.json"))
+	c := newTestClient(t, mux)
+
+	f, err := c.Food(t.Context(), 81137)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.FoodID != 81137 || f.Name != "Apple" || f.AccessLevel != "PUBLIC" || f.DefaultUnit == nil || f.DefaultUnit.ID != 304 {
+		t.Errorf("food = %+v", f)
+	}
+	if len(f.Servings) != 3 {
+		t.Fatalf("got %d servings, want 3", len(f.Servings))
+	}
+	if s := f.Servings[1]; s.UnitID != 91 || s.Multiplier != 1.82 || s.ServingSize != 1 || s.Unit == nil || s.Unit.Plural != "cups" {
+		t.Errorf("Servings[1] = %+v", s)
+	}
+	if n := f.NutritionalValues; n == nil || n.TotalCarbohydrate != 13.81 || n.DietaryFiber != 2.4 || n.Sugars != 10.39 {
+		t.Errorf("NutritionalValues = %+v", n)
+	}
+}
+
+func TestFoodErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		handler http.Handler
+		want    error
+	}{
+		{"another user's private food", serveError(t, http.StatusForbidden, "food_private.json"), ErrForbidden},
+		{"unknown food", serveError(t, http.StatusNotFound, "food_not_found.json"), ErrNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.handler)
+			_, err := c.Food(t.Context(), 80327499)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchFoodsEncodesQuery(t *testing.T) {
+	for _, tt := range []struct {
+		query    string
+		rawQuery string
+	}{
+		{"crème brûlée", "query=cr%C3%A8me+br%C3%BBl%C3%A9e"},
+		{"豆腐", "query=%E8%B1%86%E8%85%90"},
+		{"mac & cheese", "query=mac+%26+cheese"},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := &requestRecorder{Response: `{"foods":[{"foodId":1,"name":"` + tt.query + `"}]}`}
+			c := newTestClient(t, rec)
+
+			foods, err := c.SearchFoods(t.Context(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := rec.last(t)
+			if req.URL.Path != "/1/foods/search.json" || req.URL.RawQuery != tt.rawQuery {
+				t.Errorf("request = %s?%s, want /1/foods/search.json?%s", req.URL.Path, req.URL.RawQuery, tt.rawQuery)
+			}
+			if got := req.URL.Query().Get("query"); got != tt.query {
+				t.Errorf("server decoded query %q, want %q", got, tt.query)
+			}
+			if len(foods) != 1 || foods[0].Name != tt.query {
+				t.Errorf("foods = %+v", foods)
+			}
+		})
+	}
+}
+
+// fakeFoods fakes Fitbit's food endpoints over foods, which holds the
+// public database as well as the user's own foods, and logs.
+type fakeFoods struct {
+	foods fakeStore[int64, Food]
+	logs  fakeStore[int64, FoodLogEntry]
+}
+
+// logged fills in entry's food and nutrition for amount of food.
+func (f *fakeFoods) logged(entry *FoodLogEntry, food Food, amount float64) {
+	entry.LoggedFood.FoodID = food.FoodID
+	entry.LoggedFood.Name = food.Name
+	entry.LoggedFood.Amount = amount
+	entry.LoggedFood.Calories = int(float64(food.Calories) * amount)
+	entry.NutritionalValues = &NutritionalValues{Calories: float64(entry.LoggedFood.Calories)}
+}
+
+func (f *fakeFoods) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /1/user/-/foods.json", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, "validation", "n/a", err.Error())
+			return
+		}
+		unitID, err1 := strconv.Atoi(r.PostForm.Get("defaultFoodMeasurementUnitId"))
+		size, err2 := strconv.ParseFloat(r.PostForm.Get("defaultServingSize"), 64)
+		calories, err3 := strconv.Atoi(r.PostForm.Get("calories"))
+		if r.PostForm.Get("name") == "" || errors.Join(err1, err2, err3) != nil {
+			writeError(w, http.StatusBadRequest, "validation", "n/a", "missing or invalid food parameters")
+			return
+		}
+		food := Food{
+			FoodID:             f.foods.newID(),
+			Name:               r.PostForm.Get("name"),
+			AccessLevel:        "PRIVATE",
+			Calories:           calories,
+			DefaultServingSize: size,
+			DefaultUnit:        &FoodUnit{ID: unitID},
+			Units:              []int{unitID},
+		}
+		f.foods.put(food.FoodID, food)
+		writeJSON(w, http.StatusCreated, map[string]Food{"food": food})
+	})
+	mux.HandleFunc("GET /1/foods/{id}", func(w http.ResponseWriter, r *http.Request) {
+		food, ok := f.foods.get(pathID(r))
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found", "n/a", "Food not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]Food{"food": food})
+	})
+	mux.HandleFunc("DELETE /1/user/-/foods/{id}", func(w http.ResponseWriter, r *http.Request) {
+		food, ok := f.foods.get(pathID(r))
+		switch {
+		case !ok:
+			writeError(w, http.StatusNotFound, "not_found", "n/a", "Food not found")
+		case food.AccessLevel != "PRIVATE":
+			writeError(w, http.StatusForbidden, "request", "foodId", "Public foods can't be deleted")
+		default:
+			f.foods.remove(food.FoodID)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("POST /1/user/-/foods/log.json", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		date, err1 := ParseDate(r.PostForm.Get("date"))
+		meal, err2 := strconv.Atoi(r.PostForm.Get("mealTypeId"))
+		amount, err3 := strconv.ParseFloat(r.PostForm.Get("amount"), 64)
+		foodID, _ := strconv.ParseInt(r.PostForm.Get("foodId"), 10, 64)
+		food, ok := f.foods.get(foodID)
+		if !ok || errors.Join(err1, err2, err3) != nil {
+			writeError(w, http.StatusBadRequest, "validation", "n/a", "missing or invalid food log parameters")
+			return
+		}
+		entry := FoodLogEntry{LogID: f.logs.newID(), LogDate: date}
+		entry.LoggedFood.MealTypeID = MealType(meal)
+		f.logged(&entry, food, amount)
+		f.logs.put(entry.LogID, entry)
+		writeJSON(w, http.StatusCreated, map[string]FoodLogEntry{"foodLog": entry})
+	})
+	mux.HandleFunc("POST /1/user/-/foods/log/{id}", func(w http.ResponseWriter, r *http.Request) {
+		entry, ok := f.logs.get(pathID(r))
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found", "foodLogId", "Food log not found")
+			return
+		}
+		r.ParseForm()
+		if meal, err := strconv.Atoi(r.PostForm.Get("mealTypeId")); err == nil {
+			entry.LoggedFood.MealTypeID = MealType(meal)
+		}
+		if amount, err := strconv.ParseFloat(r.PostForm.Get("amount"), 64); err == nil {
+			food, _ := f.foods.get(entry.LoggedFood.FoodID)
+			f.logged(&entry, food, amount)
+		}
+		f.logs.put(entry.LogID, entry)
+		writeJSON(w, http.StatusOK, map[string]FoodLogEntry{"foodLog": entry})
+	})
+	mux.HandleFunc("GET /1/user/-/foods/log/date/{date}", func(w http.ResponseWriter, r *http.Request) {
+		var log FoodLog
+		log.Foods = f.logs.list(func(e FoodLogEntry) bool {
+			return e.LogDate.String()+".json" == r.PathValue("date")
+		})
+		for _, e := range log.Foods {
+			log.Summary.Calories += e.NutritionalValues.Calories
+		}
+		writeJSON(w, http.StatusOK, log)
+	})
+	mux.Handle("DELETE /1/user/-/foods/log/{id}", serveDelete(&f.logs, "foodLogId", "Food log not found"))
+	return mux
+}
+
+func TestCustomFoodLifecycle(t *testing.T) {
+	f := &fakeFoods{foods: fakeStore[int64, Food]{
+		items:  map[int64]Food{81137: {FoodID: 81137, Name: "Apple", AccessLevel: "PUBLIC"}},
+		nextID: 90000000,
+	}}
+	c := newTestClient(t, f.handler())
+
+	created, err := c.CreateFood(t.Context(), NewFood{
+		Name:                         "Granola",
+		DefaultFoodMeasurementUnitID: 147,
+		DefaultServingSize:           45,
+		Calories:                     210,
+		FormType:                     FoodFormDry,
+		Protein:                      Float64(5),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.FoodID == 0 || created.Name != "Granola" || created.AccessLevel != "PRIVATE" || created.Calories != 210 {
+		t.Errorf("created = %+v", created)
+	}
+
+	got, err := c.Food(t.Context(), created.FoodID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FoodID != created.FoodID || got.DefaultUnit == nil || got.DefaultUnit.ID != 147 || got.DefaultServingSize != 45 {
+		t.Errorf("Food = %+v", got)
+	}
+
+	if err := c.DeleteFood(t.Context(), created.FoodID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Food(t.Context(), created.FoodID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Food after delete = %v, want ErrNotFound", err)
+	}
+	if err := c.DeleteFood(t.Context(), created.FoodID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting it again = %v, want ErrNotFound", err)
+	}
+
+	// Public foods can't be deleted, and Fitbit's explanation comes
+	// through.
+	err = c.DeleteFood(t.Context(), 81137)
+	var apiErr *APIError
+	if !errors.Is(err, ErrForbidden) || !errors.As(err, &apiErr) || len(apiErr.Errors) != 1 || apiErr.Errors[0].Message != "Public foods can't be deleted" {
+		t.Errorf("deleting a public food = %v, want ErrForbidden with Fitbit's message", err)
+	}
+	if _, err := c.Food(t.Context(), 81137); err != nil {
+		t.Errorf("public food is gone: %v", err)
+	}
+}
+
+func TestFoodLogLifecycle(t *testing.T) {
+	f := &fakeFoods{foods: fakeStore[int64, Food]{
+		items: map[int64]Food{81137: {FoodID: 81137, Name: "Apple", AccessLevel: "PUBLIC", Calories: 95}},
+	}}
+	c := newTestClient(t, f.handler())
+	day := Date{2021, 10, 25}
+
+	created, err := c.LogFood(t.Context(), NewFoodLog{FoodID: 81137, UnitID: 304, Amount: 1, MealType: Breakfast, Date: day})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.LogID == 0 || created.LogDate != day || created.LoggedFood.FoodID != 81137 ||
+		created.LoggedFood.MealTypeID != Breakfast || created.LoggedFood.Calories != 95 {
+		t.Errorf("created = %+v", created)
+	}
+
+	updated, err := c.UpdateFoodLog(t.Context(), created.LogID, FoodLogUpdate{MealType: Lunch, Amount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.LogID != created.LogID || updated.LoggedFood.MealTypeID != Lunch ||
+		updated.LoggedFood.Amount != 2 || updated.LoggedFood.Calories != 190 {
+		t.Errorf("updated = %+v", updated)
+	}
+	log, err := c.FoodLog(t.Context(), day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log.Foods) != 1 || log.Foods[0].LoggedFood.MealTypeID != Lunch || log.Summary.Calories != 190 {
+		t.Errorf("FoodLog after update = %+v", log)
+	}
+
+	if err := c.DeleteFoodLog(t.Context(), created.LogID); err != nil {
+		t.Fatal(err)
+	}
+	if log, err := c.FoodLog(t.Context(), day); err != nil || len(log.Foods) != 0 {
+		t.Errorf("FoodLog after delete = %+v, %v, want no foods", log, err)
+	}
+	if err := c.DeleteFoodLog(t.Context(), created.LogID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting it again = %v, want ErrNotFound", err)
+	}
+	if _, err := c.UpdateFoodLog(t.Context(), created.LogID, FoodLogUpdate{Amount: 1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("updating it after delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFoodLog(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/foods/log/date/2021-10-25.json", serveFixture(t, "food_log.json"))
+	c := newTestClient(t, mux)
+
+	log, err := c.FoodLog(t.Context(), Date{2021, 10, 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log.Foods) != 3 {
+		t.Fatalf("got %d foods, want 3", len(log.Foods))
+	}
+
+	branded := log.Foods[0]
+	if !branded.IsFavorite || branded.LogDate != (Date{2021, 10, 25}) || branded.LogID != 27230633219 {
+		t.Errorf("branded entry = %+v", branded)
+	}
+	if f := branded.LoggedFood; f.Brand != "Chobani" || f.FoodID != 19540819 || f.MealTypeID != Breakfast || f.Unit == nil || f.Unit.Plural != "containers" || len(f.Units) != 5 {
+		t.Errorf("branded food = %+v", f)
+	}
+	if n := branded.NutritionalValues; n == nil || n.Calories != 130 || n.Fat != 2.5 || n.Protein != 12 || n.Sodium != 55 {
+		t.Errorf("branded nutrition = %+v", n)
+	}
+
+	custom := log.Foods[1].LoggedFood
+	if custom.AccessLevel != "PRIVATE" || custom.FoodID != 790472261 || custom.Amount != 45 || custom.Unit == nil || custom.Unit.ID != 147 || custom.Brand != "" {
+		t.Errorf("custom food = %+v", custom)
+	}
+
+	quick := log.Foods[2]
+	if f := quick.LoggedFood; f.FoodID != 0 || f.Unit != nil || f.Calories != 300 || f.MealTypeID != Anytime {
+		t.Errorf("quick entry food = %+v", f)
+	}
+	if quick.NutritionalValues != nil {
+		t.Errorf("quick entry nutrition = %+v, want nil", quick.NutritionalValues)
+	}
+
+	if log.Goals == nil || log.Goals.Calories != 1980 {
+		t.Errorf("Goals = %+v", log.Goals)
+	}
+	want := FoodLogSummary{Calories: 640, Carbs: 43, Fat: 11.5, Fiber: 4, Protein: 17, Sodium: 75, Water: 1250}
+	if log.Summary != want {
+		t.Errorf("Summary = %+v, want %+v", log.Summary, want)
+	}
+}
+
+func TestFoodLogWithoutGoals(t *testing.T) {
+	c := newTestClient(t, &requestRecorder{Response: `{"foods":[],"summary":{"calories":0,"water":0}}`})
+	log, err := c.FoodLog(t.Context(), Date{2021, 10, 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Goals != nil || len(log.Foods) != 0 {
+		t.Errorf("log = %+v, want no foods and nil Goals", log)
+	}
+}
+
+func TestRecentFoods(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/foods/log/recent.json", serveFixture(t, "foods_recent.json"))
+	c := newTestClient(t, mux)
+
+	foods, err := c.RecentFoods(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(foods) != 3 {
+		t.Fatalf("got %d foods, want 3", len(foods))
+	}
+	// The same food appears once per unit it was logged in, in Fitbit's
+	// order.
+	for i, want := range []struct {
+		foodID   int64
+		unitID   int
+		amount   float64
+		lastUsed Date
+	}{
+		{81137, 304, 1, Date{2021, 10, 25}},
+		{81137, 147, 150, Date{2021, 10, 24}},
+		{790472261, 147, 45, Date{2021, 10, 22}},
+	} {
+		f := foods[i]
+		if f.FoodID != want.foodID || f.Unit == nil || f.Unit.ID != want.unitID || f.Amount != want.amount || f.DateLastEaten != want.lastUsed {
+			t.Errorf("foods[%d] = %+v, want food %d, %v of unit %d, last eaten %s", i, f, want.foodID, want.amount, want.unitID, want.lastUsed)
+		}
+	}
+	if foods[2].AccessLevel != "PRIVATE" || foods[1].Calories != 78 {
+		t.Errorf("foods = %+v", foods)
+	}
+}
+
+func TestRecentFoodsEmpty(t *testing.T) {
+	c := newTestClient(t, &requestRecorder{Response: `[]`})
+	foods, err := c.RecentFoods(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if foods == nil || len(foods) != 0 {
+		t.Errorf("foods = %#v, want an empty slice", foods)
+	}
+}
+
+func TestLogQuickCalories(t *testing.T) {
+	rec := &requestRecorder{Response: `{"foodLog":{"logId":5,"loggedFood":{"name":"Quick Calories","calories":350,"mealTypeId":7}}}`}
+	c := newTestClient(t, rec)
+
+	entry, err := c.LogQuickCalories(t.Context(), Date{2021, 10, 25}, 0, 350)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.LogID != 5 || entry.LoggedFood.Calories != 350 {
+		t.Errorf("entry = %+v", entry)
+	}
+	req := rec.last(t)
+	form, err := url.ParseQuery(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{
+		"foodName":   {"Quick Calories"},
+		"calories":   {"350"},
+		"mealTypeId": {"7"},
+		"date":       {"2021-10-25"},
+	}
+	if req.Method != "POST" || req.URL.Path != "/1/user/-/foods/log.json" || !reflect.DeepEqual(form, want) {
+		t.Errorf("sent %s %s with %v, want POST /1/user/-/foods/log.json with %v", req.Method, req.URL.Path, form, want)
+	}
+
+	if _, err := c.LogQuickCalories(t.Context(), Date{2021, 10, 25}, Dinner, 120); err != nil {
+		t.Fatal(err)
+	}
+	if form, _ := url.ParseQuery(rec.last(t).Body); form.Get("mealTypeId") != "5" {
+		t.Errorf("mealTypeId = %q, want 5", form.Get("mealTypeId"))
+	}
+
+	for _, calories := range []int{0, -100, maxQuickCalories + 1} {
+		if _, err := c.LogQuickCalories(t.Context(), Date{2021, 10, 25}, Lunch, calories); err == nil {
+			t.Errorf("%d calories were accepted", calories)
+		}
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.reqs) != 2 {
+		t.Errorf("sent %d requests, want 2", len(rec.reqs))
+	}
+}
+
+// countingHandler answers every request with body, counting them.
+func countingHandler(hits *atomic.Int32, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}
+}
+
+func TestFoodUnitsCache(t *testing.T) {
+	var hits atomic.Int32
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/foods/units.json", countingHandler(&hits, `[{"id":226,"name":"gram","plural":"grams"},{"id":304,"name":"serving","plural":"servings"}]`))
+	c := newTestClient(t, mux)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if units, err := c.FoodUnits(t.Context()); err != nil || len(units) != 2 || units[1].Plural != "servings" {
+				t.Errorf("FoodUnits = %+v, %v", units, err)
+			}
+		})
+	}
+	wg.Wait()
+	if n := hits.Load(); n != 1 {
+		t.Errorf("fetched %d times, want once", n)
+	}
+
+	// Callers get copies of the cache.
+	units, _ := c.FoodUnits(t.Context())
+	units[0].Name = "changed"
+	if units, _ := c.FoodUnits(t.Context()); units[0].Name != "gram" || hits.Load() != 1 {
+		t.Errorf("cache changed or refetched: %+v", units)
+	}
+
+	c.InvalidateFoodUnits()
+	if _, err := c.FoodUnits(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("fetched %d times after Invalidate, want twice", n)
+	}
+}
+
+func TestFoodLocalesCache(t *testing.T) {
+	var hits atomic.Int32
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/foods/locales.json", countingHandler(&hits, `[{"value":"en_US","label":"United States","barcode":true,"imageUpload":true},{"value":"de_DE","label":"Deutschland"}]`))
+	c := newTestClient(t, mux)
+
+	for range 3 {
+		locales, err := c.FoodLocales(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(locales) != 2 || !locales[0].Barcode || locales[1].Value != "de_DE" || locales[1].ImageUpload {
+			t.Errorf("FoodLocales = %+v", locales)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("fetched %d times, want once", n)
+	}
+	c.InvalidateFoodLocales()
+	if _, err := c.FoodLocales(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("fetched %d times after Invalidate, want twice", n)
+	}
+}
+
+func TestFoodLogUpdateParams(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		u    FoodLogUpdate
+		want url.Values // nil for an error
+	}{
+		{"amount", FoodLogUpdate{UnitID: 304, Amount: 1.5}, url.Values{"unitId": {"304"}, "amount": {"1.50"}}},
+		{"meal", FoodLogUpdate{MealType: Dinner}, url.Values{"mealTypeId": {"5"}}},
+		{"calories", FoodLogUpdate{Calories: 250}, url.Values{"calories": {"250"}}},
+		{"food change", FoodLogUpdate{FoodID: 81137}, nil},
+		{"food change with others", FoodLogUpdate{FoodID: 81137, Amount: 2}, nil},
+		{"bad meal", FoodLogUpdate{MealType: 6}, nil},
+		{"negative amount", FoodLogUpdate{Amount: -1}, nil},
+		{"empty", FoodLogUpdate{}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.u.params()
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("params() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("params() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFoodGoalUpdateParams(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		u    FoodGoalUpdate
+		want url.Values // nil for an error
+	}{
+		{"calories", FoodGoalUpdate{Calories: 2200}, url.Values{"calories": {"2200"}}},
+		{"intensity", FoodGoalUpdate{Intensity: FoodPlanMedium}, url.Values{"intensity": {"MEDIUM"}, "personalized": {"false"}}},
+		{"personalized", FoodGoalUpdate{Intensity: FoodPlanEasier, Personalized: true}, url.Values{"intensity": {"EASIER"}, "personalized": {"true"}}},
+		{"both", FoodGoalUpdate{Calories: 2200, Intensity: FoodPlanMedium}, nil},
+		{"neither", FoodGoalUpdate{}, nil},
+		{"personalized calories", FoodGoalUpdate{Calories: 2200, Personalized: true}, nil},
+		{"negative calories", FoodGoalUpdate{Calories: -1}, nil},
+		{"unknown intensity", FoodGoalUpdate{Intensity: "EXTREME"}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.u.params()
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("params() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("params() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFavoriteFoods(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/foods/log/favorite.json", serveFixture(t, "favorite_foods.json"))
+	c := newTestClient(t, mux)
+
+	foods, err := c.FavoriteFoods(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(foods) != 2 {
+		t.Fatalf("got %d foods, want 2", len(foods))
+	}
+	if f := foods[0]; f.FoodID != 81137 || f.Name != "Apple" || f.AccessLevel != "PUBLIC" || f.DefaultUnit == nil || f.DefaultUnit.ID != 304 || len(f.Units) != 3 {
+		t.Errorf("foods[0] = %+v", f)
+	}
+	if f := foods[1]; f.FoodID != 90000001 || f.Brand != "Homemade" || f.AccessLevel != "PRIVATE" || f.DefaultServingSize != 45 {
+		t.Errorf("foods[1] = %+v", f)
+	}
+}
+
+func TestFavoriteFoodsEmpty(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []Food{})
+	}))
+	foods, err := c.FavoriteFoods(t.Context())
+	if err != nil || foods == nil || len(foods) != 0 {
+		t.Errorf("FavoriteFoods = %#v, %v, want an empty list", foods, err)
+	}
+}
+
+func TestFrequentFoods(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/foods/log/frequent.json", serveFixture(t, "frequent_foods.json"))
+	c := newTestClient(t, mux)
+
+	foods, err := c.FrequentFoods(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(foods) != 2 {
+		t.Fatalf("got %d foods, want 2", len(foods))
+	}
+	day := Date{2021, 10, 25}
+	want := []NewFoodLog{
+		{FoodID: 81137, UnitID: 304, Amount: 2, MealType: Breakfast, Date: day},
+		// Without a unit, the log has no UnitID, and LogFood rejects it.
+		{FoodID: 18772, Amount: 1, MealType: AfternoonSnack, Date: day},
+	}
+	for i, f := range foods {
+		if got := f.NewLog(day); got != want[i] {
+			t.Errorf("foods[%d].NewLog = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if _, err := foods[1].NewLog(day).params(); err == nil {
+		t.Error("a log without a unit was accepted")
+	}
+}
+
+func TestFoodGoals(t *testing.T) {
+	for _, tt := range []struct {
+		name, body string
+		want       *FoodGoals // nil for ErrNoGoal
+	}{
+		{"calories", `{"goals":{"calories":2200}}`, &FoodGoals{Calories: 2200}},
+		{
+			"food plan",
+			`{"foodPlan":{"estimatedDate":"2022-01-15","intensity":"MEDIUM","personalized":false},"goals":{"calories":1850}}`,
+			&FoodGoals{Calories: 1850, FoodPlan: &FoodPlan{Intensity: FoodPlanMedium, EstimatedDate: Date{2022, 1, 15}}},
+		},
+		{"none", `{"goals":{}}`, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /1/user/-/foods/log/goal.json", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tt.body)
+			})
+			c := newTestClient(t, mux)
+
+			goals, err := c.FoodGoals(t.Context())
+			if tt.want == nil {
+				if !errors.Is(err, ErrNoGoal) {
+					t.Errorf("FoodGoals = %+v, %v, want ErrNoGoal", goals, err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(goals, *tt.want) {
+				t.Errorf("FoodGoals = %+v, %v, want %+v", goals, err, *tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package fitbit
+
+import (
+	"golang.org/x/net/context"
+)
+
+// maxFoodSeriesRangeDays is the longest span Fitbit allows for a single
+// food time series range request.
+const maxFoodSeriesRangeDays = 1095
+
+const caloriesInResource = "foods/log/caloriesIn"
+
+// CaloriesInPoint is the calories logged on one day.
+type CaloriesInPoint struct {
+	DateTime Date
+	Calories int
+}
+
+func caloriesInSeries(points []rawSeriesPoint) ([]CaloriesInPoint, error) {
+	series := make([]CaloriesInPoint, len(points))
+	for i, p := range points {
+		v, err := p.int()
+		if err != nil {
+			return nil, err
+		}
+		series[i] = CaloriesInPoint{DateTime: p.DateTime, Calories: v}
+	}
+	return series, nil
+}
+
+// CaloriesInTimeSeries returns the calories logged each day of period
+// ending on date.
+func (c *Client) CaloriesInTimeSeries(ctx context.Context, date Date, period Period) ([]CaloriesInPoint, error) {
+	points, err := c.timeSeries(ctx, caloriesInResource, date, period)
+	if err != nil {
+		return nil, err
+	}
+	return caloriesInSeries(points)
+}
+
+// CaloriesInTimeSeriesRange returns the calories logged each day between
+// start and end, inclusive, fetching spans over Fitbit's 1095 day cap in
+// chunks.
+func (c *Client) CaloriesInTimeSeriesRange(ctx context.Context, start, end Date) ([]CaloriesInPoint, error) {
+	points, err := c.timeSeriesRange(ctx, caloriesInResource, start, end, maxFoodSeriesRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	return caloriesInSeries(points)
+}
+
+const waterResource = "foods/log/water"
+
+// WaterSeriesPoint is the water logged on one day.
+type WaterSeriesPoint struct {
+	DateTime Date
+	Amount   float64
+}
+
+// WaterSeries is a water consumption time series. Amounts are in fl oz
+// for the en_US unit system and ml otherwise.
+type WaterSeries struct {
+	Units  UnitSystem
+	Points []WaterSeriesPoint
+}
+
+func (c *Client) waterSeries(points []rawSeriesPoint) (WaterSeries, error) {
+	series := WaterSeries{
+		Units:  c.unitSystem(),
+		Points: make([]WaterSeriesPoint, len(points)),
+	}
+	for i, p := range points {
+		v, err := p.float()
+		if err != nil {
+			return WaterSeries{}, err
+		}
+		series.Points[i] = WaterSeriesPoint{DateTime: p.DateTime, Amount: v}
+	}
+	return series, nil
+}
+
+// WaterTimeSeries returns the water logged each day of period ending on
+// date.
+func (c *Client) WaterTimeSeries(ctx context.Context, date Date, period Period) (WaterSeries, error) {
+	points, err := c.timeSeries(ctx, waterResource, date, period)
+	if err != nil {
+		return WaterSeries{}, err
+	}
+	return c.waterSeries(points)
+}
+
+// WaterTimeSeriesRange returns the water logged each day between start
+// and end, inclusive, fetching spans over Fitbit's 1095 day cap in
+// chunks.
+func (c *Client) WaterTimeSeriesRange(ctx context.Context, start, end Date) (WaterSeries, error) {
+	points, err := c.timeSeriesRange(ctx, waterResource, start, end, maxFoodSeriesRangeDays)
+	if err != nil {
+		return WaterSeries{}, err
+	}
+	return c.waterSeries(points)
+}
@@ -0,0 +1,76 @@
+package fitbit
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCaloriesInTimeSeries(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/foods/log/caloriesIn/date/2021-10-21/7d.json", serveFixture(t, "calories_in_series.json"))
+	c := newTestClient(t, mux)
+
+	series, err := c.CaloriesInTimeSeries(t.Context(), Date{2021, 10, 21}, Period7Days)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CaloriesInPoint{
+		{Date{2021, 10, 15}, 1902},
+		{Date{2021, 10, 16}, 2310},
+		{Date{2021, 10, 17}, 1655},
+		{Date{2021, 10, 18}, 1998},
+		{Date{2021, 10, 19}, 2068},
+		{Date{2021, 10, 20}, 0},
+		{Date{2021, 10, 21}, 1764},
+	}
+	if len(series) != len(want) {
+		t.Fatalf("got %d points, want %d", len(series), len(want))
+	}
+	for i := range want {
+		if series[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, series[i], want[i])
+		}
+	}
+}
+
+func TestCaloriesInTimeSeriesRange(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/foods/log/caloriesIn/date/2021-10-15/2021-10-21.json", serveFixture(t, "calories_in_series.json"))
+	c := newTestClient(t, mux)
+
+	series, err := c.CaloriesInTimeSeriesRange(t.Context(), Date{2021, 10, 15}, Date{2021, 10, 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 7 || series[6].Calories != 1764 {
+		t.Errorf("series = %+v", series)
+	}
+}
+
+func TestWaterTimeSeriesRange(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/foods/log/water/date/2021-10-19/2021-10-21.json", serveFixture(t, "water_series.json"))
+	c := newTestClient(t, mux)
+	c.UnitSystem = UnitSystemMetric
+
+	series, err := c.WaterTimeSeriesRange(t.Context(), Date{2021, 10, 19}, Date{2021, 10, 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series.Units != UnitSystemMetric {
+		t.Errorf("Units = %q, want METRIC", series.Units)
+	}
+	want := []WaterSeriesPoint{
+		{Date{2021, 10, 19}, 1000},
+		{Date{2021, 10, 20}, 0},
+		{Date{2021, 10, 21}, 2365.9},
+	}
+	if len(series.Points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(series.Points), len(want))
+	}
+	for i := range want {
+		if series.Points[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, series.Points[i], want[i])
+		}
+	}
+}
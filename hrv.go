@@ -0,0 +1,132 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// HRV is the heart rate variability summary for one night.
+type HRV struct {
+	DateTime Date     `json:"dateTime"`
+	Value    HRVValue `json:"value"`
+}
+
+type HRVValue struct {
+	DailyRmssd float64 `json:"dailyRmssd"`
+	DeepRmssd  float64 `json:"deepRmssd"`
+}
+
+type hrvResponse struct {
+	HRV []HRV `json:"hrv"`
+}
+
+// HRVByDate returns the HRV summary for date. It returns nil (and no
+// error) when there is no reading for that night.
+func (c *Client) HRVByDate(ctx context.Context, date Date) (*HRV, error) {
+	var resp hrvResponse
+	err := c.get(ctx, fmt.Sprintf("/user/-/hrv/date/%s.json", date), &resp)
+	if err != nil || len(resp.HRV) == 0 {
+		return nil, err
+	}
+	return &resp.HRV[0], nil
+}
+
+// HRVRange returns the HRV summaries between start and end, inclusive,
+// sorted by date. Ranges longer than Fitbit's 30 day cap are fetched in
+// chunks. Nights without a reading are absent from the result.
+func (c *Client) HRVRange(ctx context.Context, start, end Date) ([]HRV, error) {
+	var entries []HRV
+	err := c.getWellnessRange(ctx, "hrv", start, end, func(urlStr string) error {
+		var resp hrvResponse
+		if err := c.get(ctx, urlStr, &resp); err != nil {
+			return err
+		}
+		entries = append(entries, resp.HRV...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DateTime.Before(entries[j].DateTime)
+	})
+	return entries, nil
+}
+
+// HRVMap indexes entries by date.
+func HRVMap(entries []HRV) map[Date]HRV {
+	m := make(map[Date]HRV, len(entries))
+	for _, e := range entries {
+		m[e.DateTime] = e
+	}
+	return m
+}
+
+// IntradayHRV holds the per-minute HRV readings Fitbit recorded during
+// the main sleep of one date.
+type IntradayHRV struct {
+	DateTime Date        `json:"dateTime"`
+	Minutes  []HRVMinute `json:"minutes"`
+}
+
+// HRVMinute is a single 5 minute HRV measurement. Minute is the time
+// the measurement started, in the user's timezone (see
+// Client.Location).
+type HRVMinute struct {
+	Minute time.Time
+	Value  HRVMinuteValue
+}
+
+type HRVMinuteValue struct {
+	Rmssd    float64 `json:"rmssd"`
+	Coverage float64 `json:"coverage"`
+	HF       float64 `json:"hf"`
+	LF       float64 `json:"lf"`
+}
+
+func (m *HRVMinute) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Minute string         `json:"minute"`
+		Value  HRVMinuteValue `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	minute, err := parseLocalDateTime(raw.Minute, time.UTC)
+	if err != nil {
+		return err
+	}
+	m.Minute, m.Value = minute, raw.Value
+	return nil
+}
+
+// IntradayHRV returns the per-minute HRV readings for date, grouped by
+// the date Fitbit attributes them to. Nights without readings return an
+// empty slice.
+func (c *Client) IntradayHRV(ctx context.Context, date Date) ([]IntradayHRV, error) {
+	var resp struct {
+		HRV []IntradayHRV `json:"hrv"`
+	}
+	err := c.get(ctx, fmt.Sprintf("/user/-/hrv/date/%s/all.json", date), &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.HRV == nil {
+		return []IntradayHRV{}, nil
+	}
+
+	var times []*time.Time
+	for i := range resp.HRV {
+		for j := range resp.HRV[i].Minutes {
+			times = append(times, &resp.HRV[i].Minutes[j].Minute)
+		}
+	}
+	c.inLocation(ctx, times...)
+	return resp.HRV, nil
+}
@@ -0,0 +1,69 @@
+package fitbit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIntradayHRV(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/hrv/date/2021-10-26/all.json", serveFixture(t, "hrv_intraday.json"))
+	c := newTestClient(t, mux)
+	ny, _ := time.LoadLocation("America/New_York")
+	c.Location = ny
+
+	days, err := c.IntradayHRV(t.Context(), Date{2021, 10, 26})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 || len(days[0].Minutes) != 3 || days[0].DateTime != (Date{2021, 10, 26}) {
+		t.Fatalf("days = %+v", days)
+	}
+	// Readings before midnight are attributed to the night's date.
+	m := days[0].Minutes[0]
+	if want := time.Date(2021, 10, 25, 23, 55, 0, 0, ny); !m.Minute.Equal(want) || m.Minute.Location() != ny {
+		t.Errorf("Minute = %v, want %v", m.Minute, want)
+	}
+	if m.Value != (HRVMinuteValue{Rmssd: 26.617, Coverage: 0.935, HF: 126.877, LF: 517.795}) {
+		t.Errorf("Value = %+v", m.Value)
+	}
+}
+
+func TestIntradayHRVEmpty(t *testing.T) {
+	for _, body := range []string{`{"hrv":[]}`, `{}`} {
+		c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		days, err := c.IntradayHRV(t.Context(), Date{2021, 10, 26})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if days == nil || len(days) != 0 {
+			t.Errorf("%s: days = %#v, want an empty slice", body, days)
+		}
+	}
+}
+
+func TestHRVRange(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/hrv/date/2021-10-25/2021-10-26.json", serveFixture(t, "hrv_range.json"))
+	c := newTestClient(t, mux)
+
+	entries, err := c.HRVRange(t.Context(), Date{2021, 10, 25}, Date{2021, 10, 26})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []HRV{
+		{Date{2021, 10, 25}, HRVValue{DailyRmssd: 34.938, DeepRmssd: 31.567}},
+		{Date{2021, 10, 26}, HRVValue{DailyRmssd: 29.211, DeepRmssd: 27.913}},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("entries = %+v, want %+v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entries[%d] = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// maxIRNLimit is the largest page size the IRN alerts endpoint accepts.
+const maxIRNLimit = 10
+
+// IrregularRhythmAlert is an irregular rhythm notification along with
+// the heart rhythm evidence that triggered it. Times are in the user's
+// timezone (see Client.Location); AlertTime is zero if Fitbit sends
+// none.
+type IrregularRhythmAlert struct {
+	AlertTime       time.Time
+	DetectedTime    time.Time
+	ServiceVersion  string
+	DeviceName      string
+	DeviceFwVersion string
+	Tachogram       []IRNSample
+	HeartRate       []IRNSample
+}
+
+// IRNSample is a single point of alert evidence: an interbeat interval
+// for the tachogram, or a heart rate.
+type IRNSample struct {
+	Time  time.Time
+	Value float64
+}
+
+func (s *IRNSample) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Time  string  `json:"time"`
+		Value float64 `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	t, err := parseLocalDateTime(raw.Time, time.UTC)
+	if err != nil {
+		return err
+	}
+	s.Time, s.Value = t, raw.Value
+	return nil
+}
+
+func (a *IrregularRhythmAlert) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		AlertTime       string `json:"alertTime"`
+		DetectedTime    string `json:"detectedTime"`
+		ServiceVersion  string `json:"serviceVersion"`
+		DeviceName      string `json:"deviceName"`
+		DeviceFwVersion string `json:"deviceFwVersion"`
+		Tachogram       struct {
+			Data []IRNSample `json:"data"`
+		} `json:"tachogram"`
+		HeartRate struct {
+			Data []IRNSample `json:"data"`
+		} `json:"heartRate"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	alert := IrregularRhythmAlert{
+		ServiceVersion:  raw.ServiceVersion,
+		DeviceName:      raw.DeviceName,
+		DeviceFwVersion: raw.DeviceFwVersion,
+		Tachogram:       raw.Tachogram.Data,
+		HeartRate:       raw.HeartRate.Data,
+	}
+	var err error
+	if raw.AlertTime != "" {
+		if alert.AlertTime, err = parseLocalDateTime(raw.AlertTime, time.UTC); err != nil {
+			return err
+		}
+	}
+	if alert.DetectedTime, err = parseLocalDateTime(raw.DetectedTime, time.UTC); err != nil {
+		return err
+	}
+	*a = alert
+	return nil
+}
+
+// IRNAlertPage is one page of irregular rhythm alerts.
+type IRNAlertPage struct {
+	Alerts     []IrregularRhythmAlert `json:"alerts"`
+	Pagination Pagination             `json:"pagination"`
+
+	client *Client
+}
+
+// IrregularRhythmAlerts returns the first page of the user's irregular
+// rhythm alerts matching opts; Limit may be at most 10. Accounts without
+// the feature enabled get an empty page rather than an error.
+func (c *Client) IrregularRhythmAlerts(ctx context.Context, opts ListOptions) (*IRNAlertPage, error) {
+	q, err := opts.values(maxIRNLimit)
+	if err != nil {
+		return nil, err
+	}
+	return c.irnAlertPage(ctx, "", "/user/-/irn/alerts/list.json?"+q.Encode())
+}
+
+func (c *Client) irnAlertPage(ctx context.Context, version, urlStr string) (*IRNAlertPage, error) {
+	page := &IRNAlertPage{client: c}
+	if err := c.getVersion(ctx, version, urlStr, page); err != nil {
+		return nil, err
+	}
+	if page.Alerts == nil {
+		page.Alerts = []IrregularRhythmAlert{}
+	}
+
+	var times []*time.Time
+	for i := range page.Alerts {
+		a := &page.Alerts[i]
+		times = append(times, &a.AlertTime, &a.DetectedTime)
+		for j := range a.Tachogram {
+			times = append(times, &a.Tachogram[j].Time)
+		}
+		for j := range a.HeartRate {
+			times = append(times, &a.HeartRate[j].Time)
+		}
+	}
+	c.inLocation(ctx, times...)
+	return page, nil
+}
+
+// HasNext reports whether there is a page after p.
+func (p *IRNAlertPage) HasNext() bool {
+	return p.Pagination.HasNext()
+}
+
+// Next fetches the page after p, or returns ErrNoMorePages.
+func (p *IRNAlertPage) Next(ctx context.Context) (*IRNAlertPage, error) {
+	if !p.HasNext() {
+		return nil, ErrNoMorePages
+	}
+	version, urlStr, err := p.client.pageURL(p.Pagination.Next)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.irnAlertPage(ctx, version, urlStr)
+}
+
+// NextPage implements Pager.
+func (p *IRNAlertPage) NextPage(ctx context.Context) (Pager, error) {
+	next, err := p.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
@@ -0,0 +1,55 @@
+package fitbit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIrregularRhythmAlerts(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/irn/alerts/list.json", serveFixture(t, "irn_alerts.json"))
+	c := newTestClient(t, mux)
+	chicago, _ := time.LoadLocation("America/Chicago")
+	c.Location = chicago
+
+	page, err := c.IrregularRhythmAlerts(t.Context(), ListOptions{BeforeDate: Date{2022, 9, 29}, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(page.Alerts))
+	}
+	a := page.Alerts[0]
+	for _, tt := range []struct {
+		name string
+		got  time.Time
+		want time.Time
+	}{
+		{"AlertTime", a.AlertTime, time.Date(2022, 9, 28, 23, 45, 0, 0, chicago)},
+		{"DetectedTime", a.DetectedTime, time.Date(2022, 9, 28, 23, 30, 0, 0, chicago)},
+		{"Tachogram[0]", a.Tachogram[0].Time, time.Date(2022, 9, 28, 23, 59, 59, 0, chicago)},
+		{"Tachogram[1]", a.Tachogram[1].Time, time.Date(2022, 9, 29, 0, 0, 0, 850e6, chicago)},
+		{"HeartRate[0]", a.HeartRate[0].Time, time.Date(2022, 9, 28, 23, 30, 0, 0, chicago)},
+	} {
+		if !tt.got.Equal(tt.want) || tt.got.Location() != chicago {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if a.Tachogram[1].Value != 1020 || a.HeartRate[0].Value != 72 || a.DeviceName != "Sense" {
+		t.Errorf("alert = %+v", a)
+	}
+}
+
+func TestIrregularRhythmAlertsEmpty(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"alerts":[],"pagination":{"next":""}}`))
+	}))
+	page, err := c.IrregularRhythmAlerts(t.Context(), ListOptions{AfterDate: Date{2022, 9, 29}, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Alerts == nil || len(page.Alerts) != 0 || page.HasNext() {
+		t.Errorf("page = %+v, want an empty last page", page)
+	}
+}
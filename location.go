@@ -0,0 +1,64 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// location returns c.Location, or else the profile's timezone, which is
+// fetched once and cached. Failed fetches aren't cached.
+func (c *Client) location(ctx context.Context) (*time.Location, error) {
+	if c.Location != nil {
+		return c.Location, nil
+	}
+
+	c.locMu.Lock()
+	loc := c.profileLoc
+	c.locMu.Unlock()
+	if loc != nil {
+		return loc, nil
+	}
+
+	// The key can't clash with those of coalesced GETs, which start with
+	// the method. The call caches the location before it ends, so calls
+	// made after it find that.
+	_, err := c.flight.do(ctx, "profile location", func() (json.RawMessage, error) {
+		var profile UserProfile
+		if err := c.get(ctx, "/user/-/profile.json", &profile); err != nil {
+			return nil, err
+		}
+		loc, err := time.LoadLocation(profile.User.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		c.locMu.Lock()
+		c.profileLoc = loc
+		c.locMu.Unlock()
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.locMu.Lock()
+	defer c.locMu.Unlock()
+	return c.profileLoc, nil
+}
+
+// inLocation moves times parsed as UTC to the same wall clock in the
+// user's timezone, if it can be had.
+func (c *Client) inLocation(ctx context.Context, times ...*time.Time) {
+	if len(times) == 0 {
+		return
+	}
+	loc, err := c.location(ctx)
+	if err != nil || loc == time.UTC {
+		return
+	}
+	for _, t := range times {
+		if !t.IsZero() {
+			*t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package fitbit
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const profileForbidden = `{"errors":[{"errorType":"insufficient_scope","message":"This application does not have permission to access profile data."}],"success":false}`
+
+// profileHandler serves the profile, as the given status and body, and
+// the sleep fixture, counting the profile requests.
+func profileHandler(t *testing.T, hits *atomic.Int32, status int, body string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/profile.json", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	})
+	mux.Handle("GET /1.2/user/-/sleep/date/{date}", serveFixture(t, "sleep_date.json"))
+	return mux
+}
+
+func TestLocationFromProfile(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, profileHandler(t, &hits, http.StatusOK, `{"user":{"timezone":"America/Los_Angeles"}}`))
+
+	day, err := c.SleepByDate(t.Context(), Date{2020, 2, 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	la, _ := time.LoadLocation("America/Los_Angeles")
+	want := time.Date(2020, 2, 20, 23, 21, 30, 0, la)
+	if got := day.Sleep[0].Start; !got.Equal(want) || got.Location().String() != la.String() {
+		t.Errorf("Start = %v, want %v", got, want)
+	}
+
+	// The timezone is cached.
+	if _, err := c.SleepByDate(t.Context(), Date{2020, 2, 21}); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("profile fetched %d times, want 1", n)
+	}
+}
+
+func TestLocationSharesFetch(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/profile.json", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		io.WriteString(w, `{"user":{"timezone":"Europe/London"}}`)
+	})
+	c := newTestClient(t, mux)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if loc, err := c.location(t.Context()); err != nil || loc.String() != "Europe/London" {
+				t.Errorf("location = %v, %v", loc, err)
+			}
+		}()
+	}
+	// While the fetch is in flight, c.locMu is free.
+	time.Sleep(20 * time.Millisecond)
+	c.locMu.Lock()
+	c.locMu.Unlock()
+	close(release)
+	wg.Wait()
+
+	// Calls may start after the first fetch is done, but they then find
+	// the cached timezone.
+	if n := hits.Load(); n != 1 {
+		t.Errorf("profile fetched %d times, want 1", n)
+	}
+}
+
+func TestLocationWithoutProfileScope(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, profileHandler(t, &hits, http.StatusForbidden, profileForbidden))
+
+	day, err := c.SleepByDate(t.Context(), Date{2020, 2, 21})
+	if err != nil {
+		t.Fatalf("SleepByDate failed for want of the profile: %v", err)
+	}
+	if len(day.Sleep) != 1 || day.Sleep[0].LogID != 26013218219 {
+		t.Fatalf("sleep = %+v, want the fixture's log", day.Sleep)
+	}
+	if l := day.Sleep[0]; !l.Start.IsZero() || !l.End.IsZero() || l.StartTime != "2020-02-20T23:21:30.000" {
+		t.Errorf("Start, End = %v, %v, want zero with the raw StartTime kept", l.Start, l.End)
+	}
+
+	// A failed fetch isn't cached.
+	if _, err := c.SleepByDate(t.Context(), Date{2020, 2, 21}); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("profile fetched %d times, want 2", n)
+	}
+}
+
+func TestLocationExplicit(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, profileHandler(t, &hits, http.StatusForbidden, profileForbidden))
+	c.Location = time.UTC
+
+	day, err := c.SleepByDate(t.Context(), Date{2020, 2, 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2020, 2, 21, 7, 3, 30, 0, time.UTC); !day.Sleep[0].End.Equal(want) {
+		t.Errorf("End = %v, want %v", day.Sleep[0].End, want)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("profile fetched %d times, want 0", n)
+	}
+}
+
+func TestLogSleepWithoutProfileScope(t *testing.T) {
+	var hits atomic.Int32
+	mux := http.NewServeMux()
+	mux.Handle("/1/user/-/profile.json", profileHandler(t, &hits, http.StatusForbidden, profileForbidden))
+	mux.HandleFunc("POST /1.2/user/-/sleep.json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"sleep":{"logId":26589710670,"dateOfSleep":"2020-02-21","startTime":"2020-02-20T23:30:00.000","endTime":"2020-02-21T07:00:00.000","duration":27000000,"isMainSleep":false,"logType":"manual","type":"classic","levels":{"data":[],"summary":{}}}}`)
+	})
+	c := newTestClient(t, mux)
+
+	l, err := c.LogSleep(t.Context(), NewSleepLog{Date: Date{2020, 2, 20}, StartTime: "23:30", Duration: 450 * time.Minute})
+	if err != nil {
+		t.Fatalf("LogSleep turned a created log into an error: %v", err)
+	}
+	if l.LogID != 26589710670 || !l.Start.IsZero() {
+		t.Errorf("log = %+v, want the created one without Start", l)
+	}
+}
@@ -0,0 +1,55 @@
+package fitbit
+
+// Calories per gram of each macronutrient.
+const (
+	caloriesPerGramCarbs   = 4
+	caloriesPerGramProtein = 4
+	caloriesPerGramFat     = 9
+)
+
+// MacroBreakdown splits a day's food log into the calories contributed
+// by each macronutrient.
+type MacroBreakdown struct {
+	CarbsCalories   float64
+	ProteinCalories float64
+	FatCalories     float64
+
+	// The percentages are of the three contributions' total, so they sum
+	// to 100 (or are all 0 on a day with nothing logged). That total can
+	// differ slightly from the logged calories, which Fitbit computes
+	// from each food's own label.
+	CarbsPercent   float64
+	ProteinPercent float64
+	FatPercent     float64
+
+	// LoggedCalories is the day's logged calories and GoalCalories its
+	// calorie goal, 0 when there is none. RemainingCalories is the goal
+	// minus the logged calories, negative once the goal is exceeded, and
+	// nil when there is no goal.
+	LoggedCalories    float64
+	GoalCalories      int
+	RemainingCalories *float64
+}
+
+// Macros returns the macronutrient breakdown of the log, using 4 calories
+// per gram of carbohydrate and protein and 9 per gram of fat.
+func (l FoodLog) Macros() MacroBreakdown {
+	s := l.Summary
+	m := MacroBreakdown{
+		CarbsCalories:   s.Carbs * caloriesPerGramCarbs,
+		ProteinCalories: s.Protein * caloriesPerGramProtein,
+		FatCalories:     s.Fat * caloriesPerGramFat,
+		LoggedCalories:  s.Calories,
+	}
+	if total := m.CarbsCalories + m.ProteinCalories + m.FatCalories; total > 0 {
+		m.CarbsPercent = 100 * m.CarbsCalories / total
+		m.ProteinPercent = 100 * m.ProteinCalories / total
+		m.FatPercent = 100 * m.FatCalories / total
+	}
+	if l.Goals != nil {
+		m.GoalCalories = l.Goals.Calories
+		remaining := float64(l.Goals.Calories) - s.Calories
+		m.RemainingCalories = &remaining
+	}
+	return m
+}
@@ -0,0 +1,66 @@
+package fitbit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMacros(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		log  FoodLog
+		want MacroBreakdown
+	}{
+		{"nothing logged", FoodLog{}, MacroBreakdown{}},
+		{"zero calories", FoodLog{Summary: FoodLogSummary{Fiber: 3, Sodium: 20}}, MacroBreakdown{}},
+		{
+			"entries without nutritional values",
+			FoodLog{
+				Foods:   []FoodLogEntry{{LogID: 1}, {LogID: 2, NutritionalValues: &NutritionalValues{Calories: 180, Carbs: 20, Protein: 10, Fat: 5}}},
+				Summary: FoodLogSummary{Calories: 180, Carbs: 20, Protein: 10, Fat: 5},
+			},
+			MacroBreakdown{
+				CarbsCalories: 80, ProteinCalories: 40, FatCalories: 45,
+				CarbsPercent: 100 * 80.0 / 165, ProteinPercent: 100 * 40.0 / 165, FatPercent: 100 * 45.0 / 165,
+				LoggedCalories: 180,
+			},
+		},
+		{
+			// Thirds aren't rounded, so the percentages still sum to 100.
+			"thirds",
+			FoodLog{Summary: FoodLogSummary{Calories: 204, Carbs: 12, Protein: 12, Fat: 48.0 / 9}},
+			MacroBreakdown{
+				CarbsCalories: 48, ProteinCalories: 48, FatCalories: 48,
+				CarbsPercent: 100.0 / 3, ProteinPercent: 100.0 / 3, FatPercent: 100.0 / 3,
+				LoggedCalories: 204,
+			},
+		},
+		{
+			"over goal",
+			FoodLog{Summary: FoodLogSummary{Calories: 2150.5, Carbs: 250, Protein: 100, Fat: 80}, Goals: &FoodLogGoals{Calories: 2000}},
+			MacroBreakdown{
+				CarbsCalories: 1000, ProteinCalories: 400, FatCalories: 720,
+				CarbsPercent: 100 * 1000.0 / 2120, ProteinPercent: 100 * 400.0 / 2120, FatPercent: 100 * 720.0 / 2120,
+				LoggedCalories: 2150.5, GoalCalories: 2000, RemainingCalories: Float64(-150.5),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.log.Macros()
+			got := []float64{m.CarbsCalories, m.ProteinCalories, m.FatCalories, m.CarbsPercent, m.ProteinPercent, m.FatPercent, m.LoggedCalories}
+			want := []float64{tt.want.CarbsCalories, tt.want.ProteinCalories, tt.want.FatCalories, tt.want.CarbsPercent, tt.want.ProteinPercent, tt.want.FatPercent, tt.want.LoggedCalories}
+			for i := range got {
+				if math.IsNaN(got[i]) || math.Abs(got[i]-want[i]) > 1e-9 {
+					t.Fatalf("Macros() = %+v, want %+v", m, tt.want)
+				}
+			}
+			if sum := m.CarbsPercent + m.ProteinPercent + m.FatPercent; m.CarbsCalories+m.ProteinCalories+m.FatCalories > 0 && math.Abs(sum-100) > 1e-9 {
+				t.Errorf("percentages sum to %v, want 100", sum)
+			}
+			if m.GoalCalories != tt.want.GoalCalories || (m.RemainingCalories == nil) != (tt.want.RemainingCalories == nil) ||
+				m.RemainingCalories != nil && *m.RemainingCalories != *tt.want.RemainingCalories {
+				t.Errorf("goal %d, remaining %v, want %d, %v", m.GoalCalories, m.RemainingCalories, tt.want.GoalCalories, tt.want.RemainingCalories)
+			}
+		})
+	}
+}
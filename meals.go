@@ -0,0 +1,120 @@
+package fitbit
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// Meal is a user defined group of foods that are logged together.
+type Meal struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	MealFoods   []MealFood `json:"mealFoods"`
+}
+
+// MealFood is one of the foods of a meal, with the amount and unit it is
+// part of the meal in.
+type MealFood struct {
+	LoggedFood
+	// NutritionalValues is nil for foods Fitbit has no nutrition data
+	// for.
+	NutritionalValues *NutritionalValues `json:"nutritionalValues"`
+}
+
+// Meals returns the user's meals.
+func (c *Client) Meals(ctx context.Context) ([]Meal, error) {
+	var resp struct {
+		Meals []Meal `json:"meals"`
+	}
+	if err := c.get(ctx, "/user/-/meals.json", &resp); err != nil {
+		return nil, err
+	}
+	if resp.Meals == nil {
+		resp.Meals = []Meal{}
+	}
+	return resp.Meals, nil
+}
+
+// NewMeal is a meal to create with CreateMeal. It needs a Name and at
+// least one food.
+type NewMeal struct {
+	Name        string
+	Description string
+	Foods       []MealFoodInput
+}
+
+// MealFoodInput is a food to include in a meal.
+type MealFoodInput struct {
+	FoodID int64   `json:"foodId"`
+	UnitID int     `json:"unitId"`
+	Amount float64 `json:"amount"`
+}
+
+// mealRequest is the JSON body the meal endpoints take.
+type mealRequest struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	MealFoods   []MealFoodInput `json:"mealFoods"`
+}
+
+func newMealRequest(name, description string, foods []MealFoodInput) (mealRequest, error) {
+	if name == "" {
+		return mealRequest{}, errors.New("fitbit: meal needs a Name")
+	}
+	if len(foods) == 0 {
+		return mealRequest{}, errors.New("fitbit: meal needs at least one food")
+	}
+	for i, f := range foods {
+		if f.FoodID == 0 || f.UnitID == 0 || f.Amount <= 0 {
+			return mealRequest{}, fmt.Errorf("fitbit: meal food %d needs a FoodID, a UnitID and a positive Amount", i)
+		}
+	}
+	return mealRequest{Name: name, Description: description, MealFoods: foods}, nil
+}
+
+// CreateMeal creates a meal and returns it with the id Fitbit assigned.
+func (c *Client) CreateMeal(ctx context.Context, m NewMeal) (Meal, error) {
+	var resp struct {
+		Meal Meal `json:"meal"`
+	}
+	body, err := newMealRequest(m.Name, m.Description, m.Foods)
+	if err != nil {
+		return resp.Meal, err
+	}
+
+	err = c.postJSON(ctx, "", "/user/-/meals.json", body, &resp)
+	return resp.Meal, err
+}
+
+// MealUpdate replaces a meal's name, description and foods. Fitbit
+// doesn't support partial updates: Foods is required and replaces the
+// meal's food list wholesale.
+type MealUpdate struct {
+	Name        string
+	Description string
+	Foods       []MealFoodInput
+}
+
+// UpdateMeal replaces the meal with the given id. It returns an error
+// matching ErrNotFound if the user has no such meal.
+func (c *Client) UpdateMeal(ctx context.Context, mealID int64, u MealUpdate) (Meal, error) {
+	var resp struct {
+		Meal Meal `json:"meal"`
+	}
+	body, err := newMealRequest(u.Name, u.Description, u.Foods)
+	if err != nil {
+		return resp.Meal, err
+	}
+
+	err = c.postJSON(ctx, "", fmt.Sprintf("/user/-/meals/%d.json", mealID), body, &resp)
+	return resp.Meal, err
+}
+
+// DeleteMeal deletes the meal with the given id. It returns an error
+// matching ErrNotFound if the user has no such meal.
+func (c *Client) DeleteMeal(ctx context.Context, mealID int64) error {
+	return c.delete(ctx, "", fmt.Sprintf("/user/-/meals/%d.json", mealID))
+}
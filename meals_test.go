@@ -0,0 +1,182 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// fakeMeals fakes Fitbit's meal endpoints, which take JSON bodies.
+type fakeMeals struct {
+	meals fakeStore[int64, Meal]
+}
+
+// meal decodes a create or update request into a meal with the given id.
+func (f *fakeMeals) meal(w http.ResponseWriter, r *http.Request, id int64) (Meal, bool) {
+	if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "validation", "n/a", "unexpected Content-Type "+ct)
+		return Meal{}, false
+	}
+	var req mealRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" || len(req.MealFoods) == 0 {
+		writeError(w, http.StatusBadRequest, "validation", "mealFoods", "A meal needs a name and foods")
+		return Meal{}, false
+	}
+	m := Meal{ID: id, Name: req.Name, Description: req.Description, MealFoods: []MealFood{}}
+	for _, in := range req.MealFoods {
+		m.MealFoods = append(m.MealFoods, MealFood{LoggedFood: LoggedFood{
+			FoodID: in.FoodID,
+			Amount: in.Amount,
+			Unit:   &FoodUnit{ID: in.UnitID},
+		}})
+	}
+	return m, true
+}
+
+func (f *fakeMeals) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/meals.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string][]Meal{"meals": f.meals.list(nil)})
+	})
+	mux.HandleFunc("POST /1/user/-/meals.json", func(w http.ResponseWriter, r *http.Request) {
+		m, ok := f.meal(w, r, 0)
+		if !ok {
+			return
+		}
+		m.ID = f.meals.newID()
+		f.meals.put(m.ID, m)
+		writeJSON(w, http.StatusCreated, map[string]Meal{"meal": m})
+	})
+	mux.HandleFunc("POST /1/user/-/meals/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := pathID(r)
+		if _, ok := f.meals.get(id); !ok {
+			writeError(w, http.StatusNotFound, "not_found", "mealId", "Meal not found")
+			return
+		}
+		m, ok := f.meal(w, r, id)
+		if !ok {
+			return
+		}
+		f.meals.put(id, m)
+		writeJSON(w, http.StatusOK, map[string]Meal{"meal": m})
+	})
+	mux.HandleFunc("DELETE /1/user/-/meals/{id}", serveDelete(&f.meals, "mealId", "Meal not found"))
+	return mux
+}
+
+// mealFoodIDs returns the ids of m's foods, in order.
+func mealFoodIDs(m Meal) []int64 {
+	ids := []int64{}
+	for _, f := range m.MealFoods {
+		ids = append(ids, f.FoodID)
+	}
+	return ids
+}
+
+func TestMealLifecycle(t *testing.T) {
+	f := &fakeMeals{}
+	c := newTestClient(t, f.handler())
+
+	created, err := c.CreateMeal(t.Context(), NewMeal{
+		Name:        "Breakfast",
+		Description: "Weekdays",
+		Foods: []MealFoodInput{
+			{FoodID: 81137, UnitID: 304, Amount: 1},
+			{FoodID: 82783, UnitID: 91, Amount: 0.5},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 || created.Name != "Breakfast" || created.Description != "Weekdays" || len(created.MealFoods) != 2 {
+		t.Errorf("created = %+v", created)
+	}
+
+	// An update replaces the food list wholesale.
+	updated, err := c.UpdateMeal(t.Context(), created.ID, MealUpdate{
+		Name:  "Big breakfast",
+		Foods: []MealFoodInput{{FoodID: 90001, UnitID: 147, Amount: 45}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != created.ID || updated.Name != "Big breakfast" || updated.Description != "" {
+		t.Errorf("updated = %+v", updated)
+	}
+	meals, err := c.Meals(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meals) != 1 {
+		t.Fatalf("got %d meals, want 1", len(meals))
+	}
+	if ids := mealFoodIDs(meals[0]); len(ids) != 1 || ids[0] != 90001 {
+		t.Errorf("foods after update = %v, want [90001]", ids)
+	}
+	if mf := meals[0].MealFoods[0]; mf.Amount != 45 || mf.Unit == nil || mf.Unit.ID != 147 {
+		t.Errorf("food after update = %+v", mf)
+	}
+
+	if err := c.DeleteMeal(t.Context(), created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if meals, err := c.Meals(t.Context()); err != nil || len(meals) != 0 {
+		t.Errorf("Meals after delete = %v, %v, want none", meals, err)
+	}
+	if err := c.DeleteMeal(t.Context(), created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting it again = %v, want ErrNotFound", err)
+	}
+	if _, err := c.UpdateMeal(t.Context(), created.ID, MealUpdate{Name: "x", Foods: []MealFoodInput{{FoodID: 1, UnitID: 1, Amount: 1}}}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("updating a deleted meal = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMeals(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/meals.json", serveFixture(t, "meals.json"))
+	c := newTestClient(t, mux)
+
+	meals, err := c.Meals(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meals) != 2 {
+		t.Fatalf("got %d meals, want 2", len(meals))
+	}
+	breakfast := meals[0]
+	if breakfast.ID != 2395887 || breakfast.Name != "Breakfast" || breakfast.Description != "Weekday breakfast" {
+		t.Errorf("breakfast = %+v", breakfast)
+	}
+	if ids := mealFoodIDs(breakfast); len(ids) != 3 || ids[0] != 19540819 || ids[1] != 790472261 || ids[2] != 81137 {
+		t.Errorf("breakfast foods = %v", ids)
+	}
+	yogurt := breakfast.MealFoods[0]
+	if yogurt.Brand != "Chobani" || yogurt.Amount != 1 || yogurt.Unit == nil || yogurt.Unit.ID != 17 || yogurt.Calories != 130 {
+		t.Errorf("yogurt = %+v", yogurt)
+	}
+	if n := yogurt.NutritionalValues; n == nil || n.Protein != 12 || n.Carbs != 16 {
+		t.Errorf("yogurt nutrition = %+v", n)
+	}
+	granola := breakfast.MealFoods[1]
+	if granola.AccessLevel != "PRIVATE" || granola.Amount != 45 || granola.Unit == nil || granola.Unit.Name != "gram" {
+		t.Errorf("granola = %+v", granola)
+	}
+	if apple := breakfast.MealFoods[2]; apple.Amount != 0.5 || apple.NutritionalValues != nil {
+		t.Errorf("apple = %+v, want no nutrition", apple)
+	}
+	if snack := meals[1]; len(snack.MealFoods) != 1 || snack.MealFoods[0].MealTypeID != Anytime || snack.MealFoods[0].NutritionalValues == nil || snack.MealFoods[0].NutritionalValues.Fat != 10.6 {
+		t.Errorf("snack = %+v", snack)
+	}
+}
+
+func TestMealsEmpty(t *testing.T) {
+	c := newTestClient(t, &requestRecorder{Response: `{"meals":[]}`})
+	meals, err := c.Meals(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meals == nil || len(meals) != 0 {
+		t.Errorf("meals = %#v, want an empty slice", meals)
+	}
+}
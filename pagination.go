@@ -0,0 +1,147 @@
+package fitbit
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// ErrNoMorePages is returned when asking a page for the page after it
+// when there is none.
+var ErrNoMorePages = errors.New("fitbit: no more pages")
+
+// Pagination is the pagination block returned by Fitbit's list
+// endpoints.
+type Pagination struct {
+	AfterDate  string `json:"afterDate"`
+	BeforeDate string `json:"beforeDate"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Sort       string `json:"sort"`
+	Next       string `json:"next"`
+	Previous   string `json:"previous"`
+}
+
+// HasNext reports whether there is a page after this one.
+func (p Pagination) HasNext() bool {
+	return p.Next != ""
+}
+
+// Pager is implemented by each page of results returned from a
+// paginated list endpoint. The concrete page types also have a typed
+// Next method.
+type Pager interface {
+	HasNext() bool
+	NextPage(ctx context.Context) (Pager, error)
+}
+
+// EachPage calls fn with first and then every following page, in order,
+// until there are no more pages or fn returns an error.
+func EachPage(ctx context.Context, first Pager, fn func(Pager) error) error {
+	for page := first; ; {
+		if err := fn(page); err != nil {
+			return err
+		}
+		if !page.HasNext() {
+			return nil
+		}
+
+		var err error
+		if page, err = page.NextPage(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// SortOrder is the order list endpoints return entries in.
+type SortOrder string
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// ListOptions are the query parameters shared by Fitbit's paginated
+// list endpoints. Exactly one of BeforeDate and AfterDate must be set.
+type ListOptions struct {
+	BeforeDate Date
+	AfterDate  Date
+	// Sort defaults to ascending when AfterDate is set and descending
+	// when BeforeDate is; Fitbit rejects any other combination.
+	Sort SortOrder
+	// Limit is the page size, and must not exceed the endpoint's
+	// maximum.
+	Limit  int
+	Offset int
+}
+
+// values validates o and encodes it as a query string for an endpoint
+// whose page size is capped at maxLimit.
+func (o ListOptions) values(maxLimit int) (url.Values, error) {
+	if o.BeforeDate.IsZero() == o.AfterDate.IsZero() {
+		return nil, errors.New("fitbit: exactly one of BeforeDate and AfterDate must be set")
+	}
+	if o.Limit < 1 || o.Limit > maxLimit {
+		return nil, fmt.Errorf("fitbit: limit must be between 1 and %d", maxLimit)
+	}
+	if o.Offset < 0 {
+		return nil, errors.New("fitbit: offset must not be negative")
+	}
+
+	v := url.Values{}
+	sort := o.Sort
+	if o.AfterDate.IsZero() {
+		v.Set("beforeDate", o.BeforeDate.String())
+		if sort == "" {
+			sort = SortDescending
+		}
+		if sort != SortDescending {
+			return nil, errors.New("fitbit: BeforeDate requires descending sort")
+		}
+	} else {
+		v.Set("afterDate", o.AfterDate.String())
+		if sort == "" {
+			sort = SortAscending
+		}
+		if sort != SortAscending {
+			return nil, errors.New("fitbit: AfterDate requires ascending sort")
+		}
+	}
+	v.Set("sort", string(sort))
+	v.Set("limit", strconv.Itoa(o.Limit))
+	v.Set("offset", strconv.Itoa(o.Offset))
+	return v, nil
+}
+
+// pageURL converts an absolute pagination link into a version and
+// urlStr for newRequest.
+func (c *Client) pageURL(link string) (version, urlStr string, err error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The link path is {root}/{version}/{resource}, where root is
+	// whatever BaseUrl has before its own version segment.
+	base := strings.TrimSuffix(c.BaseUrl.Path, "/")
+	root := path.Dir(base)
+	rel := strings.TrimPrefix(u.Path, strings.TrimSuffix(root, "/")+"/")
+	i := strings.Index(rel, "/")
+	if rel == u.Path || i < 0 {
+		return "", "", fmt.Errorf("fitbit: unexpected pagination link %q", link)
+	}
+
+	version, urlStr = rel[:i], rel[i:]
+	if version == path.Base(base) {
+		version = ""
+	}
+	if u.RawQuery != "" {
+		urlStr += "?" + u.RawQuery
+	}
+	return version, urlStr, nil
+}
@@ -0,0 +1,137 @@
+package fitbit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// ClientPool keeps a Client per user, so that the clients of users
+// making repeated requests are reused along with their connections and
+// token. Tokens are loaded from, and refreshed tokens saved back to, a
+// TokenStore.
+type ClientPool struct {
+	cfg         *ConfigSource
+	store       TokenStore
+	maxClients  int
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+	lru     *list.List // of *poolEntry, most recently used first
+}
+
+type poolEntry struct {
+	userID string
+	elem   *list.Element
+
+	// ready is closed once client (or err) is set.
+	ready  chan struct{}
+	client *Client
+	err    error
+
+	// leases and lastUsed are guarded by the pool's mu.
+	leases   int
+	lastUsed time.Time
+}
+
+// NewClientPool returns a pool making clients with the tokens in store.
+// It keeps at most maxClients clients (0 for no limit) and drops those
+// unused for idleTimeout (0 to keep them). Clients that are leased out
+// are never dropped, so the pool may exceed maxClients while they are.
+func (c *ConfigSource) NewClientPool(store TokenStore, maxClients int, idleTimeout time.Duration) *ClientPool {
+	return &ClientPool{
+		cfg:         c,
+		store:       store,
+		maxClients:  maxClients,
+		idleTimeout: idleTimeout,
+		entries:     make(map[string]*poolEntry),
+		lru:         list.New(),
+	}
+}
+
+// Get leases the client for the user with the given Fitbit user id,
+// making it from the stored token if needed. The client can't be
+// evicted until release is called.
+func (p *ClientPool) Get(userID string) (c *Client, release func(), err error) {
+	now := time.Now()
+	p.mu.Lock()
+	e, ok := p.entries[userID]
+	if ok {
+		p.lru.MoveToFront(e.elem)
+	} else {
+		e = &poolEntry{userID: userID, ready: make(chan struct{})}
+		e.elem = p.lru.PushFront(e)
+		p.entries[userID] = e
+	}
+	e.leases++
+	e.lastUsed = now
+	p.evict(now)
+	p.mu.Unlock()
+
+	if ok {
+		<-e.ready
+	} else {
+		e.client, e.err = p.newClient(userID)
+		close(e.ready)
+	}
+	if e.err != nil {
+		p.mu.Lock()
+		e.leases--
+		p.remove(e)
+		p.mu.Unlock()
+		return nil, nil, e.err
+	}
+
+	var once sync.Once
+	return e.client, func() { once.Do(func() { p.release(e) }) }, nil
+}
+
+func (p *ClientPool) newClient(userID string) (*Client, error) {
+	tok, err := p.store.LoadToken(userID)
+	if err != nil {
+		return nil, err
+	}
+	c := p.cfg.NewClient(tok)
+	c.tokens.save = func(tok *oauth2.Token) error {
+		return p.store.SaveToken(userID, tok)
+	}
+	return c, nil
+}
+
+// release ends a lease of e's client.
+func (p *ClientPool) release(e *poolEntry) {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.leases--
+	e.lastUsed = now
+	p.evict(now)
+}
+
+// evict drops idle clients and, least recently used first, those over
+// maxClients. p.mu must be held.
+func (p *ClientPool) evict(now time.Time) {
+	for elem := p.lru.Back(); elem != nil; {
+		e := elem.Value.(*poolEntry)
+		elem = elem.Prev()
+		if e.leases > 0 {
+			continue
+		}
+		idle := p.idleTimeout > 0 && now.Sub(e.lastUsed) > p.idleTimeout
+		over := p.maxClients > 0 && p.lru.Len() > p.maxClients
+		if idle || over {
+			p.remove(e)
+		}
+	}
+}
+
+// remove drops e from the pool, if it's still there. p.mu must be held.
+func (p *ClientPool) remove(e *poolEntry) {
+	if p.entries[e.userID] == e {
+		delete(p.entries, e.userID)
+		p.lru.Remove(e.elem)
+	}
+}
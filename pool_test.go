@@ -0,0 +1,227 @@
+package fitbit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// memTokenStore is a TokenStore in memory that counts its loads.
+type memTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*oauth2.Token
+	loads  map[string]int
+}
+
+func newMemTokenStore(userIDs ...string) *memTokenStore {
+	s := &memTokenStore{tokens: make(map[string]*oauth2.Token), loads: make(map[string]int)}
+	for _, id := range userIDs {
+		s.tokens[id] = &oauth2.Token{AccessToken: "access-" + id, RefreshToken: "refresh-" + id}
+	}
+	return s
+}
+
+func (s *memTokenStore) LoadToken(userID string) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads[userID]++
+	tok, ok := s.tokens[userID]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return tok, nil
+}
+
+func (s *memTokenStore) SaveToken(userID string, tok *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[userID] = tok
+	return nil
+}
+
+func (s *memTokenStore) loadCount(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loads[userID]
+}
+
+func newTestPool(store TokenStore, maxClients int, idleTimeout time.Duration) *ClientPool {
+	return NewConfigSource(&oauth2.Config{}).NewClientPool(store, maxClients, idleTimeout)
+}
+
+func mustGet(t *testing.T, p *ClientPool, userID string) (*Client, func()) {
+	t.Helper()
+	c, release, err := p.Get(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, release
+}
+
+func TestClientPoolSharesClient(t *testing.T) {
+	store := newMemTokenStore("A")
+	p := newTestPool(store, 0, 0)
+
+	clients := make([]*Client, 10)
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, release, err := p.Get("A")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer release()
+			clients[i] = c
+		}()
+	}
+	wg.Wait()
+
+	for _, c := range clients[1:] {
+		if c != clients[0] {
+			t.Fatal("concurrent Gets for one user returned different clients")
+		}
+	}
+	if n := store.loadCount("A"); n != 1 {
+		t.Errorf("token loaded %d times, want 1", n)
+	}
+}
+
+func TestClientPoolNotFound(t *testing.T) {
+	p := newTestPool(newMemTokenStore(), 0, 0)
+	if _, _, err := p.Get("A"); err != ErrTokenNotFound {
+		t.Errorf("err = %v, want ErrTokenNotFound", err)
+	}
+	if len(p.entries) != 0 || p.lru.Len() != 0 {
+		t.Error("the failed client was kept")
+	}
+}
+
+func TestClientPoolLRU(t *testing.T) {
+	store := newMemTokenStore("A", "B", "C")
+	p := newTestPool(store, 2, 0)
+
+	a, release := mustGet(t, p, "A")
+	release()
+	_, release = mustGet(t, p, "B")
+	release()
+	// A is now the most recently used, so C pushes B out.
+	_, release = mustGet(t, p, "A")
+	release()
+	_, release = mustGet(t, p, "C")
+	release()
+
+	if p.lru.Len() != 2 {
+		t.Errorf("pool has %d clients, want 2", p.lru.Len())
+	}
+	if again, release := mustGet(t, p, "A"); again != a {
+		t.Error("A was dropped")
+	} else {
+		release()
+	}
+	if _, ok := p.entries["B"]; ok {
+		t.Error("B wasn't dropped")
+	}
+}
+
+func TestClientPoolKeepsLeased(t *testing.T) {
+	store := newMemTokenStore("A", "B", "C")
+	p := newTestPool(store, 1, 0)
+
+	a, releaseA := mustGet(t, p, "A")
+	_, releaseB := mustGet(t, p, "B")
+	_, releaseC := mustGet(t, p, "C")
+	if p.lru.Len() != 3 {
+		t.Errorf("pool has %d clients, want all 3 leased ones", p.lru.Len())
+	}
+	if again, release := mustGet(t, p, "A"); again != a {
+		t.Error("the leased client of A was replaced")
+	} else {
+		release()
+	}
+
+	releaseA()
+	releaseA() // releasing twice is a no-op
+	releaseB()
+	if p.entries["C"] == nil || p.entries["C"].leases != 1 {
+		t.Fatal("releasing other leases touched C's")
+	}
+	releaseC()
+	if p.lru.Len() != 1 {
+		t.Errorf("pool has %d clients once released, want 1", p.lru.Len())
+	}
+}
+
+func TestClientPoolIdle(t *testing.T) {
+	store := newMemTokenStore("A", "B")
+	p := newTestPool(store, 0, 10*time.Millisecond)
+
+	a, release := mustGet(t, p, "A")
+	release()
+	time.Sleep(20 * time.Millisecond)
+	_, release = mustGet(t, p, "B")
+	release()
+
+	if _, ok := p.entries["A"]; ok {
+		t.Error("idle client of A wasn't dropped")
+	}
+	if again, release := mustGet(t, p, "A"); again == a {
+		t.Error("Get returned the dropped client")
+	} else {
+		release()
+	}
+	if n := store.loadCount("A"); n != 2 {
+		t.Errorf("A's token loaded %d times, want 2", n)
+	}
+}
+
+func TestClientPoolSavesRefreshedToken(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("refresh_token") != "refresh-A" {
+			http.Error(w, `{"errors":[{"errorType":"invalid_grant"}]}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token":"new","refresh_token":"r2","expires_in":3600,"token_type":"Bearer","user_id":"A"}`)
+	})
+	mux.HandleFunc("/1/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, `{}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	store := newMemTokenStore()
+	store.tokens["A"] = &oauth2.Token{
+		AccessToken:  "old",
+		RefreshToken: "refresh-A",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	cfg := &oauth2.Config{Endpoint: oauth2.Endpoint{
+		TokenURL:  srv.URL + "/oauth2/token",
+		AuthStyle: oauth2.AuthStyleInParams,
+	}}
+	p := NewConfigSource(cfg).NewClientPool(store, 0, 0)
+
+	c, release := mustGet(t, p, "A")
+	defer release()
+	c.BaseUrl = mustParseURL(t, srv.URL+"/1")
+	if err := c.get(t.Context(), "/user/-/profile.json", nil); err != nil {
+		t.Fatal(err)
+	}
+	tok, _ := store.LoadToken("A")
+	if tok.AccessToken != "new" || tok.RefreshToken != "r2" {
+		t.Errorf("stored token = %+v, want the refreshed one", tok)
+	}
+}
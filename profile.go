@@ -0,0 +1,171 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// UserProfileFor returns the profile of the user with the given encoded
+// id, typically a friend. Other users' profiles usually only include
+// their public fields, in which case User.Reduced is set. Users whose
+// privacy settings hide their profile result in an error matching
+// ErrForbidden.
+func (c *Client) UserProfileFor(ctx context.Context, userID string) (UserProfile, error) {
+	var resp struct {
+		User json.RawMessage `json:"user"`
+	}
+	var profile UserProfile
+	err := c.get(ctx, fmt.Sprintf("/user/%s/profile.json", url.PathEscape(userID)), &resp)
+	if err != nil {
+		return profile, err
+	}
+	if err := json.Unmarshal(resp.User, &profile.User); err != nil {
+		return profile, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(resp.User, &fields); err != nil {
+		return profile, err
+	}
+	_, hasWeight := fields["weight"]
+	_, hasHeight := fields["height"]
+	profile.User.Reduced = !hasWeight || !hasHeight
+	profile.User.Units = c.unitSystem()
+	return profile, nil
+}
+
+// ProfileUpdate holds the profile fields to change. Zero fields are left
+// out of the request.
+type ProfileUpdate struct {
+	FullName string
+	Gender   string // MALE, FEMALE or NA
+	Birthday Date
+
+	// Height and the stride lengths are in Units, which must be set
+	// along with any of them: centimeters for METRIC and en_GB, inches
+	// for en_US.
+	Height              float64
+	StrideLengthWalking float64
+	StrideLengthRunning float64
+	Units               UnitSystem
+
+	WeightUnit string // e.g. en_US
+	HeightUnit string
+	WaterUnit  string
+
+	// Timezone is an IANA name such as "America/Los_Angeles".
+	Timezone      string
+	FoodsLocale   string
+	Locale        string
+	LocaleLang    string
+	LocaleCountry string
+
+	StartDayOfWeek         string // SUNDAY or MONDAY
+	ClockTimeDisplayFormat string // 12hour or 24hour
+}
+
+func (u ProfileUpdate) params() (url.Values, error) {
+	params := url.Values{}
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+
+	set("fullname", u.FullName)
+	switch u.Gender {
+	case "", "MALE", "FEMALE", "NA":
+		set("gender", u.Gender)
+	default:
+		return nil, fmt.Errorf("fitbit: invalid gender %q", u.Gender)
+	}
+	if !u.Birthday.IsZero() {
+		params.Set("birthday", u.Birthday.String())
+	}
+
+	measurements := []struct {
+		name  string
+		value float64
+	}{
+		{"height", u.Height},
+		{"strideLengthWalking", u.StrideLengthWalking},
+		{"strideLengthRunning", u.StrideLengthRunning},
+	}
+	for _, m := range measurements {
+		if m.value == 0 {
+			continue
+		}
+		if m.value < 0 {
+			return nil, fmt.Errorf("fitbit: profile %s must be positive", m.name)
+		}
+		if u.Units == "" {
+			return nil, fmt.Errorf("fitbit: profile %s needs Units", m.name)
+		}
+		params.Set(m.name, strconv.FormatFloat(m.value, 'f', -1, 64))
+	}
+	if u.Units != "" {
+		if err := u.Units.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	set("weightUnit", u.WeightUnit)
+	set("heightUnit", u.HeightUnit)
+	set("waterUnit", u.WaterUnit)
+	if u.Timezone != "" {
+		if _, err := time.LoadLocation(u.Timezone); err != nil {
+			return nil, fmt.Errorf("fitbit: invalid timezone %q: %v", u.Timezone, err)
+		}
+		params.Set("timezone", u.Timezone)
+	}
+	set("foodsLocale", u.FoodsLocale)
+	set("locale", u.Locale)
+	set("localeLang", u.LocaleLang)
+	set("localeCountry", u.LocaleCountry)
+	switch u.StartDayOfWeek {
+	case "", "SUNDAY", "MONDAY":
+		set("startDayOfWeek", u.StartDayOfWeek)
+	default:
+		return nil, fmt.Errorf("fitbit: invalid start day of week %q", u.StartDayOfWeek)
+	}
+	switch u.ClockTimeDisplayFormat {
+	case "", "12hour", "24hour":
+		set("clockTimeDisplayFormat", u.ClockTimeDisplayFormat)
+	default:
+		return nil, fmt.Errorf("fitbit: invalid clock time display format %q", u.ClockTimeDisplayFormat)
+	}
+
+	if len(params) == 0 {
+		return nil, errors.New("fitbit: profile update has no fields set")
+	}
+	return params, nil
+}
+
+// UpdateProfile changes the user's profile and returns the updated
+// profile. When u.Units is set the request (and so the returned
+// measurements) use it, regardless of c.UnitSystem.
+func (c *Client) UpdateProfile(ctx context.Context, u ProfileUpdate) (UserProfile, error) {
+	var profile UserProfile
+	params, err := u.params()
+	if err != nil {
+		return profile, err
+	}
+
+	units := c.unitSystem()
+	var opts []RequestOption
+	if u.Units != "" {
+		units = u.Units
+		opts = append(opts, withUnits(u.Units))
+	}
+	if err := c.postForm(ctx, "", "/user/-/profile.json", params, &profile, opts...); err != nil {
+		return profile, err
+	}
+	profile.User.Units = units
+	return profile, nil
+}
@@ -0,0 +1,220 @@
+package fitbit
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestUserProfileTopBadges(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []string
+	}{
+		{"profile_us.json", []string{"DAILY_STEPS", "LIFETIME_DISTANCE"}},
+		// Users who showcase no badges have no topBadges at all.
+		{"profile_metric.json", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("GET /1/user/-/profile.json", serveFixture(t, tt.fixture))
+			c := newTestClient(t, mux)
+
+			profile, err := c.UserProfile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			badges := profile.User.TopBadges
+			if badges == nil {
+				t.Fatal("TopBadges is nil, want a non-nil slice")
+			}
+			var types []string
+			for _, b := range badges {
+				types = append(types, b.BadgeType)
+			}
+			if !equalStrings(types, tt.want) {
+				t.Errorf("top badge types = %q, want %q", types, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserProfileTopBadgeFields(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/profile.json", serveFixture(t, "profile_us.json"))
+	c := newTestClient(t, mux)
+
+	profile, err := c.UserProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := profile.User.TopBadges[1]
+	if b.EncodedID != "22B8M5" || b.Value != 1000 || b.Unit != "MILES" || b.DateTime != (Date{2019, 11, 2}) || len(b.Images) != 5 {
+		t.Errorf("top badge = %+v", b)
+	}
+}
+
+func TestUserProfileFeatures(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/profile.json", serveFixture(t, "profile_metric.json"))
+	c := newTestClient(t, mux)
+
+	profile, err := c.UserProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := profile.User.Features
+	if !f.ExerciseGoal {
+		t.Error("ExerciseGoal = false, want true")
+	}
+	if _, ok := f.Other["exerciseGoal"]; ok {
+		t.Error("exerciseGoal is also in Other")
+	}
+	if got, want := string(f.Other["sleepScoreBeta"]), `{"enrolled": false}`; got != want {
+		t.Errorf("Other[sleepScoreBeta] = %s, want %s", got, want)
+	}
+	if len(f.Other) != 1 {
+		t.Errorf("Other = %s, want only sleepScoreBeta", f.Other)
+	}
+}
+
+// modernUser is the user in testdata/profile_modern.json, as requested
+// in METRIC units.
+var modernUser = User{
+	AboutMe:                 "Morning runs, evening swims.",
+	Age:                     29,
+	AutoStrideEnabled:       true,
+	Avatar:                  "https://static0.fitbit.com/images/profile/defaultProfile_100.png",
+	Avatar150:               "https://static0.fitbit.com/images/profile/defaultProfile_150.png",
+	Avatar640:               "https://static0.fitbit.com/images/profile/defaultProfile_640.png",
+	AverageDailySteps:       11876,
+	ChallengesBeta:          true,
+	City:                    "Leeds",
+	ClockTimeDisplayFormat:  "24hour",
+	Country:                 "GB",
+	DateOfBirth:             "1996-02-18",
+	DisplayName:             "Priya P.",
+	DisplayNameSetting:      "name",
+	DistanceUnit:            "METRIC",
+	EncodedID:               "7PZK3D",
+	Features:                Features{ExerciseGoal: true},
+	FirstName:               "Priya",
+	FullName:                "Priya Patel",
+	Gender:                  "FEMALE",
+	GlucoseUnit:             "METRIC",
+	Height:                  165.1,
+	HeightUnit:              "METRIC",
+	LanguageLocale:          "en_GB",
+	LastName:                "Patel",
+	Locale:                  "en_GB",
+	MemberSince:             "2018-03-04",
+	MFAEnabled:              true,
+	OffsetFromUTCMillis:     3600000,
+	SleepTracking:           "Sensitive",
+	StartDayOfWeek:          "MONDAY",
+	State:                   "West Yorkshire",
+	StrideLengthRunning:     104.2,
+	StrideLengthRunningType: "auto",
+	StrideLengthWalking:     68.5,
+	StrideLengthWalkingType: "auto",
+	SwimUnit:                "METRIC",
+	TemperatureUnit:         "METRIC",
+	Timezone:                "Europe/London",
+	TopBadges:               []Badge{},
+	Units:                   UnitSystemMetric,
+	WaterUnit:               "METRIC",
+	WaterUnitName:           "ml",
+	Weight:                  58.2,
+	WeightUnit:              "en_GB",
+}
+
+func TestUserProfileModern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/profile.json", serveFixture(t, "profile_modern.json"))
+	c := newTestClient(t, mux)
+
+	profile, err := c.UserProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(profile.User, modernUser) {
+		t.Errorf("user =\n%+v\nwant\n%+v", profile.User, modernUser)
+	}
+}
+
+func TestUserProfileFor(t *testing.T) {
+	reduced := modernUser
+	reduced.Reduced = true
+	for _, f := range []*string{
+		&reduced.ClockTimeDisplayFormat, &reduced.DateOfBirth, &reduced.DistanceUnit,
+		&reduced.GlucoseUnit, &reduced.HeightUnit, &reduced.LanguageLocale, &reduced.Locale,
+		&reduced.SleepTracking, &reduced.StartDayOfWeek, &reduced.StrideLengthRunningType,
+		&reduced.StrideLengthWalkingType, &reduced.SwimUnit, &reduced.TemperatureUnit,
+		&reduced.Timezone, &reduced.WaterUnit, &reduced.WaterUnitName, &reduced.WeightUnit,
+	} {
+		*f = ""
+	}
+	reduced.AutoStrideEnabled, reduced.ChallengesBeta, reduced.MFAEnabled = false, false, false
+	reduced.Height, reduced.Weight = 0, 0
+	reduced.StrideLengthRunning, reduced.StrideLengthWalking = 0, 0
+	reduced.OffsetFromUTCMillis = 0
+
+	tests := []struct {
+		fixture string
+		want    User
+	}{
+		{"profile_modern.json", modernUser},
+		{"profile_reduced.json", reduced},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("GET /1/user/7PZK3D/profile.json", serveFixture(t, tt.fixture))
+			c := newTestClient(t, mux)
+
+			profile, err := c.UserProfileFor(t.Context(), "7PZK3D")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(profile.User, tt.want) {
+				t.Errorf("user =\n%+v\nwant\n%+v", profile.User, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileUpdateParams(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		u    ProfileUpdate
+		want url.Values // nil for an error
+	}{
+		{"name and gender", ProfileUpdate{FullName: "Ada", Gender: "FEMALE"}, url.Values{"fullname": {"Ada"}, "gender": {"FEMALE"}}},
+		{"height", ProfileUpdate{Height: 180.5, Units: UnitSystemMetric}, url.Values{"height": {"180.5"}}},
+		{"timezone", ProfileUpdate{Timezone: "America/Los_Angeles"}, url.Values{"timezone": {"America/Los_Angeles"}}},
+		{"bad gender", ProfileUpdate{Gender: "female"}, nil},
+		{"height without units", ProfileUpdate{Height: 180}, nil},
+		{"stride without units", ProfileUpdate{StrideLengthRunning: 120}, nil},
+		{"negative height", ProfileUpdate{Height: -1, Units: UnitSystemMetric}, nil},
+		{"bad units", ProfileUpdate{Height: 180, Units: "cm"}, nil},
+		{"bad timezone", ProfileUpdate{Timezone: "Mars/Olympus_Mons"}, nil},
+		{"bad start day", ProfileUpdate{StartDayOfWeek: "FRIDAY"}, nil},
+		{"empty", ProfileUpdate{}, nil},
+		{"only units", ProfileUpdate{Units: UnitSystemUS}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.u.params()
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("params() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("params() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// flightGroup coalesces concurrent calls with the same key into one, in
+// the manner of golang.org/x/sync/singleflight.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	// done is closed once body and err are set.
+	done chan struct{}
+	body json.RawMessage
+	err  error
+}
+
+// do calls fn unless a call for key is in flight, in which case it
+// waits for that one's result. If that caller gives up, fn is called anew.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (json.RawMessage, error)) (json.RawMessage, error) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = make(map[string]*flightCall)
+		}
+		call, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if isContextError(call.err) && ctx.Err() == nil {
+			continue
+		}
+		return call.body, call.err
+	}
+
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.body, call.err = fn()
+	close(call.done)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	return call.body, call.err
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// flightKey identifies a GET by its url and the headers its response
+// depends on.
+func flightKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String() +
+		" " + req.Header.Get("Accept-Language") +
+		" " + req.Header.Get("Accept-Locale") +
+		" " + req.Header.Get("Authorization")
+}
+
+// doCoalesced is Do for a GET that shares its response with identical
+// requests in flight.
+func (c *Client) doCoalesced(req *http.Request, v interface{}) error {
+	body, err := c.flight.do(req.Context(), flightKey(req), func() (json.RawMessage, error) {
+		var body json.RawMessage
+		_, err := c.Do(req, &body)
+		return body, err
+	})
+	if err != nil || v == nil || len(body) == 0 {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
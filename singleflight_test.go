@@ -0,0 +1,174 @@
+package fitbit
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// blockingServer answers each request with its Authorization header
+// once release is closed, counting the requests.
+type blockingServer struct {
+	hits    atomic.Int32
+	arrived chan struct{}
+	release chan struct{}
+}
+
+func newBlockingServer() *blockingServer {
+	return &blockingServer{arrived: make(chan struct{}, 100), release: make(chan struct{})}
+}
+
+func (s *blockingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.hits.Add(1)
+	s.arrived <- struct{}{}
+	select {
+	case <-s.release:
+	case <-r.Context().Done():
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"auth": r.Header.Get("Authorization")})
+}
+
+// waitFlight waits until c has at least n coalesced calls in flight.
+func waitFlight(t *testing.T, c *Client, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.flight.mu.Lock()
+		inFlight := len(c.flight.calls)
+		c.flight.mu.Unlock()
+		if inFlight >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fewer than %d calls in flight", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalesceGets(t *testing.T) {
+	srv := newBlockingServer()
+	c := newTestClient(t, srv)
+	c.CoalesceGets = true
+
+	var wg sync.WaitGroup
+	results := make([]map[string]string, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.get(t.Context(), "/user/-/profile.json", &results[i]); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	<-srv.arrived
+	// Let the others join the call before it completes.
+	time.Sleep(20 * time.Millisecond)
+	close(srv.release)
+	wg.Wait()
+
+	if n := srv.hits.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+	for i, r := range results {
+		if r == nil {
+			t.Errorf("results[%d] wasn't decoded", i)
+		}
+	}
+}
+
+func TestCoalesceGetsKeysOnAuthorization(t *testing.T) {
+	srv := newBlockingServer()
+	c := newTestClient(t, srv)
+	c.CoalesceGets = true
+
+	var wg sync.WaitGroup
+	results := make([]map[string]string, 2)
+	for i, tok := range []string{"Bearer a", "Bearer b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.get(t.Context(), "/user/-/profile.json", &results[i], WithHeader("Authorization", tok))
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	<-srv.arrived
+	<-srv.arrived
+	close(srv.release)
+	wg.Wait()
+
+	if results[0]["auth"] != "Bearer a" || results[1]["auth"] != "Bearer b" {
+		t.Errorf("results = %v, want each caller's own response", results)
+	}
+}
+
+func TestCoalesceGetsLeaderCanceled(t *testing.T) {
+	srv := newBlockingServer()
+	c := newTestClient(t, srv)
+	c.CoalesceGets = true
+
+	leaderCtx, cancel := context.WithCancel(t.Context())
+	leaderErr := make(chan error, 1)
+	go func() {
+		leaderErr <- c.get(leaderCtx, "/user/-/profile.json", nil)
+	}()
+	<-srv.arrived
+	waitFlight(t, c, 1)
+
+	waiterErr := make(chan error, 1)
+	var got map[string]string
+	go func() {
+		waiterErr <- c.get(t.Context(), "/user/-/profile.json", &got)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader: err = %v, want context.Canceled", err)
+	}
+
+	// The waiter sends the request itself.
+	<-srv.arrived
+	close(srv.release)
+	if err := <-waiterErr; err != nil {
+		t.Fatalf("waiter: err = %v, want the response", err)
+	}
+	if got == nil {
+		t.Error("waiter's response wasn't decoded")
+	}
+	if n := srv.hits.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+}
+
+func TestCoalesceGetsWaiterCanceled(t *testing.T) {
+	srv := newBlockingServer()
+	c := newTestClient(t, srv)
+	c.CoalesceGets = true
+
+	leaderErr := make(chan error, 1)
+	go func() {
+		leaderErr <- c.get(t.Context(), "/user/-/profile.json", nil)
+	}()
+	<-srv.arrived
+	waitFlight(t, c, 1)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.get(ctx, "/user/-/profile.json", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiter: err = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(srv.release)
+	if err := <-leaderErr; err != nil {
+		t.Errorf("leader: err = %v", err)
+	}
+}
@@ -0,0 +1,541 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// maxSleepRangeDays is the longest span Fitbit allows for a single
+// sleep range request.
+const maxSleepRangeDays = 100
+
+// SleepLog is a single sleep record. A night may have several (a main
+// sleep plus naps); IsMainSleep marks the main one.
+type SleepLog struct {
+	LogID       int64  `json:"logId"`
+	DateOfSleep Date   `json:"dateOfSleep"`
+	StartTime   string `json:"startTime"` // 2020-02-20T23:21:30.000
+	EndTime     string `json:"endTime"`
+	// Start and End are StartTime and EndTime in the user's timezone,
+	// or zero if it is unknown (see Client.Location).
+	Start time.Time `json:"-"`
+	End   time.Time `json:"-"`
+	// Duration is in milliseconds.
+	Duration            int64        `json:"duration"`
+	Efficiency          int          `json:"efficiency"`
+	IsMainSleep         bool         `json:"isMainSleep"`
+	MinutesAsleep       int          `json:"minutesAsleep"`
+	MinutesAwake        int          `json:"minutesAwake"`
+	MinutesAfterWakeup  int          `json:"minutesAfterWakeup"`
+	MinutesToFallAsleep int          `json:"minutesToFallAsleep"`
+	TimeInBed           int          `json:"timeInBed"`
+	InfoCode            int          `json:"infoCode"`
+	LogType             string       `json:"logType"` // auto_detected or manual
+	Type                SleepLogType `json:"type"`
+	Levels              SleepLevels  `json:"levels"`
+}
+
+// SleepLogType is the kind of a sleep log, which determines the levels
+// its data is broken down into.
+type SleepLogType string
+
+const (
+	// SleepLogStages logs break sleep into deep, light, rem and wake.
+	SleepLogStages SleepLogType = "stages"
+	// SleepLogClassic logs break sleep into asleep, restless and awake.
+	// Manual logs and logs without enough heart rate data are classic.
+	SleepLogClassic SleepLogType = "classic"
+)
+
+// SleepLevel is a level of sleep, from either the stages or the classic
+// vocabulary.
+type SleepLevel string
+
+const (
+	SleepLevelDeep  SleepLevel = "deep"
+	SleepLevelLight SleepLevel = "light"
+	SleepLevelREM   SleepLevel = "rem"
+	SleepLevelWake  SleepLevel = "wake"
+
+	SleepLevelAsleep   SleepLevel = "asleep"
+	SleepLevelRestless SleepLevel = "restless"
+	SleepLevelAwake    SleepLevel = "awake"
+)
+
+// localize sets l's parsed timestamps from the raw ones.
+func (l *SleepLog) localize(loc *time.Location) error {
+	var err error
+	if l.Start, err = parseLocalDateTime(l.StartTime, loc); err != nil {
+		return err
+	}
+	l.End, err = parseLocalDateTime(l.EndTime, loc)
+	return err
+}
+
+// localizeSleepLogs sets the parsed timestamps of each of logs, unless
+// the user's timezone can't be had, in which case they are left zero.
+func (c *Client) localizeSleepLogs(ctx context.Context, logs []SleepLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	loc, err := c.location(ctx)
+	if err != nil {
+		// The logs are of use without their parsed timestamps.
+		return nil
+	}
+	for i := range logs {
+		if err := logs[i].localize(loc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SleepLevels is the per-level breakdown of a sleep log. Exactly one of
+// Stages and Classic is set, according to Type.
+type SleepLevels struct {
+	Type    SleepLogType
+	Stages  *StagesLevelSummary
+	Classic *ClassicLevelSummary
+	Data    []SleepLevelData
+	// ShortData holds the brief (under 3 minute) wake periods of stages
+	// logs, which overlap Data; see SleepLog.MergedTimeline.
+	ShortData []SleepLevelData
+}
+
+type StagesLevelSummary struct {
+	Deep  SleepLevelSummary
+	Light SleepLevelSummary
+	REM   SleepLevelSummary
+	Wake  SleepLevelSummary
+}
+
+type ClassicLevelSummary struct {
+	Asleep   SleepLevelSummary
+	Restless SleepLevelSummary
+	Awake    SleepLevelSummary
+}
+
+type SleepLevelSummary struct {
+	Count   int `json:"count"`
+	Minutes int `json:"minutes"`
+	// ThirtyDayAvgMinutes is only reported for stages logs.
+	ThirtyDayAvgMinutes *int `json:"thirtyDayAvgMinutes"`
+}
+
+// SleepLevelData is a contiguous period spent in one level.
+type SleepLevelData struct {
+	DateTime string     `json:"dateTime"`
+	Level    SleepLevel `json:"level"`
+	Seconds  int        `json:"seconds"`
+}
+
+func (l *SleepLevels) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Summary   map[SleepLevel]SleepLevelSummary `json:"summary"`
+		Data      []SleepLevelData                 `json:"data"`
+		ShortData []SleepLevelData                 `json:"shortData"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	levels := SleepLevels{Data: raw.Data, ShortData: raw.ShortData}
+	_, hasDeep := raw.Summary[SleepLevelDeep]
+	_, hasREM := raw.Summary[SleepLevelREM]
+	_, hasAsleep := raw.Summary[SleepLevelAsleep]
+	switch {
+	case hasDeep || hasREM:
+		levels.Type = SleepLogStages
+		levels.Stages = &StagesLevelSummary{
+			Deep:  raw.Summary[SleepLevelDeep],
+			Light: raw.Summary[SleepLevelLight],
+			REM:   raw.Summary[SleepLevelREM],
+			Wake:  raw.Summary[SleepLevelWake],
+		}
+	case hasAsleep:
+		levels.Type = SleepLogClassic
+		levels.Classic = &ClassicLevelSummary{
+			Asleep:   raw.Summary[SleepLevelAsleep],
+			Restless: raw.Summary[SleepLevelRestless],
+			Awake:    raw.Summary[SleepLevelAwake],
+		}
+	}
+	*l = levels
+	return nil
+}
+
+// SleepSummary totals all the sleep logs of a day.
+type SleepSummary struct {
+	TotalMinutesAsleep int `json:"totalMinutesAsleep"`
+	TotalSleepRecords  int `json:"totalSleepRecords"`
+	TotalTimeInBed     int `json:"totalTimeInBed"`
+	// Stages is only present when the day has a stages sleep log.
+	Stages *SleepStagesSummary `json:"stages"`
+}
+
+// SleepStagesSummary is the minutes spent in each sleep stage.
+type SleepStagesSummary struct {
+	Deep  int `json:"deep"`
+	Light int `json:"light"`
+	REM   int `json:"rem"`
+	Wake  int `json:"wake"`
+}
+
+// SleepDay holds the sleep logs for a date and their summary. Summary is
+// nil when Fitbit doesn't send one.
+type SleepDay struct {
+	Sleep   []SleepLog    `json:"sleep"`
+	Summary *SleepSummary `json:"summary"`
+}
+
+// SleepByDate returns the sleep logs whose dateOfSleep is date, which
+// may include naps as well as the main sleep.
+func (c *Client) SleepByDate(ctx context.Context, date Date) (SleepDay, error) {
+	var day SleepDay
+	err := c.getVersion(
+		ctx,
+		apiVersion1_2,
+		fmt.Sprintf("/user/-/sleep/date/%s.json", date),
+		&day,
+	)
+	if err != nil {
+		return day, err
+	}
+	return day, c.localizeSleepLogs(ctx, day.Sleep)
+}
+
+// SleepRange returns the sleep logs whose dateOfSleep falls between
+// start and end, inclusive, sorted by start time. Ranges longer than
+// Fitbit's 100 day cap are fetched in chunks. Fitbit sends no summary
+// for ranges.
+func (c *Client) SleepRange(ctx context.Context, start, end Date) ([]SleepLog, error) {
+	chunks, err := splitDateRange(start, end, maxSleepRangeDays)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []SleepLog
+	for _, chunk := range chunks {
+		var day SleepDay
+		err := c.getVersion(
+			ctx,
+			apiVersion1_2,
+			fmt.Sprintf("/user/-/sleep/date/%s/%s.json", chunk.Start, chunk.End),
+			&day,
+		)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, day.Sleep...)
+	}
+
+	// startTime is a fixed width local timestamp, so it sorts
+	// lexically.
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].StartTime < logs[j].StartTime
+	})
+	return logs, c.localizeSleepLogs(ctx, logs)
+}
+
+// maxSleepListLimit is the largest page size the sleep list endpoint
+// accepts.
+const maxSleepListLimit = 100
+
+// SleepLogPage is one page of sleep logs.
+type SleepLogPage struct {
+	Sleep      []SleepLog `json:"sleep"`
+	Pagination Pagination `json:"pagination"`
+
+	client *Client
+}
+
+// SleepLogs returns the first page of the user's sleep logs matching
+// opts; Limit may be at most 100. It is intended for backfills, where
+// walking the pages with EachPage or Next is cheaper than date ranges.
+func (c *Client) SleepLogs(ctx context.Context, opts ListOptions) (*SleepLogPage, error) {
+	q, err := opts.values(maxSleepListLimit)
+	if err != nil {
+		return nil, err
+	}
+	return c.sleepLogPage(ctx, apiVersion1_2, "/user/-/sleep/list.json?"+q.Encode())
+}
+
+func (c *Client) sleepLogPage(ctx context.Context, version, urlStr string) (*SleepLogPage, error) {
+	page := &SleepLogPage{client: c}
+	if err := c.getVersion(ctx, version, urlStr, page); err != nil {
+		return nil, err
+	}
+	if err := c.localizeSleepLogs(ctx, page.Sleep); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// HasNext reports whether there is a page after p.
+func (p *SleepLogPage) HasNext() bool {
+	return p.Pagination.HasNext()
+}
+
+// Next fetches the page after p, or returns ErrNoMorePages.
+func (p *SleepLogPage) Next(ctx context.Context) (*SleepLogPage, error) {
+	if !p.HasNext() {
+		return nil, ErrNoMorePages
+	}
+	version, urlStr, err := p.client.pageURL(p.Pagination.Next)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.sleepLogPage(ctx, version, urlStr)
+}
+
+// NextPage implements Pager.
+func (p *SleepLogPage) NextPage(ctx context.Context) (Pager, error) {
+	next, err := p.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// ErrSleepLogOverlap matches (with errors.Is) the error LogSleep returns
+// when Fitbit rejects the log for overlapping an existing one.
+var ErrSleepLogOverlap = errors.New("fitbit: sleep log overlaps an existing log")
+
+// NewSleepLog is a sleep log to create by hand.
+type NewSleepLog struct {
+	Date Date
+	// StartTime is the local clock time sleep started, as HH:mm.
+	StartTime string
+	Duration  time.Duration
+}
+
+func (l NewSleepLog) params() (url.Values, error) {
+	if l.Date.IsZero() {
+		return nil, errors.New("fitbit: sleep log date is required")
+	}
+	if _, err := time.Parse("15:04", l.StartTime); err != nil {
+		return nil, fmt.Errorf("fitbit: sleep log start time %q is not HH:mm", l.StartTime)
+	}
+	if l.Duration < time.Minute || l.Duration > 24*time.Hour {
+		return nil, errors.New("fitbit: sleep log duration must be between a minute and a day")
+	}
+
+	return url.Values{
+		"date":      {l.Date.String()},
+		"startTime": {l.StartTime},
+		"duration":  {strconv.FormatInt(int64(l.Duration/time.Millisecond), 10)},
+	}, nil
+}
+
+// LogSleep creates a manual sleep log. Manual logs are always classic
+// logs.
+func (c *Client) LogSleep(ctx context.Context, l NewSleepLog) (SleepLog, error) {
+	var resp struct {
+		Sleep SleepLog `json:"sleep"`
+	}
+	params, err := l.params()
+	if err != nil {
+		return resp.Sleep, err
+	}
+
+	err = c.postForm(ctx, apiVersion1_2, "/user/-/sleep.json", params, &resp)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && apiErr.hasError("validation", "startTime") {
+		return resp.Sleep, fmt.Errorf("%w: %w", ErrSleepLogOverlap, apiErr)
+	}
+	if err != nil {
+		return resp.Sleep, err
+	}
+
+	// The log is created, so a malformed timestamp only leaves Start and
+	// End zero rather than failing the call.
+	logs := []SleepLog{resp.Sleep}
+	if c.localizeSleepLogs(ctx, logs) != nil {
+		logs[0].Start, logs[0].End = time.Time{}, time.Time{}
+	}
+	return logs[0], nil
+}
+
+// DeleteSleepLog deletes the sleep log with the given id. It returns an
+// error matching ErrNotFound if there is no such log.
+func (c *Client) DeleteSleepLog(ctx context.Context, logID int64) error {
+	return c.delete(ctx, apiVersion1_2, fmt.Sprintf("/user/-/sleep/%d.json", logID))
+}
+
+// SleepGoal is the user's sleep goal and, when Fitbit has enough data,
+// its sleep consistency details.
+type SleepGoal struct {
+	// Goal is nil when the user has never set a sleep goal.
+	Goal        *SleepGoalTarget
+	Consistency *SleepConsistency
+}
+
+type SleepGoalTarget struct {
+	// MinDuration is the target time asleep, in minutes.
+	MinDuration int
+	Bedtime     string // 23:00
+	WakeupTime  string // 07:00
+	UpdatedOn   time.Time
+}
+
+type SleepConsistency struct {
+	FlowID                  int     `json:"flowId"`
+	AwakeRestlessPercentage float64 `json:"awakeRestlessPercentage"`
+	RecommendedSleepGoal    int     `json:"recommendedSleepGoal"`
+	TypicalDuration         int     `json:"typicalDuration"`
+	TypicalWakeupTime       string  `json:"typicalWakeupTime"`
+}
+
+func (g *SleepGoal) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Consistency *SleepConsistency `json:"consistency"`
+		Goal        *struct {
+			MinDuration *int   `json:"minDuration"`
+			Bedtime     string `json:"bedtime"`
+			WakeupTime  string `json:"wakeupTime"`
+			UpdatedOn   string `json:"updatedOn"`
+		} `json:"goal"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	goal := SleepGoal{Consistency: raw.Consistency}
+	if raw.Goal != nil && raw.Goal.MinDuration != nil {
+		goal.Goal = &SleepGoalTarget{
+			MinDuration: *raw.Goal.MinDuration,
+			Bedtime:     raw.Goal.Bedtime,
+			WakeupTime:  raw.Goal.WakeupTime,
+		}
+		if raw.Goal.UpdatedOn != "" {
+			updated, err := time.Parse(time.RFC3339, raw.Goal.UpdatedOn)
+			if err != nil {
+				return err
+			}
+			goal.Goal.UpdatedOn = updated
+		}
+	}
+	*g = goal
+	return nil
+}
+
+// SleepGoal returns the user's sleep goal.
+func (c *Client) SleepGoal(ctx context.Context) (SleepGoal, error) {
+	var goal SleepGoal
+	err := c.getVersion(ctx, apiVersion1_2, "/user/-/sleep/goal.json", &goal)
+	return goal, err
+}
+
+// Bounds UpdateSleepGoal accepts; Fitbit answers values far outside
+// them with an unhelpful 400.
+const (
+	minSleepGoal = time.Hour
+	maxSleepGoal = 16 * time.Hour
+)
+
+// UpdateSleepGoal sets the user's sleep goal to minDuration asleep.
+// minDuration must be a whole number of minutes between 1 and 16 hours.
+func (c *Client) UpdateSleepGoal(ctx context.Context, minDuration time.Duration) (SleepGoal, error) {
+	var goal SleepGoal
+	if minDuration%time.Minute != 0 {
+		return goal, fmt.Errorf("fitbit: sleep goal %s is not a whole number of minutes", minDuration)
+	}
+	if minDuration < minSleepGoal || minDuration > maxSleepGoal {
+		return goal, fmt.Errorf(
+			"fitbit: sleep goal %s must be between %s and %s",
+			minDuration,
+			minSleepGoal,
+			maxSleepGoal,
+		)
+	}
+
+	params := url.Values{
+		"minDuration": {strconv.Itoa(int(minDuration / time.Minute))},
+	}
+	err := c.postForm(ctx, apiVersion1_2, "/user/-/sleep/goal.json", params, &goal)
+	return goal, err
+}
+
+// maxSleepSeriesRangeDays is the longest span Fitbit allows for a
+// single sleep time series range request.
+const maxSleepSeriesRangeDays = 1095
+
+// SleepResource is a sleep time series resource.
+type SleepResource string
+
+const (
+	SleepResourceStartTime           SleepResource = "sleep/startTime"
+	SleepResourceTimeInBed           SleepResource = "sleep/timeInBed"
+	SleepResourceMinutesAsleep       SleepResource = "sleep/minutesAsleep"
+	SleepResourceMinutesAwake        SleepResource = "sleep/minutesAwake"
+	SleepResourceAwakeningsCount     SleepResource = "sleep/awakeningsCount"
+	SleepResourceMinutesToFallAsleep SleepResource = "sleep/minutesToFallAsleep"
+	SleepResourceMinutesAfterWakeup  SleepResource = "sleep/minutesAfterWakeup"
+	SleepResourceEfficiency          SleepResource = "sleep/efficiency"
+)
+
+// SleepSeriesPoint is one day of a sleep time series. Every resource
+// but SleepResourceStartTime is numeric and sets Value; the start time
+// resource sets StartTime instead, leaving it nil for days without
+// sleep.
+type SleepSeriesPoint struct {
+	DateTime  Date
+	Value     float64
+	StartTime *ClockTime
+}
+
+func sleepSeries(resource SleepResource, points []rawSeriesPoint) ([]SleepSeriesPoint, error) {
+	series := make([]SleepSeriesPoint, len(points))
+	for i, p := range points {
+		series[i].DateTime = p.DateTime
+		if resource != SleepResourceStartTime {
+			v, err := p.float()
+			if err != nil {
+				return nil, err
+			}
+			series[i].Value = v
+			continue
+		}
+
+		if p.Value == "" {
+			continue
+		}
+		start, err := ParseClockTime(string(p.Value))
+		if err != nil {
+			return nil, fmt.Errorf("fitbit: invalid %s value %q for %s", resource, p.Value, p.DateTime)
+		}
+		series[i].StartTime = &start
+	}
+	return series, nil
+}
+
+// SleepTimeSeries returns the (v1) time series of resource for period
+// ending on date. These are much cheaper than fetching full sleep logs
+// for long spans.
+func (c *Client) SleepTimeSeries(ctx context.Context, resource SleepResource, date Date, period Period) ([]SleepSeriesPoint, error) {
+	points, err := c.timeSeries(ctx, string(resource), date, period)
+	if err != nil {
+		return nil, err
+	}
+	return sleepSeries(resource, points)
+}
+
+// SleepTimeSeriesRange returns the (v1) time series of resource between
+// start and end, inclusive, fetching spans over Fitbit's 1095 day cap in
+// chunks.
+func (c *Client) SleepTimeSeriesRange(ctx context.Context, resource SleepResource, start, end Date) ([]SleepSeriesPoint, error) {
+	points, err := c.timeSeriesRange(ctx, string(resource), start, end, maxSleepSeriesRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	return sleepSeries(resource, points)
+}
@@ -0,0 +1,443 @@
+package fitbit
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSleep fakes Fitbit's sleep log endpoints, rejecting logs that
+// overlap existing ones as Fitbit does.
+type fakeSleep struct {
+	// overlap is the error body sent for an overlapping log.
+	overlap []byte
+	logs    fakeStore[int64, SleepLog]
+}
+
+func (f *fakeSleep) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1.2/user/-/sleep/date/{date}", func(w http.ResponseWriter, r *http.Request) {
+		date := strings.TrimSuffix(r.PathValue("date"), ".json")
+		writeJSON(w, http.StatusOK, SleepDay{Sleep: f.logs.list(func(l SleepLog) bool {
+			return l.DateOfSleep.String() == date
+		})})
+	})
+	mux.HandleFunc("POST /1.2/user/-/sleep.json", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, "validation", "n/a", err.Error())
+			return
+		}
+		date, err1 := ParseDate(r.PostForm.Get("date"))
+		clock, err2 := ParseClockTime(r.PostForm.Get("startTime"))
+		ms, err3 := strconv.ParseInt(r.PostForm.Get("duration"), 10, 64)
+		if errors.Join(err1, err2, err3) != nil {
+			writeError(w, http.StatusBadRequest, "validation", "n/a", "invalid sleep log")
+			return
+		}
+		start := date.In(time.UTC).Add(time.Duration(clock.Minutes()) * time.Minute)
+		end := start.Add(time.Duration(ms) * time.Millisecond)
+
+		var overlaps bool
+		l := SleepLog{
+			DateOfSleep: DateOf(end),
+			StartTime:   start.Format("2006-01-02T15:04:05.000"),
+			EndTime:     end.Format("2006-01-02T15:04:05.000"),
+			Duration:    ms,
+			LogType:     "manual",
+			Type:        SleepLogClassic,
+		}
+		f.logs.do(func(logs map[int64]SleepLog) {
+			for _, other := range logs {
+				s, _ := parseLocalDateTime(other.StartTime, time.UTC)
+				e, _ := parseLocalDateTime(other.EndTime, time.UTC)
+				overlaps = overlaps || start.Before(e) && s.Before(end)
+			}
+			if !overlaps {
+				f.logs.nextID++
+				l.LogID = f.logs.nextID
+				logs[l.LogID] = l
+			}
+		})
+		if overlaps {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(f.overlap)
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]SleepLog{"sleep": l})
+	})
+	mux.HandleFunc("DELETE /1.2/user/-/sleep/{id}", serveDelete(&f.logs, "logId", "Sleep log not found"))
+	return mux
+}
+
+func TestSleepLogLifecycle(t *testing.T) {
+	f := &fakeSleep{
+		overlap: fixture(t, filepath.Join("errors", "sleep_overlap.json")),
+	}
+	c := newTestClient(t, f.handler())
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Location = la
+
+	l, err := c.LogSleep(t.Context(), NewSleepLog{Date: Date{2021, 10, 25}, StartTime: "23:10", Duration: 7*time.Hour + 30*time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStart := time.Date(2021, 10, 25, 23, 10, 0, 0, la)
+	if l.LogID == 0 || l.Type != SleepLogClassic || l.Duration != 27000000 || !l.Start.Equal(wantStart) || !l.End.Equal(wantStart.Add(7*time.Hour+30*time.Minute)) {
+		t.Errorf("logged = %+v", l)
+	}
+
+	// Fitbit rejects a log overlapping the first.
+	_, err = c.LogSleep(t.Context(), NewSleepLog{Date: Date{2021, 10, 26}, StartTime: "05:00", Duration: 2 * time.Hour})
+	var apiErr *APIError
+	if !errors.Is(err, ErrSleepLogOverlap) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("overlapping log = %v, want ErrSleepLogOverlap", err)
+	}
+	// An adjacent one is fine.
+	nap, err := c.LogSleep(t.Context(), NewSleepLog{Date: Date{2021, 10, 26}, StartTime: "06:40", Duration: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day, err := c.SleepByDate(t.Context(), Date{2021, 10, 26})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(day.Sleep) != 2 {
+		t.Errorf("got %d logs, want 2", len(day.Sleep))
+	}
+
+	if err := c.DeleteSleepLog(t.Context(), l.LogID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteSleepLog(t.Context(), l.LogID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting it again = %v, want ErrNotFound", err)
+	}
+	day, err = c.SleepByDate(t.Context(), Date{2021, 10, 26})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(day.Sleep) != 1 || day.Sleep[0].LogID != nap.LogID {
+		t.Errorf("logs after delete = %+v, want only the nap", day.Sleep)
+	}
+	// With the first log gone, the previously overlapping one fits.
+	if _, err := c.LogSleep(t.Context(), NewSleepLog{Date: Date{2021, 10, 26}, StartTime: "05:00", Duration: time.Hour}); err != nil {
+		t.Errorf("log after delete = %v", err)
+	}
+}
+
+func TestLogSleepOtherValidationError(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusBadRequest, "validation", "duration", "Sleep log overlaps the maximum duration")
+	}))
+	_, err := c.LogSleep(t.Context(), NewSleepLog{Date: Date{2021, 10, 25}, StartTime: "23:10", Duration: time.Hour})
+	var apiErr *APIError
+	if errors.Is(err, ErrSleepLogOverlap) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v, want a 400 other than ErrSleepLogOverlap", err)
+	}
+}
+
+func TestUpdateSleepGoal(t *testing.T) {
+	rec := &requestRecorder{Response: `{"goal":{"minDuration":450,"updatedOn":"2021-10-25T15:04:05.000Z"}}`}
+	c := newTestClient(t, rec)
+
+	goal, err := c.UpdateSleepGoal(t.Context(), 7*time.Hour+30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goal.Goal == nil || goal.Goal.MinDuration != 450 {
+		t.Errorf("goal = %+v", goal.Goal)
+	}
+	req := rec.last(t)
+	form, err := url.ParseQuery(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "POST" || req.URL.Path != "/1.2/user/-/sleep/goal.json" || form.Get("minDuration") != "450" || len(form) != 1 {
+		t.Errorf("sent %s %s with %v, want POST /1.2/user/-/sleep/goal.json with minDuration=450", req.Method, req.URL.Path, form)
+	}
+
+	for _, d := range []time.Duration{90 * time.Second, 7*time.Hour + 30*time.Second, 59 * time.Minute, 16*time.Hour + time.Minute, 0, -time.Hour} {
+		if _, err := c.UpdateSleepGoal(t.Context(), d); err == nil {
+			t.Errorf("UpdateSleepGoal(%s) succeeded", d)
+		}
+	}
+	if _, err := c.UpdateSleepGoal(t.Context(), 90*time.Second); err == nil || !strings.Contains(err.Error(), "whole number of minutes") {
+		t.Errorf("UpdateSleepGoal(90s) = %v, want it rejected for not being whole minutes", err)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.reqs) != 1 {
+		t.Errorf("sent %d requests, want 1", len(rec.reqs))
+	}
+}
+
+// nightLog returns a minimal log of a night's sleep starting at 23:00
+// the day before date.
+func nightLog(id int64, date Date) map[string]interface{} {
+	return map[string]interface{}{
+		"logId":       id,
+		"dateOfSleep": date,
+		"startTime":   date.AddDays(-1).String() + "T23:00:00.000",
+		"endTime":     date.String() + "T07:00:00.000",
+		"isMainSleep": true,
+	}
+}
+
+func TestSleepRange(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1.2/user/-/sleep/date/{start}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		start, err1 := ParseDate(r.PathValue("start"))
+		end, err2 := ParseDate(strings.TrimSuffix(r.PathValue("file"), ".json"))
+		if err1 != nil || err2 != nil {
+			http.Error(w, "bad range", http.StatusBadRequest)
+			return
+		}
+		// Every 10th night, newest first, as Fitbit sends them.
+		logs := []map[string]interface{}{}
+		for d := end; !d.Before(start); d = d.AddDays(-1) {
+			if n := (Date{2021, 1, 1}).DaysUntil(d); n%10 == 0 {
+				logs = append(logs, nightLog(int64(n), d))
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"sleep": logs})
+	})
+	c := newTestClient(t, mux)
+	c.Location = time.UTC
+
+	logs, err := c.SleepRange(t.Context(), Date{2021, 1, 1}, Date{2021, 5, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRequests := []string{
+		"/1.2/user/-/sleep/date/2021-01-01/2021-04-10.json",
+		"/1.2/user/-/sleep/date/2021-04-11/2021-05-01.json",
+	}
+	if !equalStrings(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+	if len(logs) != 13 {
+		t.Fatalf("got %d logs, want 13", len(logs))
+	}
+	for i, l := range logs {
+		date := (Date{2021, 1, 1}).AddDays(10 * i)
+		if l.LogID != int64(10*i) || l.DateOfSleep != date || !l.Start.Equal(date.In(time.UTC).Add(-time.Hour)) {
+			t.Errorf("logs[%d] = %d of %s starting %v, want %d of %s", i, l.LogID, l.DateOfSleep, l.Start, 10*i, date)
+		}
+	}
+}
+
+func TestSleepLogsPages(t *testing.T) {
+	// 7 nights from the 1st, listed 3 to a page.
+	mux := http.NewServeMux()
+	var pageQueries []string
+	mux.HandleFunc("GET /1.2/user/-/sleep/list.json", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		pageQueries = append(pageQueries, r.URL.RawQuery)
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if q.Get("afterDate") != "2021-10-01" || q.Get("sort") != "asc" || limit != 3 {
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		logs := []map[string]interface{}{}
+		for i := offset; i < offset+limit && i < 7; i++ {
+			logs = append(logs, nightLog(int64(i), Date{2021, 10, 2 + i}))
+		}
+		pagination := Pagination{AfterDate: "2021-10-01", Limit: limit, Offset: offset, Sort: "asc"}
+		if offset+limit < 7 {
+			next := url.Values{"afterDate": {"2021-10-01"}, "sort": {"asc"}, "limit": {"3"}, "offset": {strconv.Itoa(offset + limit)}}
+			pagination.Next = "https://api.fitbit.com/1.2/user/-/sleep/list.json?" + next.Encode()
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"sleep": logs, "pagination": pagination})
+	})
+	c := newTestClient(t, mux)
+	c.Location = time.UTC
+
+	first, err := c.SleepLogs(t.Context(), ListOptions{AfterDate: Date{2021, 10, 1}, Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	pages := 0
+	err = EachPage(t.Context(), first, func(p Pager) error {
+		pages++
+		for _, l := range p.(*SleepLogPage).Sleep {
+			ids = append(ids, l.LogID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 3 || !reflect.DeepEqual(ids, []int64{0, 1, 2, 3, 4, 5, 6}) {
+		t.Errorf("got %d pages with logs %v, want 3 pages with logs 0 through 6", pages, ids)
+	}
+	if len(pageQueries) != 3 || !strings.Contains(pageQueries[2], "offset=6") {
+		t.Errorf("page queries = %v", pageQueries)
+	}
+
+	last, err := first.Next(t.Context())
+	if err == nil {
+		last, err = last.Next(t.Context())
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := last.Next(t.Context()); !errors.Is(err, ErrNoMorePages) {
+		t.Errorf("Next after the last page = %v, want ErrNoMorePages", err)
+	}
+
+	for _, opts := range []ListOptions{
+		{Limit: 3},
+		{AfterDate: Date{2021, 10, 1}, BeforeDate: Date{2021, 11, 1}, Limit: 3},
+		{AfterDate: Date{2021, 10, 1}, Limit: 101},
+		{AfterDate: Date{2021, 10, 1}, Limit: 3, Sort: SortDescending},
+	} {
+		if _, err := c.SleepLogs(t.Context(), opts); err == nil {
+			t.Errorf("SleepLogs(%+v) succeeded", opts)
+		}
+	}
+}
+
+func TestSleepGoal(t *testing.T) {
+	for _, tt := range []struct {
+		name, body string
+		want       *SleepGoalTarget
+		wantFlow   int // 0 for no consistency
+	}{
+		{
+			"set",
+			`{"consistency":{"awakeRestlessPercentage":0.08,"flowId":2,"recommendedSleepGoal":480,"typicalDuration":455,"typicalWakeupTime":"06:55"},
+			  "goal":{"bedtime":"23:00","minDuration":450,"updatedOn":"2021-10-25T15:04:05.000Z","wakeupTime":"07:00"}}`,
+			&SleepGoalTarget{MinDuration: 450, Bedtime: "23:00", WakeupTime: "07:00", UpdatedOn: time.Date(2021, 10, 25, 15, 4, 5, 0, time.UTC)},
+			2,
+		},
+		{"never set", `{"goal":{"bedtime":"","wakeupTime":""}}`, nil, 0},
+		{"no goal", `{}`, nil, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /1.2/user/-/sleep/goal.json", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			})
+			c := newTestClient(t, mux)
+
+			goal, err := c.SleepGoal(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g := goal.Goal; (g == nil) != (tt.want == nil) || g != nil && (g.MinDuration != tt.want.MinDuration ||
+				g.Bedtime != tt.want.Bedtime || g.WakeupTime != tt.want.WakeupTime || !g.UpdatedOn.Equal(tt.want.UpdatedOn)) {
+				t.Errorf("Goal = %+v, want %+v", g, tt.want)
+			}
+			if tt.wantFlow == 0 {
+				if goal.Consistency != nil {
+					t.Errorf("Consistency = %+v, want nil", goal.Consistency)
+				}
+			} else if c := goal.Consistency; c == nil || c.FlowID != tt.wantFlow || c.RecommendedSleepGoal != 480 || c.TypicalWakeupTime != "06:55" {
+				t.Errorf("Consistency = %+v", c)
+			}
+		})
+	}
+}
+
+func TestSleepTimeSeries(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/sleep/minutesAsleep/date/2021-10-27/7d.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sleep-minutesAsleep":[{"dateTime":"2021-10-25","value":"412"},{"dateTime":"2021-10-26","value":0},{"dateTime":"2021-10-27","value":"388"}]}`))
+	})
+	mux.HandleFunc("GET /1/user/-/sleep/startTime/date/2021-10-27/7d.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sleep-startTime":[{"dateTime":"2021-10-25","value":"23:10"},{"dateTime":"2021-10-26","value":""},{"dateTime":"2021-10-27","value":"00:45"}]}`))
+	})
+	mux.HandleFunc("GET /1/user/-/sleep/startTime/date/2021-10-28/1d.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sleep-startTime":[{"dateTime":"2021-10-28","value":"late"}]}`))
+	})
+	c := newTestClient(t, mux)
+
+	asleep, err := c.SleepTimeSeries(t.Context(), SleepResourceMinutesAsleep, Date{2021, 10, 27}, Period7Days)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SleepSeriesPoint{{Date{2021, 10, 25}, 412, nil}, {Date{2021, 10, 26}, 0, nil}, {Date{2021, 10, 27}, 388, nil}}
+	if !reflect.DeepEqual(asleep, want) {
+		t.Errorf("minutesAsleep = %+v, want %+v", asleep, want)
+	}
+
+	starts, err := c.SleepTimeSeries(t.Context(), SleepResourceStartTime, Date{2021, 10, 27}, Period7Days)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []SleepSeriesPoint{
+		{DateTime: Date{2021, 10, 25}, StartTime: &ClockTime{23, 10}},
+		// No sleep that night.
+		{DateTime: Date{2021, 10, 26}},
+		{DateTime: Date{2021, 10, 27}, StartTime: &ClockTime{0, 45}},
+	}
+	if !reflect.DeepEqual(starts, want) {
+		t.Errorf("startTime = %+v, want %+v", starts, want)
+	}
+
+	if _, err := c.SleepTimeSeries(t.Context(), SleepResourceStartTime, Date{2021, 10, 28}, Period1Day); err == nil || !strings.Contains(err.Error(), `"late"`) {
+		t.Errorf("malformed start time = %v, want an error naming it", err)
+	}
+}
+
+func TestSleepTimeSeriesRange(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/sleep/efficiency/date/{start}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		start, _ := ParseDate(r.PathValue("start"))
+		end, _ := ParseDate(strings.TrimSuffix(r.PathValue("file"), ".json"))
+		writeJSON(w, http.StatusOK, map[string][]map[string]interface{}{"sleep-efficiency": {
+			{"dateTime": start, "value": "91"},
+			{"dateTime": end, "value": "88"},
+		}})
+	})
+	c := newTestClient(t, mux)
+
+	start, end := Date{2019, 1, 1}, Date{2022, 1, 10}
+	points, err := c.SleepTimeSeriesRange(t.Context(), SleepResourceEfficiency, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	split := start.AddDays(maxSleepSeriesRangeDays)
+	wantRequests := []string{
+		"/1/user/-/sleep/efficiency/date/2019-01-01/" + split.AddDays(-1).String() + ".json",
+		"/1/user/-/sleep/efficiency/date/" + split.String() + "/2022-01-10.json",
+	}
+	if !equalStrings(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+	want := []SleepSeriesPoint{{start, 91, nil}, {split.AddDays(-1), 88, nil}, {split, 91, nil}, {end, 88, nil}}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("points = %+v, want %+v", points, want)
+	}
+}
@@ -0,0 +1,248 @@
+package fitbit
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// SleepWeek summarizes the nights of one week.
+type SleepWeek struct {
+	// Start is the first day of the week.
+	Start Date
+	// Nights is how many nights in the week have a sleep log. The
+	// averages are over those nights only, and are zero (or nil) when
+	// there are none.
+	Nights           int
+	AvgMinutesAsleep float64
+	AvgEfficiency    float64
+	AvgBedtime       *ClockTime
+	AvgWakeTime      *ClockTime
+}
+
+// sleepNight is one dateOfSleep's worth of logs, reduced to what the
+// weekly summaries need.
+type sleepNight struct {
+	minutesAsleep int
+	main          SleepLog
+}
+
+// WeeklySleep averages logs by week, beginning on weekStart. Bedtimes
+// and wake times are averaged on the clock face, and empty weeks between
+// logged ones have Nights set to 0.
+func WeeklySleep(logs []SleepLog, weekStart time.Weekday) []SleepWeek {
+	nights := map[Date]*sleepNight{}
+	for _, l := range logs {
+		n, ok := nights[l.DateOfSleep]
+		if !ok {
+			n = &sleepNight{main: l}
+			nights[l.DateOfSleep] = n
+		}
+		n.minutesAsleep += l.MinutesAsleep
+		if isMoreMainSleep(l, n.main) {
+			n.main = l
+		}
+	}
+	if len(nights) == 0 {
+		return nil
+	}
+
+	byWeek := map[Date][]*sleepNight{}
+	var first, last Date
+	for date, n := range nights {
+		week := weekOf(date, weekStart)
+		byWeek[week] = append(byWeek[week], n)
+		if first.IsZero() || week.Before(first) {
+			first = week
+		}
+		if week.After(last) {
+			last = week
+		}
+	}
+
+	var weeks []SleepWeek
+	for week := first; !week.After(last); week = week.AddDays(7) {
+		weeks = append(weeks, summarizeSleepWeek(week, byWeek[week]))
+	}
+	return weeks
+}
+
+// WeeklySleepRange fetches the sleep logs between start and end with
+// SleepRange and summarizes them with WeeklySleep.
+func (c *Client) WeeklySleepRange(ctx context.Context, start, end Date, weekStart time.Weekday) ([]SleepWeek, error) {
+	logs, err := c.SleepRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return WeeklySleep(logs, weekStart), nil
+}
+
+// isMoreMainSleep reports whether l beats cur as a night's main sleep.
+func isMoreMainSleep(l, cur SleepLog) bool {
+	if l.IsMainSleep != cur.IsMainSleep {
+		return l.IsMainSleep
+	}
+	return l.Duration > cur.Duration
+}
+
+// weekOf returns the first day of the week, starting on weekStart, that
+// d falls in.
+func weekOf(d Date, weekStart time.Weekday) Date {
+	offset := (int(d.In(time.UTC).Weekday()) - int(weekStart) + 7) % 7
+	return d.AddDays(-offset)
+}
+
+func summarizeSleepWeek(start Date, nights []*sleepNight) SleepWeek {
+	week := SleepWeek{Start: start, Nights: len(nights)}
+	if len(nights) == 0 {
+		return week
+	}
+
+	var asleep, efficiency float64
+	var bedtimes, waketimes []float64
+	for _, n := range nights {
+		asleep += float64(n.minutesAsleep)
+		efficiency += float64(n.main.Efficiency)
+		if m, ok := clockMinutes(n.main.StartTime); ok {
+			bedtimes = append(bedtimes, m)
+		}
+		if m, ok := clockMinutes(n.main.EndTime); ok {
+			waketimes = append(waketimes, m)
+		}
+	}
+	week.AvgMinutesAsleep = asleep / float64(len(nights))
+	week.AvgEfficiency = efficiency / float64(len(nights))
+	week.AvgBedtime = circularMeanClock(bedtimes)
+	week.AvgWakeTime = circularMeanClock(waketimes)
+	return week
+}
+
+// clockMinutes returns the time of day of a sleep log timestamp, in
+// minutes since midnight.
+func clockMinutes(timestamp string) (float64, bool) {
+	t, err := parseLocalDateTime(timestamp, time.UTC)
+	if err != nil {
+		return 0, false
+	}
+	return float64(t.Hour()*60+t.Minute()) + float64(t.Second())/60, true
+}
+
+const minutesPerDay = 24 * 60
+
+// circularMeanClock averages minutes since midnight on a clock face,
+// or returns nil if they cancel out.
+func circularMeanClock(minutes []float64) *ClockTime {
+	var sin, cos float64
+	for _, m := range minutes {
+		angle := 2 * math.Pi * m / minutesPerDay
+		sin += math.Sin(angle)
+		cos += math.Cos(angle)
+	}
+	if math.Hypot(sin, cos) < 1e-9 {
+		return nil
+	}
+
+	mean := math.Atan2(sin, cos) * minutesPerDay / (2 * math.Pi)
+	m := int(math.Floor(mean+0.5)) % minutesPerDay
+	if m < 0 {
+		m += minutesPerDay
+	}
+	return &ClockTime{Hour: m / 60, Minute: m % 60}
+}
+
+// SleepSegment is a contiguous period of a single sleep level.
+type SleepSegment struct {
+	Start    time.Time
+	Duration time.Duration
+	Level    SleepLevel
+}
+
+// End returns the time the segment ends.
+func (s SleepSegment) End() time.Time {
+	return s.Start.Add(s.Duration)
+}
+
+// MergedTimeline returns the log's levels as sorted, non-overlapping
+// segments, with the short wakes of Levels.ShortData cut into
+// Levels.Data. Times are in the location of l.Start.
+func (l SleepLog) MergedTimeline() ([]SleepSegment, error) {
+	loc := time.UTC
+	if !l.Start.IsZero() {
+		loc = l.Start.Location()
+	}
+
+	data, err := sleepSegments(l.Levels.Data, loc)
+	if err != nil {
+		return nil, err
+	}
+	short, err := sleepSegments(l.Levels.ShortData, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the night at every segment boundary and pick a level for
+	// each resulting piece, short data taking precedence.
+	var bounds []time.Time
+	for _, segs := range [][]SleepSegment{data, short} {
+		for _, s := range segs {
+			bounds = append(bounds, s.Start, s.End())
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+
+	var merged []SleepSegment
+	di, si := 0, 0
+	for i := 0; i+1 < len(bounds); i++ {
+		start, end := bounds[i], bounds[i+1]
+		if !start.Before(end) {
+			continue
+		}
+
+		for di < len(data) && !data[di].End().After(start) {
+			di++
+		}
+		for si < len(short) && !short[si].End().After(start) {
+			si++
+		}
+
+		var level SleepLevel
+		switch {
+		case si < len(short) && !short[si].Start.After(start):
+			level = short[si].Level
+		case di < len(data) && !data[di].Start.After(start):
+			level = data[di].Level
+		default:
+			continue
+		}
+
+		if n := len(merged); n > 0 && merged[n-1].Level == level && merged[n-1].End().Equal(start) {
+			merged[n-1].Duration += end.Sub(start)
+			continue
+		}
+		merged = append(merged, SleepSegment{Start: start, Duration: end.Sub(start), Level: level})
+	}
+	return merged, nil
+}
+
+// sleepSegments converts level data into segments sorted by start.
+func sleepSegments(data []SleepLevelData, loc *time.Location) ([]SleepSegment, error) {
+	segs := make([]SleepSegment, 0, len(data))
+	for _, d := range data {
+		start, err := parseLocalDateTime(d.DateTime, loc)
+		if err != nil {
+			return nil, err
+		}
+		if d.Seconds <= 0 {
+			continue
+		}
+		segs = append(segs, SleepSegment{
+			Start:    start,
+			Duration: time.Duration(d.Seconds) * time.Second,
+			Level:    d.Level,
+		})
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].Start.Before(segs[j].Start) })
+	return segs, nil
+}
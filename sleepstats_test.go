@@ -0,0 +1,264 @@
+package fitbit
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// night returns a main sleep log for date from start to end, given as
+// local timestamps.
+func night(date Date, start, end string, asleep, efficiency int) SleepLog {
+	return SleepLog{
+		DateOfSleep:   date,
+		StartTime:     start,
+		EndTime:       end,
+		IsMainSleep:   true,
+		MinutesAsleep: asleep,
+		Efficiency:    efficiency,
+	}
+}
+
+func TestWeeklySleepCircularMean(t *testing.T) {
+	// The week of Monday 2020-02-17.
+	logs := []SleepLog{
+		night(Date{2020, 2, 18}, "2020-02-17T23:30:00.000", "2020-02-18T07:00:00.000", 400, 90),
+		night(Date{2020, 2, 19}, "2020-02-19T00:30:00.000", "2020-02-19T08:00:00.000", 420, 94),
+		// A nap counts towards minutes asleep only.
+		{DateOfSleep: Date{2020, 2, 19}, StartTime: "2020-02-19T14:00:00.000", EndTime: "2020-02-19T14:40:00.000", MinutesAsleep: 40, Efficiency: 50},
+	}
+	weeks := WeeklySleep(logs, time.Monday)
+	if len(weeks) != 1 {
+		t.Fatalf("got %d weeks, want 1", len(weeks))
+	}
+	w := weeks[0]
+	if w.Start != (Date{2020, 2, 17}) || w.Nights != 2 {
+		t.Errorf("Start, Nights = %v, %d, want 2020-02-17, 2", w.Start, w.Nights)
+	}
+	if w.AvgMinutesAsleep != 430 || w.AvgEfficiency != 92 {
+		t.Errorf("AvgMinutesAsleep, AvgEfficiency = %v, %v, want 430, 92", w.AvgMinutesAsleep, w.AvgEfficiency)
+	}
+	if w.AvgBedtime == nil || *w.AvgBedtime != (ClockTime{Hour: 0, Minute: 0}) {
+		t.Errorf("AvgBedtime = %v, want 00:00", w.AvgBedtime)
+	}
+	if w.AvgWakeTime == nil || *w.AvgWakeTime != (ClockTime{Hour: 7, Minute: 30}) {
+		t.Errorf("AvgWakeTime = %v, want 07:30", w.AvgWakeTime)
+	}
+}
+
+func TestWeeklySleepEmptyWeek(t *testing.T) {
+	logs := []SleepLog{
+		night(Date{2020, 2, 18}, "2020-02-17T23:00:00.000", "2020-02-18T07:00:00.000", 450, 95),
+		night(Date{2020, 3, 3}, "2020-03-02T22:00:00.000", "2020-03-03T06:00:00.000", 460, 93),
+	}
+	weeks := WeeklySleep(logs, time.Monday)
+	if len(weeks) != 3 {
+		t.Fatalf("got %d weeks, want 3", len(weeks))
+	}
+	empty := weeks[1]
+	if empty.Start != (Date{2020, 2, 24}) || empty.Nights != 0 {
+		t.Errorf("Start, Nights = %v, %d, want 2020-02-24, 0", empty.Start, empty.Nights)
+	}
+	if empty.AvgMinutesAsleep != 0 || empty.AvgEfficiency != 0 || empty.AvgBedtime != nil || empty.AvgWakeTime != nil {
+		t.Errorf("empty week has averages: %+v", empty)
+	}
+
+	if weeks := WeeklySleep(nil, time.Monday); weeks != nil {
+		t.Errorf("WeeklySleep(nil) = %v, want nil", weeks)
+	}
+}
+
+func TestCircularMeanClock(t *testing.T) {
+	for _, tt := range []struct {
+		minutes []float64
+		want    *ClockTime
+	}{
+		{[]float64{23*60 + 30, 30}, &ClockTime{Hour: 0, Minute: 0}},
+		{[]float64{22 * 60, 23 * 60}, &ClockTime{Hour: 22, Minute: 30}},
+		{[]float64{23*60 + 50}, &ClockTime{Hour: 23, Minute: 50}},
+		// Opposite times leave no mean.
+		{[]float64{6 * 60, 18 * 60}, nil},
+		{nil, nil},
+	} {
+		got := circularMeanClock(tt.minutes)
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("circularMeanClock(%v) = %v, want %v", tt.minutes, got, tt.want)
+		}
+	}
+}
+
+func TestMergedTimeline(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, serveFixture(t, "sleep_mixed.json"))
+	c.Location = la
+	day, err := c.SleepByDate(t.Context(), Date{2020, 2, 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(day.Sleep) != 2 {
+		t.Fatalf("got %d logs, want 2", len(day.Sleep))
+	}
+
+	at := func(day, hour, min, sec int) time.Time {
+		return time.Date(2020, 2, day, hour, min, sec, 0, la)
+	}
+	for _, tt := range []struct {
+		name string
+		log  SleepLog
+		typ  SleepLogType
+		want []SleepSegment
+	}{{
+		// Adjacent light segments are joined, a short wake within the
+		// first wake is absorbed by it, one inside deep splits it, and
+		// one across the deep/rem boundary cuts into both.
+		name: "stages",
+		log:  day.Sleep[0],
+		typ:  SleepLogStages,
+		want: []SleepSegment{
+			{at(20, 23, 0, 0), 5 * time.Minute, SleepLevelWake},
+			{at(20, 23, 5, 0), 15 * time.Minute, SleepLevelLight},
+			{at(20, 23, 20, 0), 5 * time.Minute, SleepLevelDeep},
+			{at(20, 23, 25, 0), time.Minute, SleepLevelWake},
+			{at(20, 23, 26, 0), 13*time.Minute + 30*time.Second, SleepLevelDeep},
+			{at(20, 23, 39, 30), 90 * time.Second, SleepLevelWake},
+			{at(20, 23, 41, 0), 9 * time.Minute, SleepLevelREM},
+		},
+	}, {
+		// Classic logs have no short data, and their gaps are kept.
+		name: "classic",
+		log:  day.Sleep[1],
+		typ:  SleepLogClassic,
+		want: []SleepSegment{
+			{at(21, 13, 0, 0), 10 * time.Minute, SleepLevelAsleep},
+			{at(21, 13, 10, 0), 2 * time.Minute, SleepLevelRestless},
+			{at(21, 13, 12, 0), 5 * time.Minute, SleepLevelAsleep},
+			{at(21, 13, 30, 0), time.Minute, SleepLevelAwake},
+		},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.log.Levels.Type != tt.typ {
+				t.Errorf("Levels.Type = %q, want %q", tt.log.Levels.Type, tt.typ)
+			}
+			got, err := tt.log.MergedTimeline()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d segments, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i, s := range got {
+				w := tt.want[i]
+				if !s.Start.Equal(w.Start) || s.Duration != w.Duration || s.Level != w.Level {
+					t.Errorf("segment %d = %v %v %s, want %v %v %s", i, s.Start, s.Duration, s.Level, w.Start, w.Duration, w.Level)
+				}
+				if s.Start.Location() != la {
+					t.Errorf("segment %d is in %v, want %v", i, s.Start.Location(), la)
+				}
+			}
+		})
+	}
+}
+
+// randomLevels returns stages data for a night from start, with gaps
+// and short wakes at random.
+func randomLevels(r *rand.Rand, start time.Time) SleepLevels {
+	var levels SleepLevels
+	stages := []SleepLevel{SleepLevelWake, SleepLevelLight, SleepLevelDeep, SleepLevelREM}
+	at := start
+	for range 1 + r.IntN(30) {
+		if r.IntN(8) == 0 {
+			at = at.Add(time.Duration(1+r.IntN(600)) * time.Second)
+		}
+		secs := 30 * (1 + r.IntN(60))
+		levels.Data = append(levels.Data, SleepLevelData{
+			DateTime: at.Format("2006-01-02T15:04:05.000"),
+			Level:    stages[r.IntN(len(stages))],
+			Seconds:  secs,
+		})
+		at = at.Add(time.Duration(secs) * time.Second)
+	}
+
+	span := int(at.Sub(start)/time.Second) + 300
+	var next int
+	for next < span {
+		next += r.IntN(1200)
+		secs := 30 * (1 + r.IntN(5))
+		levels.ShortData = append(levels.ShortData, SleepLevelData{
+			DateTime: start.Add(time.Duration(next) * time.Second).Format("2006-01-02T15:04:05.000"),
+			Level:    SleepLevelWake,
+			Seconds:  secs,
+		})
+		next += secs
+	}
+	// Fitbit's order isn't guaranteed.
+	r.Shuffle(len(levels.Data), func(i, j int) { levels.Data[i], levels.Data[j] = levels.Data[j], levels.Data[i] })
+	return levels
+}
+
+// levelsBySecond returns the level of each second from start that the
+// data covers, short data taking precedence.
+func levelsBySecond(t *testing.T, levels SleepLevels, start time.Time) map[int]SleepLevel {
+	t.Helper()
+	bySecond := make(map[int]SleepLevel)
+	for _, data := range [][]SleepLevelData{levels.Data, levels.ShortData} {
+		for _, d := range data {
+			at, err := time.ParseInLocation("2006-01-02T15:04:05.000", d.DateTime, start.Location())
+			if err != nil {
+				t.Fatal(err)
+			}
+			from := int(at.Sub(start) / time.Second)
+			for s := from; s < from+d.Seconds; s++ {
+				bySecond[s] = d.Level
+			}
+		}
+	}
+	return bySecond
+}
+
+func TestMergedTimelineRandom(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewPCG(3, 4))
+	for i := range 300 {
+		start := time.Date(2021, 3, 13, 21, 0, 0, 0, la).Add(time.Duration(r.IntN(48*60)) * time.Minute)
+		log := SleepLog{Start: start, Levels: randomLevels(r, start)}
+
+		merged, err := log.MergedTimeline()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := levelsBySecond(t, log.Levels, start)
+		covered := 0
+		for j, s := range merged {
+			if s.Duration <= 0 {
+				t.Fatalf("night %d: segment %d has duration %v", i, j, s.Duration)
+			}
+			if j > 0 {
+				prev := merged[j-1]
+				if s.Start.Before(prev.End()) {
+					t.Fatalf("night %d: segment %d starts at %v, before the previous one ends at %v", i, j, s.Start, prev.End())
+				}
+				if s.Start.Equal(prev.End()) && s.Level == prev.Level {
+					t.Fatalf("night %d: segments %d and %d are both %s and weren't joined", i, j-1, j, s.Level)
+				}
+			}
+			from := int(s.Start.Sub(start) / time.Second)
+			for sec := from; sec < from+int(s.Duration/time.Second); sec++ {
+				if want[sec] != s.Level {
+					t.Fatalf("night %d: second %d is %q in segment %d, want %q", i, sec, s.Level, j, want[sec])
+				}
+			}
+			covered += int(s.Duration / time.Second)
+		}
+		// The timeline covers exactly the seconds the data does.
+		if covered != len(want) {
+			t.Fatalf("night %d: timeline covers %ds, data covers %ds", i, covered, len(want))
+		}
+	}
+}
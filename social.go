@@ -0,0 +1,116 @@
+package fitbit
+
+import (
+	"golang.org/x/net/context"
+)
+
+// Friend is one of the user's Fitbit friends.
+type Friend struct {
+	UserID      string
+	DisplayName string
+	Avatar      string
+	// Child is set for family account children.
+	Child bool
+}
+
+// personResource is a "person" of the JSON:API-like 1.1 social
+// endpoints.
+type personResource struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	Attributes struct {
+		Name   string `json:"name"`
+		Avatar string `json:"avatar"`
+		Child  bool   `json:"child"`
+	} `json:"attributes"`
+}
+
+func (p personResource) friend() Friend {
+	return Friend{
+		UserID:      p.ID,
+		DisplayName: p.Attributes.Name,
+		Avatar:      p.Attributes.Avatar,
+		Child:       p.Attributes.Child,
+	}
+}
+
+// Friends returns the user's friends.
+func (c *Client) Friends(ctx context.Context) ([]Friend, error) {
+	var resp struct {
+		Data []personResource `json:"data"`
+	}
+	if err := c.getVersion(ctx, apiVersion1_1, "/user/-/friends.json", &resp); err != nil {
+		return nil, err
+	}
+
+	friends := make([]Friend, 0, len(resp.Data))
+	for _, p := range resp.Data {
+		if p.Type == "person" {
+			friends = append(friends, p.friend())
+		}
+	}
+	return friends, nil
+}
+
+// LeaderboardEntry is one user's place on the friends step leaderboard.
+type LeaderboardEntry struct {
+	UserID string
+	Rank   int
+	// Steps is the user's step count over the last 7 days.
+	Steps int
+	// Friend is the user's profile, nil for users whose privacy settings
+	// hide it.
+	Friend *Friend
+}
+
+type rankedUserResource struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		StepRank    int `json:"step-rank"`
+		StepSummary int `json:"step-summary"`
+	} `json:"attributes"`
+	Relationships struct {
+		User struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		} `json:"user"`
+	} `json:"relationships"`
+}
+
+// Leaderboard returns the friends step leaderboard, including the user,
+// in the order Fitbit ranks it.
+func (c *Client) Leaderboard(ctx context.Context) ([]LeaderboardEntry, error) {
+	var resp struct {
+		Data     []rankedUserResource `json:"data"`
+		Included []personResource     `json:"included"`
+	}
+	if err := c.getVersion(ctx, apiVersion1_1, "/user/-/leaderboard/friends.json", &resp); err != nil {
+		return nil, err
+	}
+
+	people := make(map[string]Friend, len(resp.Included))
+	for _, p := range resp.Included {
+		if p.Type == "person" {
+			people[p.ID] = p.friend()
+		}
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(resp.Data))
+	for _, r := range resp.Data {
+		userID := r.Relationships.User.Data.ID
+		if userID == "" {
+			userID = r.ID
+		}
+		entry := LeaderboardEntry{
+			UserID: userID,
+			Rank:   r.Attributes.StepRank,
+			Steps:  r.Attributes.StepSummary,
+		}
+		if f, ok := people[userID]; ok {
+			entry.Friend = &f
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,66 @@
+package fitbit
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFriends(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1.1/user/-/friends.json", serveFixture(t, "friends.json"))
+	c := newTestClient(t, mux)
+
+	friends, err := c.Friends(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(friends) != 2 {
+		t.Fatalf("got %d friends, want 2 (the invitation isn't one): %+v", len(friends), friends)
+	}
+	if f := friends[0]; f.UserID != "GGNJL9" || f.DisplayName != "Lola M." || f.Avatar == "" || f.Child {
+		t.Errorf("friends[0] = %+v", f)
+	}
+	if f := friends[1]; f.UserID != "7XJH3C" || !f.Child {
+		t.Errorf("friends[1] = %+v", f)
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1.1/user/-/leaderboard/friends.json", serveFixture(t, "leaderboard.json"))
+	c := newTestClient(t, mux)
+
+	entries, err := c.Leaderboard(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		userID string
+		steps  int
+		name   string // "" for a hidden profile
+	}{
+		{"GGNJL9", 84214, "Lola M."},
+		// Without relationships, the ranked user's own id is the user's.
+		{"9XQ7CM", 61503, "Me"},
+		// Only a team is included under this id, so the user is hidden.
+		{"2ZPQK4", 40012, ""},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		w := want[i]
+		if e.UserID != w.userID || e.Rank != i+1 || e.Steps != w.steps {
+			t.Errorf("entries[%d] = %+v, want user %s ranked %d with %d steps", i, e, w.userID, i+1, w.steps)
+		}
+		if w.name == "" {
+			if e.Friend != nil {
+				t.Errorf("entries[%d].Friend = %+v, want nil", i, e.Friend)
+			}
+			continue
+		}
+		if e.Friend == nil || e.Friend.UserID != w.userID || e.Friend.DisplayName != w.name {
+			t.Errorf("entries[%d].Friend = %+v, want %s", i, e.Friend, w.name)
+		}
+	}
+}
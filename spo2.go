@@ -0,0 +1,129 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// SpO2 is the blood oxygen saturation summary for one night, in
+// percent.
+type SpO2 struct {
+	DateTime Date      `json:"dateTime"`
+	Value    SpO2Value `json:"value"`
+}
+
+type SpO2Value struct {
+	Avg float64 `json:"avg"`
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// SpO2ByDate returns the SpO2 summary for date. Fitbit returns an empty
+// object for nights without a reading (or users without a compatible
+// device); SpO2ByDate returns nil rather than a 0% reading in that case.
+func (c *Client) SpO2ByDate(ctx context.Context, date Date) (*SpO2, error) {
+	var resp struct {
+		SpO2
+		Value *SpO2Value `json:"value"`
+	}
+	err := c.get(ctx, fmt.Sprintf("/user/-/spo2/date/%s.json", date), &resp)
+	if err != nil || resp.Value == nil {
+		return nil, err
+	}
+
+	resp.SpO2.Value = *resp.Value
+	return &resp.SpO2, nil
+}
+
+// SpO2Range returns the SpO2 summaries between start and end, inclusive,
+// sorted by date. Ranges longer than Fitbit's 30 day cap are fetched in
+// chunks, and nights without a reading are absent from the result.
+func (c *Client) SpO2Range(ctx context.Context, start, end Date) ([]SpO2, error) {
+	var entries []SpO2
+	err := c.getWellnessRange(ctx, "spo2", start, end, func(urlStr string) error {
+		var resp []struct {
+			DateTime Date       `json:"dateTime"`
+			Value    *SpO2Value `json:"value"`
+		}
+		if err := c.get(ctx, urlStr, &resp); err != nil {
+			return err
+		}
+		for _, e := range resp {
+			if e.Value != nil {
+				entries = append(entries, SpO2{DateTime: e.DateTime, Value: *e.Value})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DateTime.Before(entries[j].DateTime)
+	})
+	return entries, nil
+}
+
+// SpO2Map indexes entries by date, which is also the dateOfSleep of the
+// sleep log for the same night.
+func SpO2Map(entries []SpO2) map[Date]SpO2 {
+	m := make(map[Date]SpO2, len(entries))
+	for _, e := range entries {
+		m[e.DateTime] = e
+	}
+	return m
+}
+
+// SpO2Point is a single intraday SpO2 reading. Minute is the time of
+// the reading, in the user's timezone (see Client.Location); readings
+// for one night routinely span midnight, so it need not fall on the
+// requested date.
+type SpO2Point struct {
+	Minute time.Time
+	Value  float64
+}
+
+func (p *SpO2Point) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Minute string  `json:"minute"`
+		Value  float64 `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	minute, err := parseLocalDateTime(raw.Minute, time.UTC)
+	if err != nil {
+		return err
+	}
+	p.Minute, p.Value = minute, raw.Value
+	return nil
+}
+
+// IntradaySpO2 returns the per-minute SpO2 readings recorded during the
+// sleep attributed to date, in the order Fitbit returns them. Nights
+// without readings return an empty slice.
+func (c *Client) IntradaySpO2(ctx context.Context, date Date) ([]SpO2Point, error) {
+	var resp struct {
+		Minutes []SpO2Point `json:"minutes"`
+	}
+	err := c.get(ctx, fmt.Sprintf("/user/-/spo2/date/%s/all.json", date), &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Minutes == nil {
+		return []SpO2Point{}, nil
+	}
+
+	times := make([]*time.Time, len(resp.Minutes))
+	for i := range resp.Minutes {
+		times[i] = &resp.Minutes[i].Minute
+	}
+	c.inLocation(ctx, times...)
+	return resp.Minutes, nil
+}
@@ -0,0 +1,62 @@
+package fitbit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIntradaySpO2AcrossMidnight(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/spo2/date/2021-10-04/all.json", serveFixture(t, "spo2_intraday.json"))
+	mux.HandleFunc("GET /1/user/-/profile.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"user":{"timezone":"Australia/Sydney"}}`))
+	})
+	c := newTestClient(t, mux)
+
+	points, err := c.IntradaySpO2(t.Context(), Date{2021, 10, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sydney, _ := time.LoadLocation("Australia/Sydney")
+	want := []SpO2Point{
+		{time.Date(2021, 10, 3, 23, 58, 0, 0, sydney), 95.7},
+		{time.Date(2021, 10, 3, 23, 59, 0, 0, sydney), 96.1},
+		{time.Date(2021, 10, 4, 0, 0, 0, 0, sydney), 95.4},
+		{time.Date(2021, 10, 4, 6, 40, 0, 0, sydney), 97.0},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(points), len(want))
+	}
+	for i, p := range points {
+		if !p.Minute.Equal(want[i].Minute) || p.Value != want[i].Value {
+			t.Errorf("points[%d] = %v %v, want %v %v", i, p.Minute, p.Value, want[i].Minute, want[i].Value)
+		}
+	}
+	if d := points[3].Minute.Sub(points[0].Minute); d != 6*time.Hour+42*time.Minute {
+		t.Errorf("night spans %v, want 6h42m", d)
+	}
+}
+
+func TestIntradaySpO2Empty(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	points, err := c.IntradaySpO2(t.Context(), Date{2021, 10, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if points == nil || len(points) != 0 {
+		t.Errorf("points = %#v, want an empty slice", points)
+	}
+}
+
+func TestSpO2ByDateNoReading(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	s, err := c.SpO2ByDate(t.Context(), Date{2021, 10, 4})
+	if err != nil || s != nil {
+		t.Errorf("SpO2ByDate = %+v, %v, want nil, nil", s, err)
+	}
+}
@@ -0,0 +1,126 @@
+package fitbit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrStateExpired matches (with errors.Is) the error StateSigner.Verify
+// returns for a state older than its TTL, which matches ErrInvalidState
+// too.
+var ErrStateExpired = errors.New("fitbit: oauth state expired")
+
+// NewState returns a cryptographically random value for the OAuth state
+// parameter.
+func NewState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// defaultStateTTL is how long a signed state stays valid unless
+// StateSigner.TTL says otherwise.
+const defaultStateTTL = 10 * time.Minute
+
+// stateNonceSize is the number of random bytes in a signed state.
+const stateNonceSize = 16
+
+// StateSigner makes OAuth state values that carry a payload, such as the
+// app's own id for the user being linked, and expire, so that verifying
+// a callback's state needs no server side storage. States are signed
+// with HMAC-SHA256 under Key.
+type StateSigner struct {
+	Key []byte
+	// TTL is how long a state stays valid; 0 means 10 minutes.
+	TTL time.Duration
+}
+
+// NewStateSigner returns a StateSigner with a random key. States it
+// signs only verify with the same signer, so a deployment with several
+// instances needs a StateSigner with a shared Key instead.
+func NewStateSigner() (*StateSigner, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &StateSigner{Key: key}, nil
+}
+
+func (s *StateSigner) mac(data []byte) []byte {
+	m := hmac.New(sha256.New, s.Key)
+	m.Write(data)
+	return m.Sum(nil)
+}
+
+func (s *StateSigner) ttl() time.Duration {
+	if s.TTL > 0 {
+		return s.TTL
+	}
+	return defaultStateTTL
+}
+
+// Sign returns a new state carrying payload.
+func (s *StateSigner) Sign(payload string) (string, error) {
+	if len(s.Key) == 0 {
+		return "", errors.New("fitbit: state signer has no key")
+	}
+	data := make([]byte, stateNonceSize+8, stateNonceSize+8+len(payload))
+	if _, err := rand.Read(data[:stateNonceSize]); err != nil {
+		return "", err
+	}
+	expiry := time.Now().Add(s.ttl()).Unix()
+	binary.BigEndian.PutUint64(data[stateNonceSize:], uint64(expiry))
+	data = append(data, payload...)
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(data) + "." + enc.EncodeToString(s.mac(data)), nil
+}
+
+// Verify checks that state was made by Sign with the same key and hasn't
+// expired, and returns its payload. The error matches ErrInvalidState,
+// and ErrStateExpired for an expired state.
+func (s *StateSigner) Verify(state string) (string, error) {
+	if len(s.Key) == 0 {
+		return "", errors.New("fitbit: state signer has no key")
+	}
+	i := strings.IndexByte(state, '.')
+	if i < 0 {
+		return "", ErrInvalidState
+	}
+	enc := base64.RawURLEncoding
+	data, err := enc.DecodeString(state[:i])
+	if err != nil || len(data) < stateNonceSize+8 {
+		return "", ErrInvalidState
+	}
+	sig, err := enc.DecodeString(state[i+1:])
+	if err != nil || !hmac.Equal(sig, s.mac(data)) {
+		return "", ErrInvalidState
+	}
+
+	expiry := int64(binary.BigEndian.Uint64(data[stateNonceSize:]))
+	if time.Now().Unix() > expiry {
+		return "", fmt.Errorf("%w: %w", ErrInvalidState, ErrStateExpired)
+	}
+	return string(data[stateNonceSize+8:]), nil
+}
+
+type statePayloadKey struct{}
+
+// StatePayload returns the payload of the state verified by
+// CallbackHandler's StateSigner, from the context of the request passed
+// to CallbackOptions.OnSuccess.
+func StatePayload(ctx context.Context) (string, bool) {
+	payload, ok := ctx.Value(statePayloadKey{}).(string)
+	return payload, ok
+}
@@ -0,0 +1,139 @@
+package fitbit
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateSignerRoundTrip(t *testing.T) {
+	s := &StateSigner{Key: []byte("key")}
+	state, err := s.Sign("user-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := s.Verify(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload != "user-42" {
+		t.Errorf("payload = %q, want user-42", payload)
+	}
+}
+
+// flipByte returns s, which is base64 (RawURLEncoding), with one bit of
+// the i-th decoded byte flipped.
+func flipByte(t *testing.T, s string, i int) string {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[i] ^= 1
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestStateSignerRejects(t *testing.T) {
+	s := &StateSigner{Key: []byte("key")}
+	state, err := s.Sign("user-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, sig, _ := strings.Cut(state, ".")
+
+	for _, tt := range []struct {
+		name  string
+		state string
+	}{
+		{"flipped signature byte", data + "." + flipByte(t, sig, 0)},
+		{"flipped payload byte", flipByte(t, data, stateNonceSize+8) + "." + sig},
+		{"flipped expiry byte", flipByte(t, data, stateNonceSize+7) + "." + sig},
+		{"no separator", data + sig},
+		{"bad base64 data", "!!!." + sig},
+		{"bad base64 signature", data + ".!!!"},
+		{"too short", "AAAA." + sig},
+		{"empty", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Verify(tt.state)
+			if !errors.Is(err, ErrInvalidState) {
+				t.Errorf("err = %v, want ErrInvalidState", err)
+			}
+			if errors.Is(err, ErrStateExpired) {
+				t.Errorf("err = %v, shouldn't match ErrStateExpired", err)
+			}
+		})
+	}
+}
+
+func TestStateSignerWrongKey(t *testing.T) {
+	state, err := (&StateSigner{Key: []byte("key")}).Sign("user-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&StateSigner{Key: []byte("other key")}).Verify(state)
+	if !errors.Is(err, ErrInvalidState) {
+		t.Errorf("err = %v, want ErrInvalidState", err)
+	}
+}
+
+func TestStateSignerExpired(t *testing.T) {
+	s := &StateSigner{Key: []byte("key")}
+	// A state as Sign makes it, but one that expired a minute ago.
+	data := make([]byte, stateNonceSize+8, stateNonceSize+8+len("user-42"))
+	binary.BigEndian.PutUint64(data[stateNonceSize:], uint64(time.Now().Add(-time.Minute).Unix()))
+	data = append(data, "user-42"...)
+	enc := base64.RawURLEncoding
+	state := enc.EncodeToString(data) + "." + enc.EncodeToString(s.mac(data))
+
+	_, err := s.Verify(state)
+	if !errors.Is(err, ErrStateExpired) || !errors.Is(err, ErrInvalidState) {
+		t.Errorf("err = %v, want ErrStateExpired and ErrInvalidState", err)
+	}
+}
+
+func TestStateSignerNoKey(t *testing.T) {
+	if _, err := (&StateSigner{}).Sign("x"); err == nil {
+		t.Error("Sign without a key succeeded")
+	}
+	if _, err := (&StateSigner{}).Verify("x.y"); err == nil {
+		t.Error("Verify without a key succeeded")
+	}
+}
+
+func TestNewStateSigner(t *testing.T) {
+	a, err := NewStateSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewStateSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := a.Sign("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Verify(state); err != nil {
+		t.Errorf("a.Verify(a.Sign()) = %v", err)
+	}
+	if _, err := b.Verify(state); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("b.Verify(a.Sign()) = %v, want ErrInvalidState", err)
+	}
+}
+
+func TestCallbackHandlerNeedsStateVerifier(t *testing.T) {
+	cfg := NewConfigSource(nil)
+	if _, err := CallbackHandler(cfg, nil, CallbackOptions{}); err == nil {
+		t.Error("CallbackHandler without States or VerifyState succeeded")
+	}
+	if _, err := CallbackHandler(cfg, nil, CallbackOptions{States: &StateSigner{}}); err == nil {
+		t.Error("CallbackHandler with a keyless StateSigner succeeded")
+	}
+	if _, err := CallbackHandler(cfg, nil, CallbackOptions{States: &StateSigner{Key: []byte("k")}}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,305 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// CollectionType is a collection of user data a subscription notifies
+// about changes to.
+type CollectionType string
+
+const (
+	CollectionActivities        CollectionType = "activities"
+	CollectionBody              CollectionType = "body"
+	CollectionFoods             CollectionType = "foods"
+	CollectionSleep             CollectionType = "sleep"
+	CollectionUserRevokedAccess CollectionType = "userRevokedAccess"
+	// CollectionAll subscribes to every collection.
+	CollectionAll CollectionType = ""
+)
+
+// Subscription is a subscription to notifications of changes to a
+// user's data.
+type Subscription struct {
+	// CollectionType is "user" for subscriptions to all collections.
+	CollectionType string `json:"collectionType"`
+	OwnerID        string `json:"ownerId"`
+	OwnerType      string `json:"ownerType"`
+	SubscriberID   string `json:"subscriberId"`
+	SubscriptionID string `json:"subscriptionId"`
+}
+
+// ErrSubscriptionConflict matches (with errors.Is) the
+// *SubscriptionConflictError CreateSubscription returns when the
+// subscription id is already in use for another user or collection.
+var ErrSubscriptionConflict = errors.New("fitbit: subscription id already in use")
+
+// SubscriptionConflictError is returned for a 409 response to
+// CreateSubscription. Existing holds the subscriptions already using
+// the id, as described by Fitbit, so the caller can decide whether to
+// delete and recreate them or reuse them.
+type SubscriptionConflictError struct {
+	Existing []Subscription
+	Err      *APIError
+}
+
+func (e *SubscriptionConflictError) Error() string {
+	if len(e.Existing) == 0 {
+		return "fitbit: subscription id already in use: " + e.Err.Error()
+	}
+	s := e.Existing[0]
+	return fmt.Sprintf(
+		"fitbit: subscription id %q already in use for collection %q of user %s",
+		s.SubscriptionID, s.CollectionType, s.OwnerID,
+	)
+}
+
+func (e *SubscriptionConflictError) Is(target error) bool {
+	return target == ErrSubscriptionConflict
+}
+
+func (e *SubscriptionConflictError) Unwrap() error {
+	return e.Err
+}
+
+// newSubscriptionConflictError parses the existing subscriptions from
+// the body of a 409 response.
+func newSubscriptionConflictError(apiErr *APIError) *SubscriptionConflictError {
+	conflict := &SubscriptionConflictError{Err: apiErr}
+	var body struct {
+		APISubscriptions []Subscription `json:"apiSubscriptions"`
+	}
+	if json.Unmarshal(apiErr.Body, &body) == nil {
+		conflict.Existing = body.APISubscriptions
+	}
+	return conflict
+}
+
+// subscriptionsURL returns the path of a user's subscriptions to
+// collection, or of the one with subscriptionID if set.
+func subscriptionsURL(userID string, collection CollectionType, subscriptionID string) string {
+	urlStr := "/user/" + url.PathEscape(userID)
+	if collection != CollectionAll {
+		urlStr += "/" + string(collection)
+	}
+	urlStr += "/apiSubscriptions"
+	if subscriptionID != "" {
+		urlStr += "/" + url.PathEscape(subscriptionID)
+	}
+	return urlStr + ".json"
+}
+
+// withSubscriber sends a subscription request to c.SubscriberID, if set.
+func (c *Client) withSubscriber(req *http.Request) {
+	if c.SubscriberID != "" {
+		req.Header.Set("X-Fitbit-Subscriber-Id", c.SubscriberID)
+	}
+}
+
+// CreateSubscription subscribes to changes to collection of the user's
+// data under subscriptionID. created is false if the subscription
+// already existed. An id already in use for another user or collection
+// results in a *SubscriptionConflictError.
+func (c *Client) CreateSubscription(
+	ctx context.Context,
+	collection CollectionType,
+	subscriptionID string,
+) (sub Subscription, created bool, err error) {
+	if subscriptionID == "" {
+		return sub, false, errors.New("fitbit: subscription id is required")
+	}
+	req, err := c.newFormRequest("POST", "", subscriptionsURL("-", collection, subscriptionID), url.Values{})
+	if err != nil {
+		return sub, false, err
+	}
+	c.withSubscriber(req)
+
+	resp, err := c.Do(req.WithContext(ctx), &sub)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		return sub, false, newSubscriptionConflictError(apiErr)
+	}
+	if err != nil {
+		return sub, false, err
+	}
+	return sub, resp.StatusCode == http.StatusCreated, nil
+}
+
+// Subscriptions returns the user's subscriptions to collection, or to
+// any collection for CollectionAll.
+func (c *Client) Subscriptions(ctx context.Context, collection CollectionType) ([]Subscription, error) {
+	var resp struct {
+		APISubscriptions []Subscription `json:"apiSubscriptions"`
+	}
+	req, err := c.newRequest("GET", "", subscriptionsURL("-", collection, ""), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.withSubscriber(req)
+
+	if _, err := c.Do(req.WithContext(ctx), &resp); err != nil {
+		return nil, err
+	}
+	if resp.APISubscriptions == nil {
+		resp.APISubscriptions = []Subscription{}
+	}
+	return resp.APISubscriptions, nil
+}
+
+// DeleteSubscription deletes the subscription to collection with the
+// given id. It returns an error matching ErrNotFound if there is no such
+// subscription.
+func (c *Client) DeleteSubscription(ctx context.Context, collection CollectionType, subscriptionID string) error {
+	return c.deleteSubscription(ctx, "-", collection, subscriptionID)
+}
+
+// deleteSubscription is DeleteSubscription for a subscription of the
+// user with the given id.
+func (c *Client) deleteSubscription(ctx context.Context, userID string, collection CollectionType, subscriptionID string) error {
+	if subscriptionID == "" {
+		return errors.New("fitbit: subscription id is required")
+	}
+	req, err := c.newRequest("DELETE", "", subscriptionsURL(userID, collection, subscriptionID), nil)
+	if err != nil {
+		return err
+	}
+	c.withSubscriber(req)
+
+	_, err = c.Do(req.WithContext(ctx), nil)
+	return err
+}
+
+// collectionOf returns the CollectionType of an existing subscription,
+// whose collectionType is "user" for subscriptions to all collections.
+func (s Subscription) collectionOf() CollectionType {
+	if s.CollectionType == "user" {
+		return CollectionAll
+	}
+	return CollectionType(s.CollectionType)
+}
+
+// SubscriptionIDFor returns the subscription id EnsureSubscriptions uses
+// for collection: prefix followed by the collection name, or by "all"
+// for CollectionAll.
+func SubscriptionIDFor(prefix string, collection CollectionType) string {
+	if collection == CollectionAll {
+		return prefix + "all"
+	}
+	return prefix + string(collection)
+}
+
+// SubscriptionReport is the outcome of EnsureSubscriptions.
+type SubscriptionReport struct {
+	Created  []Subscription
+	Existing []Subscription
+	Deleted  []Subscription
+	// Errors holds the subscriptions that couldn't be created or
+	// deleted; the others were reconciled regardless.
+	Errors []SubscriptionError
+}
+
+// SubscriptionError is a failure to reconcile one subscription.
+type SubscriptionError struct {
+	Collection     CollectionType
+	SubscriptionID string
+	Err            error
+}
+
+func (e SubscriptionError) Error() string {
+	return fmt.Sprintf("fitbit: subscription %q: %s", e.SubscriptionID, e.Err)
+}
+
+func (e SubscriptionError) Unwrap() error {
+	return e.Err
+}
+
+// EnsureSubscriptions makes the user's subscriptions with ids starting
+// with prefix (which can't be empty) exactly one per collection, as
+// named by SubscriptionIDFor. Per subscription failures are in the
+// report's Errors.
+func (c *Client) EnsureSubscriptions(
+	ctx context.Context,
+	prefix string,
+	collections ...CollectionType,
+) (SubscriptionReport, error) {
+	var report SubscriptionReport
+	if prefix == "" {
+		return report, errors.New("fitbit: subscription id prefix is required")
+	}
+	existing, err := c.Subscriptions(ctx, CollectionAll)
+	if err != nil {
+		return report, err
+	}
+
+	wanted := make(map[string]CollectionType, len(collections))
+	for _, col := range collections {
+		wanted[SubscriptionIDFor(prefix, col)] = col
+	}
+
+	have := make(map[string]bool)
+	for _, s := range existing {
+		if !strings.HasPrefix(s.SubscriptionID, prefix) {
+			continue
+		}
+		if col, ok := wanted[s.SubscriptionID]; ok && col == s.collectionOf() {
+			have[s.SubscriptionID] = true
+			report.Existing = append(report.Existing, s)
+			continue
+		}
+		if err := c.DeleteSubscription(ctx, s.collectionOf(), s.SubscriptionID); err != nil {
+			report.Errors = append(report.Errors, SubscriptionError{
+				Collection:     s.collectionOf(),
+				SubscriptionID: s.SubscriptionID,
+				Err:            err,
+			})
+			continue
+		}
+		report.Deleted = append(report.Deleted, s)
+	}
+
+	for _, col := range collections {
+		id := SubscriptionIDFor(prefix, col)
+		if have[id] {
+			continue
+		}
+		have[id] = true
+		sub, err := c.createSubscriptionHealing(ctx, col, id)
+		if err != nil {
+			report.Errors = append(report.Errors, SubscriptionError{
+				Collection:     col,
+				SubscriptionID: id,
+				Err:            err,
+			})
+			continue
+		}
+		report.Created = append(report.Created, sub)
+	}
+	return report, nil
+}
+
+// createSubscriptionHealing is CreateSubscription that retries once
+// after deleting the conflicting subscriptions the token may delete.
+func (c *Client) createSubscriptionHealing(ctx context.Context, col CollectionType, id string) (Subscription, error) {
+	sub, _, err := c.CreateSubscription(ctx, col, id)
+	var conflict *SubscriptionConflictError
+	if !errors.As(err, &conflict) || len(conflict.Existing) == 0 {
+		return sub, err
+	}
+	for _, s := range conflict.Existing {
+		if s.OwnerID == "" {
+			return sub, conflict
+		}
+		if err := c.deleteSubscription(ctx, s.OwnerID, s.collectionOf(), s.SubscriptionID); err != nil {
+			return sub, conflict
+		}
+	}
+	sub, _, err = c.CreateSubscription(ctx, col, id)
+	return sub, err
+}
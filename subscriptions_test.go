@@ -0,0 +1,296 @@
+package fitbit
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeSubscriptions fakes the subscription endpoints for user. Other
+// users' subscriptions can be added to provoke conflicts.
+type fakeSubscriptions struct {
+	user string
+
+	subs fakeStore[string, Subscription]
+	// deleted records the paths of successful deletes.
+	deleted []string
+}
+
+func newFakeSubscriptions(subs ...Subscription) *fakeSubscriptions {
+	f := &fakeSubscriptions{user: "USER1"}
+	for _, s := range subs {
+		f.subs.put(s.SubscriptionID, s)
+	}
+	return f
+}
+
+func (f *fakeSubscriptions) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.subs.do(func(subs map[string]Subscription) { f.serve(w, r, subs) })
+}
+
+func (f *fakeSubscriptions) serve(w http.ResponseWriter, r *http.Request, subs map[string]Subscription) {
+
+	// /1/user/{user}[/{collection}]/apiSubscriptions[/{id}].json
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/1/user/"), ".json"), "/")
+	if len(parts) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+	user, parts := parts[0], parts[1:]
+	collection := "user"
+	if parts[0] != "apiSubscriptions" {
+		collection, parts = parts[0], parts[1:]
+	}
+	if len(parts) == 0 || parts[0] != "apiSubscriptions" || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+	var id string
+	if len(parts) == 2 {
+		id = parts[1]
+	}
+	if user != "-" && user != f.user {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"errors": []ErrorDetail{{
+			ErrorType: "insufficient_permissions",
+			Message:   "Insufficient permissions to access the resource of user " + user,
+		}}})
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && id == "":
+		list := []Subscription{}
+		for _, s := range subs {
+			if s.OwnerID == f.user && (collection == "user" || s.CollectionType == collection) {
+				list = append(list, s)
+			}
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].SubscriptionID < list[j].SubscriptionID })
+		writeJSON(w, http.StatusOK, map[string]interface{}{"apiSubscriptions": list})
+
+	case r.Method == "POST" && id != "":
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+			http.Error(w, "unexpected Content-Type "+ct, http.StatusUnsupportedMediaType)
+			return
+		}
+		want := Subscription{
+			CollectionType: collection,
+			OwnerID:        f.user,
+			OwnerType:      "user",
+			SubscriberID:   "1",
+			SubscriptionID: id,
+		}
+		if sub := r.Header.Get("X-Fitbit-Subscriber-Id"); sub != "" {
+			want.SubscriberID = sub
+		}
+		if s, ok := subs[id]; ok {
+			if s == want {
+				writeJSON(w, http.StatusOK, s)
+				return
+			}
+			writeJSON(w, http.StatusConflict, map[string]interface{}{"apiSubscriptions": []Subscription{s}})
+			return
+		}
+		subs[id] = want
+		writeJSON(w, http.StatusCreated, want)
+
+	case r.Method == "DELETE" && id != "":
+		s, ok := subs[id]
+		if !ok || s.OwnerID != f.user || (collection != "user" && s.CollectionType != collection) {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"errors": []ErrorDetail{{
+				ErrorType: "not_found",
+				Message:   "The subscription " + id + " was not found.",
+			}}})
+			return
+		}
+		delete(subs, id)
+		f.deleted = append(f.deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusMethodNotAllowed)
+	}
+}
+
+// ids returns the ids of the subscriptions f has, sorted.
+func (f *fakeSubscriptions) ids() []string {
+	return subscriptionIDs(f.subs.list(nil))
+}
+
+func subscriptionIDs(subs []Subscription) []string {
+	ids := []string{}
+	for _, s := range subs {
+		ids = append(ids, s.SubscriptionID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEnsureSubscriptions(t *testing.T) {
+	f := newFakeSubscriptions(
+		Subscription{CollectionType: "sleep", OwnerID: "USER1", OwnerType: "user", SubscriberID: "1", SubscriptionID: "app-sleep"},
+		// Not asked for any more.
+		Subscription{CollectionType: "foods", OwnerID: "USER1", OwnerType: "user", SubscriberID: "1", SubscriptionID: "app-foods"},
+		// Not the reconciler's.
+		Subscription{CollectionType: "body", OwnerID: "USER1", OwnerType: "user", SubscriberID: "1", SubscriptionID: "other-body"},
+	)
+	c := newTestClient(t, f)
+
+	report, err := c.EnsureSubscriptions(t.Context(), "app-", CollectionSleep, CollectionActivities, CollectionAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 0 {
+		t.Fatalf("Errors = %v", report.Errors)
+	}
+	for _, tt := range []struct {
+		name      string
+		got, want []string
+	}{
+		{"Created", subscriptionIDs(report.Created), []string{"app-activities", "app-all"}},
+		{"Existing", subscriptionIDs(report.Existing), []string{"app-sleep"}},
+		{"Deleted", subscriptionIDs(report.Deleted), []string{"app-foods"}},
+		{"stored", f.ids(), []string{"app-activities", "app-all", "app-sleep", "other-body"}},
+	} {
+		if !equalStrings(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// Running it again changes nothing.
+	report, err = c.EnsureSubscriptions(t.Context(), "app-", CollectionSleep, CollectionActivities, CollectionAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Created) != 0 || len(report.Deleted) != 0 || len(report.Existing) != 3 || len(report.Errors) != 0 {
+		t.Errorf("second run: report = %+v, want all 3 existing", report)
+	}
+}
+
+func TestEnsureSubscriptionsNeedsPrefix(t *testing.T) {
+	f := newFakeSubscriptions(
+		Subscription{CollectionType: "body", OwnerID: "USER1", OwnerType: "user", SubscriberID: "1", SubscriptionID: "other-body"},
+	)
+	c := newTestClient(t, f)
+
+	if _, err := c.EnsureSubscriptions(t.Context(), "", CollectionSleep); err == nil {
+		t.Fatal("EnsureSubscriptions with an empty prefix succeeded")
+	}
+	if ids := f.ids(); !equalStrings(ids, []string{"other-body"}) {
+		t.Errorf("subscriptions = %v, want them untouched", ids)
+	}
+}
+
+func TestCreateSubscriptionHealing(t *testing.T) {
+	// The id is in use by one of the user's own subscriptions, to another
+	// collection.
+	f := newFakeSubscriptions(
+		Subscription{CollectionType: "foods", OwnerID: "USER1", OwnerType: "user", SubscriberID: "1", SubscriptionID: "app-sleep"},
+	)
+	c := newTestClient(t, f)
+
+	sub, err := c.createSubscriptionHealing(t.Context(), CollectionSleep, "app-sleep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.CollectionType != "sleep" || sub.SubscriptionID != "app-sleep" {
+		t.Errorf("sub = %+v", sub)
+	}
+	if want := []string{"/1/user/USER1/foods/apiSubscriptions/app-sleep.json"}; !equalStrings(f.deleted, want) {
+		t.Errorf("deleted = %v, want %v", f.deleted, want)
+	}
+}
+
+func TestEnsureSubscriptionsOtherUsersConflict(t *testing.T) {
+	other := Subscription{CollectionType: "sleep", OwnerID: "OTHER", OwnerType: "user", SubscriberID: "1", SubscriptionID: "app-sleep"}
+	f := newFakeSubscriptions(other)
+	c := newTestClient(t, f)
+
+	report, err := c.EnsureSubscriptions(t.Context(), "app-", CollectionSleep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 1 || !errors.Is(report.Errors[0], ErrSubscriptionConflict) {
+		t.Fatalf("Errors = %v, want the conflict", report.Errors)
+	}
+	var conflict *SubscriptionConflictError
+	if !errors.As(report.Errors[0], &conflict) || len(conflict.Existing) != 1 || conflict.Existing[0] != other {
+		t.Errorf("conflict = %+v, want it to describe the other user's subscription", conflict)
+	}
+	if s, _ := f.subs.get("app-sleep"); len(f.deleted) != 0 || s != other {
+		t.Errorf("the other user's subscription was touched: deleted %v", f.deleted)
+	}
+}
+
+func TestSubscriptionLifecycle(t *testing.T) {
+	f := newFakeSubscriptions()
+	c := newTestClient(t, f)
+	c.SubscriberID = "7"
+
+	sub, created, err := c.CreateSubscription(t.Context(), CollectionSleep, "app-sleep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Subscription{CollectionType: "sleep", OwnerID: "USER1", OwnerType: "user", SubscriberID: "7", SubscriptionID: "app-sleep"}
+	if !created || sub != want {
+		t.Errorf("CreateSubscription = %+v, %v, want %+v, true", sub, created, want)
+	}
+	if _, created, err := c.CreateSubscription(t.Context(), CollectionSleep, "app-sleep"); err != nil || created {
+		t.Errorf("creating it again = %v, %v, want false, nil", created, err)
+	}
+	if _, _, err := c.CreateSubscription(t.Context(), CollectionAll, "app-all"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		collection CollectionType
+		want       []string
+	}{
+		{CollectionSleep, []string{"app-sleep"}},
+		{CollectionFoods, []string{}},
+		{CollectionAll, []string{"app-all", "app-sleep"}},
+	} {
+		subs, err := c.Subscriptions(t.Context(), tt.collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := subscriptionIDs(subs); !equalStrings(got, tt.want) {
+			t.Errorf("Subscriptions(%q) = %v, want %v", tt.collection, got, tt.want)
+		}
+	}
+
+	// The id is taken by the sleep subscription.
+	_, _, err = c.CreateSubscription(t.Context(), CollectionFoods, "app-sleep")
+	var conflict *SubscriptionConflictError
+	if !errors.Is(err, ErrSubscriptionConflict) || !errors.As(err, &conflict) ||
+		len(conflict.Existing) != 1 || conflict.Existing[0] != want || conflict.Err.StatusCode != http.StatusConflict {
+		t.Errorf("conflicting create = %v, want a conflict with %+v", err, want)
+	}
+
+	if err := c.DeleteSubscription(t.Context(), CollectionSleep, "app-sleep"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/1/user/-/sleep/apiSubscriptions/app-sleep.json"}; !equalStrings(f.deleted, want) {
+		t.Errorf("deleted = %v, want %v", f.deleted, want)
+	}
+	if err := c.DeleteSubscription(t.Context(), CollectionSleep, "app-sleep"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting it again = %v, want ErrNotFound", err)
+	}
+	if ids := f.ids(); !equalStrings(ids, []string{"app-all"}) {
+		t.Errorf("subscriptions = %v, want [app-all]", ids)
+	}
+}
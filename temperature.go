@@ -0,0 +1,219 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// SkinTemperature is the nightly skin temperature variation relative to
+// the user's personal baseline. NightlyRelative keeps its sign; negative
+// deltas are common.
+type SkinTemperature struct {
+	DateTime        Date
+	NightlyRelative float64
+	// LogType is how the reading was taken, e.g.
+	// "dedicated_temp_sensor".
+	LogType string
+}
+
+type skinTemperatureResponse struct {
+	TempSkin []struct {
+		DateTime Date `json:"dateTime"`
+		Value    struct {
+			NightlyRelative *float64 `json:"nightlyRelative"`
+		} `json:"value"`
+		LogType string `json:"logType"`
+	} `json:"tempSkin"`
+}
+
+// entries returns the response's readings, dropping any that carry no
+// nightlyRelative value so they can't be mistaken for a 0.0 delta.
+func (r skinTemperatureResponse) entries() []SkinTemperature {
+	var entries []SkinTemperature
+	for _, e := range r.TempSkin {
+		if e.Value.NightlyRelative == nil {
+			continue
+		}
+		entries = append(entries, SkinTemperature{
+			DateTime:        e.DateTime,
+			NightlyRelative: *e.Value.NightlyRelative,
+			LogType:         e.LogType,
+		})
+	}
+	return entries
+}
+
+// SkinTemperatureByDate returns the skin temperature variation for date.
+// It returns nil (and no error) when there is no reading for that
+// night.
+func (c *Client) SkinTemperatureByDate(ctx context.Context, date Date) (*SkinTemperature, error) {
+	var resp skinTemperatureResponse
+	err := c.get(ctx, fmt.Sprintf("/user/-/temp/skin/date/%s.json", date), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := resp.entries()
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// SkinTemperatureRange returns the skin temperature variations between
+// start and end, inclusive, sorted by date. Ranges longer than Fitbit's
+// 30 day cap are fetched in chunks, and nights without a reading are
+// absent from the result.
+func (c *Client) SkinTemperatureRange(ctx context.Context, start, end Date) ([]SkinTemperature, error) {
+	var entries []SkinTemperature
+	err := c.getWellnessRange(ctx, "temp/skin", start, end, func(urlStr string) error {
+		var resp skinTemperatureResponse
+		if err := c.get(ctx, urlStr, &resp); err != nil {
+			return err
+		}
+		entries = append(entries, resp.entries()...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DateTime.Before(entries[j].DateTime)
+	})
+	return entries, nil
+}
+
+// SkinTemperatureDeviation compares one night's reading with the
+// average of the readings in the days before it.
+type SkinTemperatureDeviation struct {
+	SkinTemperature
+	TrailingAverage float64
+	// Deviation is NightlyRelative - TrailingAverage.
+	Deviation float64
+	// Elevated is set when Deviation exceeds the threshold passed to
+	// SkinTemperatureDeviations.
+	Elevated bool
+}
+
+// SkinTemperatureDeviations compares each reading in entries (which must
+// be sorted by date, as returned by SkinTemperatureRange) against the
+// average of the readings from the windowDays days before it, flagging
+// nights more than threshold above that average. Nights with no earlier
+// reading inside the window have no baseline and are left out.
+func SkinTemperatureDeviations(entries []SkinTemperature, windowDays int, threshold float64) []SkinTemperatureDeviation {
+	var deviations []SkinTemperatureDeviation
+	first := 0
+	for i, e := range entries {
+		windowStart := e.DateTime.AddDays(-windowDays)
+		for first < i && entries[first].DateTime.Before(windowStart) {
+			first++
+		}
+		if first == i {
+			continue
+		}
+
+		var sum float64
+		for _, prev := range entries[first:i] {
+			sum += prev.NightlyRelative
+		}
+		avg := sum / float64(i-first)
+		deviations = append(deviations, SkinTemperatureDeviation{
+			SkinTemperature: e,
+			TrailingAverage: avg,
+			Deviation:       e.NightlyRelative - avg,
+			Elevated:        e.NightlyRelative-avg > threshold,
+		})
+	}
+	return deviations
+}
+
+// CoreTemperature is a manually logged core body temperature reading.
+// DateTime is the time of the reading, in the user's timezone (see
+// Client.Location).
+type CoreTemperature struct {
+	DateTime time.Time
+	Value    float64
+}
+
+func (t *CoreTemperature) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		DateTime string  `json:"dateTime"`
+		Value    float64 `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	dateTime, err := parseLocalDateTime(raw.DateTime, time.UTC)
+	if err != nil {
+		return err
+	}
+	t.DateTime, t.Value = dateTime, raw.Value
+	return nil
+}
+
+// CoreTemperatureLog holds the core temperature readings for a date or
+// range. Values are in Fahrenheit for UnitSystemUS and Celsius
+// otherwise.
+type CoreTemperatureLog struct {
+	Units    UnitSystem
+	Readings []CoreTemperature
+}
+
+type coreTemperatureResponse struct {
+	TempCore []CoreTemperature `json:"tempCore"`
+}
+
+// CoreTemperatureByDate returns the core temperature readings logged on
+// date. Readings are user entered, so there may be any number per day.
+func (c *Client) CoreTemperatureByDate(ctx context.Context, date Date) (CoreTemperatureLog, error) {
+	log := CoreTemperatureLog{Units: c.unitSystem()}
+	var resp coreTemperatureResponse
+	err := c.get(ctx, fmt.Sprintf("/user/-/temp/core/date/%s.json", date), &resp)
+	if err != nil {
+		return log, err
+	}
+
+	log.Readings = resp.TempCore
+	c.localizeCoreTemperatures(ctx, log.Readings)
+	return log, nil
+}
+
+// CoreTemperatureRange returns the core temperature readings logged
+// between start and end, inclusive, sorted by time. Ranges longer than
+// Fitbit's 30 day cap are fetched in chunks.
+func (c *Client) CoreTemperatureRange(ctx context.Context, start, end Date) (CoreTemperatureLog, error) {
+	log := CoreTemperatureLog{Units: c.unitSystem()}
+	err := c.getWellnessRange(ctx, "temp/core", start, end, func(urlStr string) error {
+		var resp coreTemperatureResponse
+		if err := c.get(ctx, urlStr, &resp); err != nil {
+			return err
+		}
+		log.Readings = append(log.Readings, resp.TempCore...)
+		return nil
+	})
+	if err != nil {
+		return log, err
+	}
+
+	sort.Slice(log.Readings, func(i, j int) bool {
+		return log.Readings[i].DateTime.Before(log.Readings[j].DateTime)
+	})
+	c.localizeCoreTemperatures(ctx, log.Readings)
+	return log, nil
+}
+
+// localizeCoreTemperatures puts the times of readings in the user's
+// timezone.
+func (c *Client) localizeCoreTemperatures(ctx context.Context, readings []CoreTemperature) {
+	times := make([]*time.Time, len(readings))
+	for i := range readings {
+		times[i] = &readings[i].DateTime
+	}
+	c.inLocation(ctx, times...)
+}
@@ -0,0 +1,145 @@
+package fitbit
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoreTemperatureByDate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/temp/core/date/2021-10-04.json", serveFixture(t, "temp_core.json"))
+	c := newTestClient(t, mux)
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	c.Location = tokyo
+	c.UnitSystem = UnitSystemMetric
+
+	log, err := c.CoreTemperatureByDate(t.Context(), Date{2021, 10, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CoreTemperature{
+		{time.Date(2021, 10, 4, 7, 12, 0, 0, tokyo), 37.1},
+		{time.Date(2021, 10, 4, 21, 40, 0, 0, tokyo), 37.6},
+	}
+	if log.Units != UnitSystemMetric || len(log.Readings) != len(want) {
+		t.Fatalf("log = %+v", log)
+	}
+	for i, r := range log.Readings {
+		if !r.DateTime.Equal(want[i].DateTime) || r.DateTime.Location() != tokyo || r.Value != want[i].Value {
+			t.Errorf("Readings[%d] = %v %v, want %v %v", i, r.DateTime, r.Value, want[i].DateTime, want[i].Value)
+		}
+	}
+}
+
+func TestCoreTemperatureWithoutTimezone(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/temp/core/date/2021-10-04.json", serveFixture(t, "temp_core.json"))
+	mux.HandleFunc("GET /1/user/-/profile.json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(profileForbidden))
+	})
+	c := newTestClient(t, mux)
+
+	log, err := c.CoreTemperatureByDate(t.Context(), Date{2021, 10, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2021, 10, 4, 7, 12, 0, 0, time.UTC); !log.Readings[0].DateTime.Equal(want) {
+		t.Errorf("DateTime = %v, want %v", log.Readings[0].DateTime, want)
+	}
+}
+
+func TestSkinTemperatureRange(t *testing.T) {
+	// Every night has a reading but the 5th of each month, whose value is
+	// missing; each chunk comes back newest first.
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/temp/skin/date/{start}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		start, err1 := ParseDate(r.PathValue("start"))
+		end, err2 := ParseDate(strings.TrimSuffix(r.PathValue("file"), ".json"))
+		if err1 != nil || err2 != nil {
+			http.Error(w, "bad range", http.StatusBadRequest)
+			return
+		}
+		entries := []map[string]interface{}{}
+		for d := end; !d.Before(start); d = d.AddDays(-1) {
+			value := map[string]interface{}{}
+			if d.Day != 5 {
+				value["nightlyRelative"] = float64(d.Day%7-3) / 10
+			}
+			entries = append(entries, map[string]interface{}{"dateTime": d, "value": value, "logType": "dedicated_temp_sensor"})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tempSkin": entries})
+	})
+	c := newTestClient(t, mux)
+
+	entries, err := c.SkinTemperatureRange(t.Context(), Date{2021, 9, 1}, Date{2021, 10, 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRequests := []string{
+		"/1/user/-/temp/skin/date/2021-09-01/2021-09-30.json",
+		"/1/user/-/temp/skin/date/2021-10-01/2021-10-20.json",
+	}
+	if !equalStrings(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+
+	var want []SkinTemperature
+	for d := (Date{2021, 9, 1}); !d.After(Date{2021, 10, 20}); d = d.AddDays(1) {
+		if d.Day != 5 {
+			want = append(want, SkinTemperature{d, float64(d.Day%7-3) / 10, "dedicated_temp_sensor"})
+		}
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entries[%d] = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestSkinTemperatureDeviations(t *testing.T) {
+	entries := []SkinTemperature{
+		{DateTime: Date{2021, 10, 1}, NightlyRelative: -0.2},
+		{DateTime: Date{2021, 10, 2}, NightlyRelative: 0.2},
+		{DateTime: Date{2021, 10, 3}, NightlyRelative: 0.0},
+		// The 4th is missing.
+		{DateTime: Date{2021, 10, 5}, NightlyRelative: 0.8},
+		// More than 3 days after the 5th, so without a baseline.
+		{DateTime: Date{2021, 10, 12}, NightlyRelative: 1.5},
+	}
+	got := SkinTemperatureDeviations(entries, 3, 0.5)
+	want := []struct {
+		date              Date
+		average, deviates float64
+		elevated          bool
+	}{
+		{Date{2021, 10, 2}, -0.2, 0.4, false},
+		{Date{2021, 10, 3}, 0, 0, false},
+		// The window is the 2nd through the 4th.
+		{Date{2021, 10, 5}, 0.1, 0.7, true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d deviations, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.DateTime != w.date || math.Abs(g.TrailingAverage-w.average) > 1e-9 ||
+			math.Abs(g.Deviation-w.deviates) > 1e-9 || g.Elevated != w.elevated {
+			t.Errorf("deviations[%d] = %+v, want %+v", i, g, w)
+		}
+	}
+}
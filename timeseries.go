@@ -0,0 +1,114 @@
+package fitbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// Period is a time series span ending on (and including) a base date.
+type Period string
+
+const (
+	Period1Day    Period = "1d"
+	Period7Days   Period = "7d"
+	Period30Days  Period = "30d"
+	Period1Week   Period = "1w"
+	Period1Month  Period = "1m"
+	Period3Months Period = "3m"
+	Period6Months Period = "6m"
+	Period1Year   Period = "1y"
+	PeriodMax     Period = "max"
+)
+
+// rawSeriesPoint is a time series value as Fitbit sends it, usually as
+// a string even for numeric series.
+type rawSeriesPoint struct {
+	DateTime Date        `json:"dateTime"`
+	Value    seriesValue `json:"value"`
+}
+
+// seriesValue decodes a time series value sent either as a JSON string
+// or a bare number.
+type seriesValue string
+
+func (v *seriesValue) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*v = seriesValue(s)
+		return nil
+	}
+	*v = seriesValue(b)
+	return nil
+}
+
+// float parses the point's value as a number.
+func (p rawSeriesPoint) float() (float64, error) {
+	v, err := strconv.ParseFloat(string(p.Value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("fitbit: invalid time series value %q for %s", p.Value, p.DateTime)
+	}
+	return v, nil
+}
+
+// int parses the point's value as an integer.
+func (p rawSeriesPoint) int() (int, error) {
+	v, err := strconv.Atoi(string(p.Value))
+	if err != nil {
+		return 0, fmt.Errorf("fitbit: invalid time series value %q for %s", p.Value, p.DateTime)
+	}
+	return v, nil
+}
+
+// timeSeriesKey returns the key a resource's series is returned under,
+// e.g. "sleep-minutesAsleep".
+func timeSeriesKey(resource string) string {
+	return strings.Replace(resource, "/", "-", -1)
+}
+
+func (c *Client) getTimeSeries(ctx context.Context, resource, urlStr string) ([]rawSeriesPoint, error) {
+	var resp map[string][]rawSeriesPoint
+	if err := c.get(ctx, urlStr, &resp); err != nil {
+		return nil, err
+	}
+	return resp[timeSeriesKey(resource)], nil
+}
+
+// timeSeries fetches resource's series for period ending on date.
+// resource is the path after /user/-/, e.g. "sleep/minutesAsleep".
+func (c *Client) timeSeries(ctx context.Context, resource string, date Date, period Period) ([]rawSeriesPoint, error) {
+	return c.getTimeSeries(
+		ctx,
+		resource,
+		fmt.Sprintf("/user/-/%s/date/%s/%s.json", resource, date, period),
+	)
+}
+
+// timeSeriesRange fetches resource's series between start and end,
+// inclusive, in chunks of at most maxDays days.
+func (c *Client) timeSeriesRange(ctx context.Context, resource string, start, end Date, maxDays int) ([]rawSeriesPoint, error) {
+	chunks, err := splitDateRange(start, end, maxDays)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []rawSeriesPoint
+	for _, chunk := range chunks {
+		chunkPoints, err := c.getTimeSeries(
+			ctx,
+			resource,
+			fmt.Sprintf("/user/-/%s/date/%s/%s.json", resource, chunk.Start, chunk.End),
+		)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, chunkPoints...)
+	}
+	return points, nil
+}
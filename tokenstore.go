@@ -0,0 +1,230 @@
+package fitbit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrTokenNotFound is returned by a TokenStore that has no token for a
+// user.
+var ErrTokenNotFound = errors.New("fitbit: no token stored for user")
+
+// TokenStore persists users' tokens, keyed by their Fitbit user id.
+type TokenStore interface {
+	LoadToken(userID string) (*oauth2.Token, error)
+	SaveToken(userID string, tok *oauth2.Token) error
+}
+
+// TokenBlobStore persists opaque per-user records, such as the encrypted
+// tokens of EncryptedTokenStore. LoadBlob returns ErrTokenNotFound for a
+// key with no record.
+type TokenBlobStore interface {
+	LoadBlob(key string) ([]byte, error)
+	SaveBlob(key string, b []byte) error
+}
+
+// FileTokenStore is a TokenStore keeping each user's token as JSON in
+// its own file in Dir. Tokens are stored unencrypted; see
+// EncryptedTokenStore. It is also a TokenBlobStore keeping each record
+// in its own file.
+type FileTokenStore struct {
+	Dir string
+}
+
+func (s FileTokenStore) path(userID string) (string, error) {
+	if userID == "" || userID == "." || userID == ".." || strings.ContainsAny(userID, `/\`) {
+		return "", errors.New("fitbit: invalid user id " + userID)
+	}
+	return filepath.Join(s.Dir, userID+".json"), nil
+}
+
+func (s FileTokenStore) LoadToken(userID string) (*oauth2.Token, error) {
+	b, err := s.LoadBlob(userID)
+	if err != nil {
+		return nil, err
+	}
+	tok := new(oauth2.Token)
+	if err := json.Unmarshal(b, tok); err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+func (s FileTokenStore) SaveToken(userID string, tok *oauth2.Token) error {
+	b, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	return s.SaveBlob(userID, b)
+}
+
+// LoadBlob returns the contents of key's file.
+func (s FileTokenStore) LoadBlob(key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, ErrTokenNotFound
+	}
+	return b, err
+}
+
+// SaveBlob writes b to a temporary file that then replaces key's, so
+// readers never see a partly written record.
+func (s FileTokenStore) SaveBlob(key string, b []byte) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(s.Dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+// ErrTokenDecrypt is returned by EncryptedTokenStore for a stored token
+// that none of its keys decrypt, because it was tampered with, corrupted
+// or encrypted with a key no longer configured.
+var ErrTokenDecrypt = errors.New("fitbit: stored token failed to decrypt")
+
+// EncryptedTokenStore is a TokenStore keeping tokens in a TokenBlobStore
+// encrypted with AES-256-GCM, each record holding a random nonce
+// followed by the ciphertext.
+type EncryptedTokenStore struct {
+	blobs TokenBlobStore
+	aeads []cipher.AEAD
+}
+
+// NewEncryptedTokenStore returns a store keeping tokens in blobs, such as
+// a FileTokenStore, encrypted under keys, which must be 32 bytes each.
+// Tokens are always encrypted with the first key; the others are only
+// tried when decrypting, so that keys can be rotated by putting the new
+// one first.
+func NewEncryptedTokenStore(blobs TokenBlobStore, keys ...[]byte) (*EncryptedTokenStore, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("fitbit: encrypted token store needs a key")
+	}
+	s := &EncryptedTokenStore{blobs: blobs}
+	for i, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("fitbit: token key %d is %d bytes, want 32", i, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		s.aeads = append(s.aeads, aead)
+	}
+	return s, nil
+}
+
+func (s *EncryptedTokenStore) LoadToken(userID string) (*oauth2.Token, error) {
+	sealed, err := s.blobs.LoadBlob(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, aead := range s.aeads {
+		n := aead.NonceSize()
+		if len(sealed) < n {
+			break
+		}
+		// The user id is authenticated too, so a token can't be moved to
+		// another user's file.
+		b, err := aead.Open(nil, sealed[:n], sealed[n:], []byte(userID))
+		if err != nil {
+			continue
+		}
+		tok := new(oauth2.Token)
+		if err := json.Unmarshal(b, tok); err != nil {
+			return nil, err
+		}
+		return tok, nil
+	}
+	return nil, ErrTokenDecrypt
+}
+
+func (s *EncryptedTokenStore) SaveToken(userID string, tok *oauth2.Token) error {
+	b, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	aead := s.aeads[0]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return s.blobs.SaveBlob(userID, aead.Seal(nonce, nonce, b, []byte(userID)))
+}
+
+// saltKey is the record NewPassphraseTokenStore keeps its salt in. It
+// can't clash with Fitbit user ids, which are upper case.
+const saltKey = "passphrase-salt"
+
+// pbkdf2Iterations is the PBKDF2-HMAC-SHA256 work factor for deriving
+// keys from passphrases. It is a var for tests.
+var pbkdf2Iterations = 600000
+
+// NewPassphraseTokenStore is NewEncryptedTokenStore with keys derived
+// from passphrases (with PBKDF2-HMAC-SHA256) rather than given. The salt
+// is random and kept in blobs, where it is created by the first call
+// for an empty store. Losing it makes all tokens undecryptable, as does
+// creating stores for an empty blobs concurrently.
+func NewPassphraseTokenStore(blobs TokenBlobStore, passphrases ...string) (*EncryptedTokenStore, error) {
+	if len(passphrases) == 0 {
+		return nil, errors.New("fitbit: encrypted token store needs a passphrase")
+	}
+	salt, err := blobs.LoadBlob(saltKey)
+	if err == ErrTokenNotFound {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		err = blobs.SaveBlob(saltKey, salt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fitbit: loading the token store salt: %w", err)
+	}
+
+	keys := make([][]byte, len(passphrases))
+	for i, p := range passphrases {
+		if p == "" {
+			return nil, fmt.Errorf("fitbit: token passphrase %d is empty", i)
+		}
+		if keys[i], err = pbkdf2.Key(sha256.New, p, salt, pbkdf2Iterations, 32); err != nil {
+			return nil, err
+		}
+	}
+	return NewEncryptedTokenStore(blobs, keys...)
+}
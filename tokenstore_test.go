@@ -0,0 +1,263 @@
+package fitbit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// memBlobStore is a TokenBlobStore in memory.
+type memBlobStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (s *memBlobStore) LoadBlob(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.blobs[key]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return bytes.Clone(b), nil
+}
+
+func (s *memBlobStore) SaveBlob(key string, b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.blobs == nil {
+		s.blobs = make(map[string][]byte)
+	}
+	s.blobs[key] = bytes.Clone(b)
+	return nil
+}
+
+var testToken = &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}
+
+func testKey(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+
+func newEncryptedStore(t *testing.T, blobs TokenBlobStore, keys ...[]byte) *EncryptedTokenStore {
+	t.Helper()
+	s, err := NewEncryptedTokenStore(blobs, keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func checkToken(t *testing.T, s TokenStore, userID string) {
+	t.Helper()
+	tok, err := s.LoadToken(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != testToken.AccessToken || tok.RefreshToken != testToken.RefreshToken {
+		t.Errorf("token = %+v, want %+v", tok, testToken)
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	s := FileTokenStore{Dir: t.TempDir()}
+	if _, err := s.LoadToken("ABC123"); err != ErrTokenNotFound {
+		t.Errorf("LoadToken of a missing user = %v, want ErrTokenNotFound", err)
+	}
+	if err := s.SaveToken("ABC123", testToken); err != nil {
+		t.Fatal(err)
+	}
+	checkToken(t, s, "ABC123")
+	for _, id := range []string{"", ".", "..", "../x", `a\b`} {
+		if err := s.SaveToken(id, testToken); err == nil {
+			t.Errorf("SaveToken(%q) succeeded", id)
+		}
+	}
+}
+
+func TestEncryptedTokenStoreRoundTrip(t *testing.T) {
+	files := FileTokenStore{Dir: t.TempDir()}
+	s := newEncryptedStore(t, files, testKey(1))
+	if err := s.SaveToken("ABC123", testToken); err != nil {
+		t.Fatal(err)
+	}
+	checkToken(t, s, "ABC123")
+
+	raw, err := os.ReadFile(filepath.Join(files.Dir, "ABC123.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("refresh")) {
+		t.Error("the stored token isn't encrypted")
+	}
+}
+
+func TestEncryptedTokenStoreTampered(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		tamper func([]byte) []byte
+	}{
+		{"flipped ciphertext byte", func(b []byte) []byte { b[len(b)-20] ^= 1; return b }},
+		{"flipped nonce byte", func(b []byte) []byte { b[0] ^= 1; return b }},
+		{"flipped tag byte", func(b []byte) []byte { b[len(b)-1] ^= 1; return b }},
+		{"truncated", func(b []byte) []byte { return b[:len(b)-1] }},
+		{"shorter than a nonce", func(b []byte) []byte { return b[:5] }},
+		{"empty", func(b []byte) []byte { return nil }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			blobs := &memBlobStore{}
+			s := newEncryptedStore(t, blobs, testKey(1))
+			if err := s.SaveToken("ABC123", testToken); err != nil {
+				t.Fatal(err)
+			}
+			blobs.blobs["ABC123"] = tt.tamper(blobs.blobs["ABC123"])
+			if _, err := s.LoadToken("ABC123"); err != ErrTokenDecrypt {
+				t.Errorf("err = %v, want ErrTokenDecrypt", err)
+			}
+		})
+	}
+}
+
+func TestEncryptedTokenStoreTruncatedFile(t *testing.T) {
+	files := FileTokenStore{Dir: t.TempDir()}
+	s := newEncryptedStore(t, files, testKey(1))
+	if err := s.SaveToken("ABC123", testToken); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(files.Dir, "ABC123.json")
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, raw[:len(raw)/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadToken("ABC123"); err != ErrTokenDecrypt {
+		t.Errorf("err = %v, want ErrTokenDecrypt", err)
+	}
+}
+
+func TestEncryptedTokenStoreMovedToOtherUser(t *testing.T) {
+	blobs := &memBlobStore{}
+	s := newEncryptedStore(t, blobs, testKey(1))
+	if err := s.SaveToken("ABC123", testToken); err != nil {
+		t.Fatal(err)
+	}
+	blobs.blobs["XYZ789"] = blobs.blobs["ABC123"]
+	if _, err := s.LoadToken("XYZ789"); err != ErrTokenDecrypt {
+		t.Errorf("err = %v, want ErrTokenDecrypt", err)
+	}
+}
+
+func TestEncryptedTokenStoreRotation(t *testing.T) {
+	blobs := &memBlobStore{}
+	old := newEncryptedStore(t, blobs, testKey(1))
+	if err := old.SaveToken("ABC123", testToken); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newEncryptedStore(t, blobs, testKey(2), testKey(1))
+	checkToken(t, rotated, "ABC123")
+	if err := rotated.SaveToken("XYZ789", testToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// New writes use the first key only.
+	if _, err := old.LoadToken("XYZ789"); err != ErrTokenDecrypt {
+		t.Errorf("old key decrypting a new write: err = %v, want ErrTokenDecrypt", err)
+	}
+	checkToken(t, newEncryptedStore(t, blobs, testKey(2)), "XYZ789")
+	// Until it is rewritten, the old token still needs the old key.
+	if _, err := newEncryptedStore(t, blobs, testKey(2)).LoadToken("ABC123"); err != ErrTokenDecrypt {
+		t.Errorf("new key alone decrypting an old write: err = %v, want ErrTokenDecrypt", err)
+	}
+}
+
+func TestNewEncryptedTokenStoreKeys(t *testing.T) {
+	if _, err := NewEncryptedTokenStore(&memBlobStore{}); err == nil {
+		t.Error("no keys: succeeded")
+	}
+	if _, err := NewEncryptedTokenStore(&memBlobStore{}, testKey(1), make([]byte, 16)); err == nil {
+		t.Error("a 16 byte key: succeeded")
+	}
+}
+
+// fastPBKDF2 lowers the PBKDF2 work factor for the rest of the test.
+func fastPBKDF2(t *testing.T) {
+	n := pbkdf2Iterations
+	pbkdf2Iterations = 1000
+	t.Cleanup(func() { pbkdf2Iterations = n })
+}
+
+func TestPassphraseTokenStore(t *testing.T) {
+	fastPBKDF2(t)
+	files := FileTokenStore{Dir: t.TempDir()}
+	s, err := NewPassphraseTokenStore(files, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveToken("ABC123", testToken); err != nil {
+		t.Fatal(err)
+	}
+	salt, err := files.LoadBlob(saltKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new store reuses the stored salt, so derives the same key.
+	s, err = NewPassphraseTokenStore(files, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkToken(t, s, "ABC123")
+	if again, _ := files.LoadBlob(saltKey); !bytes.Equal(again, salt) {
+		t.Error("the salt changed")
+	}
+
+	wrong, err := NewPassphraseTokenStore(files, "battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrong.LoadToken("ABC123"); err != ErrTokenDecrypt {
+		t.Errorf("wrong passphrase: err = %v, want ErrTokenDecrypt", err)
+	}
+	rotated, err := NewPassphraseTokenStore(files, "battery staple", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkToken(t, rotated, "ABC123")
+
+	// The same passphrase under another salt makes another key.
+	other := &memBlobStore{}
+	s2, err := NewPassphraseTokenStore(other, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := files.LoadBlob("ABC123")
+	other.SaveBlob("ABC123", raw)
+	if _, err := s2.LoadToken("ABC123"); err != ErrTokenDecrypt {
+		t.Errorf("other salt: err = %v, want ErrTokenDecrypt", err)
+	}
+}
+
+func TestPassphraseTokenStoreSaltError(t *testing.T) {
+	fastPBKDF2(t)
+	broken := errors.New("broken")
+	_, err := NewPassphraseTokenStore(failingBlobStore{broken}, "pass")
+	if !errors.Is(err, broken) {
+		t.Errorf("err = %v, want it to wrap the store's", err)
+	}
+	if _, err := NewPassphraseTokenStore(&memBlobStore{}); err == nil {
+		t.Error("no passphrases: succeeded")
+	}
+	if _, err := NewPassphraseTokenStore(&memBlobStore{}, ""); err == nil {
+		t.Error("empty passphrase: succeeded")
+	}
+}
+
+type failingBlobStore struct{ err error }
+
+func (s failingBlobStore) LoadBlob(string) ([]byte, error) { return nil, s.err }
+func (s failingBlobStore) SaveBlob(string, []byte) error   { return s.err }
@@ -0,0 +1,75 @@
+package fitbit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// UnitSystem selects the units Fitbit reads and writes measurements in.
+// It is sent as the Accept-Language header, and its values match the
+// ones Fitbit uses in profile fields such as weightUnit.
+type UnitSystem string
+
+const (
+	UnitSystemMetric UnitSystem = "METRIC"
+	UnitSystemUS     UnitSystem = "en_US"
+	UnitSystemUK     UnitSystem = "en_GB"
+)
+
+// validate checks that u is one of the known unit systems.
+func (u UnitSystem) validate() error {
+	switch u {
+	case UnitSystemMetric, UnitSystemUS, UnitSystemUK:
+		return nil
+	case "":
+		return errors.New("fitbit: a unit system is required")
+	}
+	return fmt.Errorf("fitbit: unknown unit system %q", string(u))
+}
+
+// unitSystem returns the unit system requests from c are made in.
+// Fitbit treats a missing Accept-Language header as metric.
+func (c *Client) unitSystem() UnitSystem {
+	if c.UnitSystem == "" {
+		return UnitSystemMetric
+	}
+	return c.UnitSystem
+}
+
+// Conversion factors to the metric units ComputeBMI works in.
+const (
+	kilogramsPerPound  = 0.45359237
+	kilogramsPerStone  = 6.35029318
+	centimetersPerInch = 2.54
+)
+
+// ComputeBMI computes a body mass index from weight and height given in
+// units: kilograms and centimeters for metric, pounds and inches for
+// UnitSystemUS, and stone and centimeters for UnitSystemUK. Like Fitbit,
+// it rounds to one decimal place. It returns 0 if height isn't positive.
+func ComputeBMI(weight, height float64, units UnitSystem) float64 {
+	switch units {
+	case UnitSystemUS:
+		weight *= kilogramsPerPound
+		height *= centimetersPerInch
+	case UnitSystemUK:
+		weight *= kilogramsPerStone
+	}
+	if height <= 0 {
+		return 0
+	}
+
+	meters := height / 100
+	return math.Round(weight/(meters*meters)*10) / 10
+}
+
+// BMI computes the user's body mass index from their profile's weight
+// and height.
+func (u User) BMI() float64 {
+	units := u.Units
+	if units == "" {
+		units = UnitSystemMetric
+	}
+	return ComputeBMI(u.Weight, u.Height, units)
+}
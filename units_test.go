@@ -0,0 +1,66 @@
+package fitbit
+
+import (
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestUserBMIMatchesFitbit(t *testing.T) {
+	for _, tt := range []struct {
+		units   UnitSystem
+		profile string
+		weights string
+	}{
+		{UnitSystemMetric, "profile_metric.json", "weight_log_metric.json"},
+		{UnitSystemUS, "profile_us.json", "weight_log_us.json"},
+	} {
+		t.Run(string(tt.units), func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("GET /1/user/-/profile.json", serveFixture(t, tt.profile))
+			mux.Handle("GET /1/user/-/body/log/weight/date/2021-10-25.json", serveFixture(t, tt.weights))
+			c := newTestClient(t, mux)
+			c.UnitSystem = tt.units
+
+			profile, err := c.UserProfile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile.User.Units != tt.units {
+				t.Errorf("Units = %q, want %q", profile.User.Units, tt.units)
+			}
+			logs, err := c.WeightLogs(t.Context(), Date{2021, 10, 25})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(logs.Logs) != 1 {
+				t.Fatalf("got %d weight logs, want 1", len(logs.Logs))
+			}
+			// Fitbit's logs carry two decimals; the helpers round to one.
+			want := math.Round(logs.Logs[0].BMI*10) / 10
+			if got := profile.User.BMI(); got != want {
+				t.Errorf("BMI() = %v, want %v (Fitbit's %v)", got, want, logs.Logs[0].BMI)
+			}
+		})
+	}
+}
+
+func TestComputeBMI(t *testing.T) {
+	for _, tt := range []struct {
+		weight, height float64
+		units          UnitSystem
+		want           float64
+	}{
+		{73, 176, UnitSystemMetric, 23.6},
+		{161.5, 69.3, UnitSystemUS, 23.6},
+		// en_GB weighs in stone but measures in centimeters.
+		{11.5, 176, UnitSystemUK, 23.6},
+		{95, 182.5, UnitSystemMetric, 28.5},
+		{209.4, 71.85, UnitSystemUS, 28.5},
+		{80, 0, UnitSystemMetric, 0},
+	} {
+		if got := ComputeBMI(tt.weight, tt.height, tt.units); got != tt.want {
+			t.Errorf("ComputeBMI(%v, %v, %s) = %v, want %v", tt.weight, tt.height, tt.units, got, tt.want)
+		}
+	}
+}
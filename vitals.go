@@ -0,0 +1,74 @@
+package fitbit
+
+import (
+	"golang.org/x/net/context"
+)
+
+// maxConcurrentVitals bounds how many of DailyVitals' requests are in
+// flight at once.
+const maxConcurrentVitals = 2
+
+// VitalMetric names one of the metrics DailyVitals fetches.
+type VitalMetric string
+
+const (
+	VitalHRV             VitalMetric = "hrv"
+	VitalSpO2            VitalMetric = "spo2"
+	VitalBreathingRate   VitalMetric = "breathingRate"
+	VitalSkinTemperature VitalMetric = "skinTemperature"
+)
+
+// DailyVitals combines the nightly wellness metrics for one date. A nil
+// metric either had no reading or failed to fetch; Errors tells the two
+// apart.
+type DailyVitals struct {
+	Date            Date
+	HRV             *HRV
+	SpO2            *SpO2
+	BreathingRate   *BreathingRate
+	SkinTemperature *SkinTemperature
+
+	// Errors holds the error for each metric that couldn't be fetched,
+	// e.g. a *ScopeError when the token lacks that metric's scope.
+	Errors map[VitalMetric]error
+}
+
+// DailyVitals fetches HRV, SpO2, breathing rate and skin temperature for
+// date concurrently, as a Batch. A failure fetching one metric is
+// recorded in the result's Errors rather than failing the call; metrics
+// the batch skips for the rate limit are recorded with its error. The
+// returned error is ctx's, if it ended before all the metrics were
+// fetched.
+func (c *Client) DailyVitals(ctx context.Context, date Date) (DailyVitals, error) {
+	vitals := DailyVitals{Date: date, Errors: map[VitalMetric]error{}}
+	b := c.Batch(ctx)
+	b.Concurrency = maxConcurrentVitals
+
+	metrics := []VitalMetric{VitalHRV, VitalSpO2, VitalBreathingRate, VitalSkinTemperature}
+	b.Add(func(ctx context.Context) (err error) {
+		vitals.HRV, err = c.HRVByDate(ctx, date)
+		return err
+	})
+	b.Add(func(ctx context.Context) (err error) {
+		vitals.SpO2, err = c.SpO2ByDate(ctx, date)
+		return err
+	})
+	b.Add(func(ctx context.Context) (err error) {
+		vitals.BreathingRate, err = c.BreathingRateByDate(ctx, date)
+		return err
+	})
+	b.Add(func(ctx context.Context) (err error) {
+		vitals.SkinTemperature, err = c.SkinTemperatureByDate(ctx, date)
+		return err
+	})
+
+	for i, err := range b.Run() {
+		if err != nil {
+			vitals.Errors[metrics[i]] = err
+		}
+	}
+	if len(vitals.Errors) > 0 {
+		return vitals, ctx.Err()
+	}
+	return vitals, nil
+}
@@ -0,0 +1,90 @@
+package fitbit
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestDailyVitalsPartialScope(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /1/user/-/hrv/date/2021-10-25.json", serveFixture(t, "hrv_date.json"))
+	mux.Handle("GET /1/user/-/spo2/date/2021-10-25.json", serveError(t, http.StatusForbidden, "insufficient_scope.json"))
+	mux.Handle("GET /1/user/-/br/date/2021-10-25.json", serveFixture(t, "br_date.json"))
+	mux.Handle("GET /1/user/-/temp/skin/date/2021-10-25.json", serveFixture(t, "temp_skin_date.json"))
+	c := newTestClient(t, mux)
+
+	v, err := c.DailyVitals(t.Context(), Date{2021, 10, 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Errors) != 1 {
+		t.Errorf("Errors = %v, want only spo2's", v.Errors)
+	}
+	var scopeErr *ScopeError
+	if err := v.Errors[VitalSpO2]; !errors.As(err, &scopeErr) || scopeErr.Scope != ScopeOxygenSaturation || !errors.Is(err, ErrForbidden) {
+		t.Errorf("Errors[spo2] = %v, want a ScopeError for oxygen_saturation", err)
+	}
+	if v.SpO2 != nil {
+		t.Errorf("SpO2 = %+v, want nil", v.SpO2)
+	}
+	if v.HRV == nil || v.HRV.Value.DailyRmssd != 34.938 {
+		t.Errorf("HRV = %+v", v.HRV)
+	}
+	if v.BreathingRate == nil || v.BreathingRate.Value.BreathingRate != 16.8 {
+		t.Errorf("BreathingRate = %+v", v.BreathingRate)
+	}
+	if v.SkinTemperature == nil || v.SkinTemperature.NightlyRelative != -0.4 {
+		t.Errorf("SkinTemperature = %+v", v.SkinTemperature)
+	}
+}
+
+func TestDailyVitalsNoData(t *testing.T) {
+	notFound := func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not_found", "n/a", "The API you are requesting could not be found.")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/hrv/date/2021-10-25.json", notFound)
+	mux.HandleFunc("GET /1/user/-/spo2/date/2021-10-25.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{})
+	})
+	mux.HandleFunc("GET /1/user/-/br/date/2021-10-25.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string][]BreathingRate{"br": {}})
+	})
+	mux.HandleFunc("GET /1/user/-/temp/skin/date/2021-10-25.json", notFound)
+	c := newTestClient(t, mux)
+
+	v, err := c.DailyVitals(t.Context(), Date{2021, 10, 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.HRV != nil || v.SpO2 != nil || v.BreathingRate != nil || v.SkinTemperature != nil {
+		t.Errorf("DailyVitals = %+v, want no metrics", v)
+	}
+	// Empty responses are nights without a reading, not errors.
+	if len(v.Errors) != 2 || !errors.Is(v.Errors[VitalHRV], ErrNotFound) || !errors.Is(v.Errors[VitalSkinTemperature], ErrNotFound) {
+		t.Errorf("Errors = %v, want ErrNotFound for hrv and skinTemperature", v.Errors)
+	}
+}
+
+func TestDailyVitalsCanceled(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, path := range []string{"hrv", "spo2", "br", "temp/skin"} {
+		mux.HandleFunc("GET /1/user/-/"+path+"/date/2021-10-25.json", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{})
+		})
+	}
+	c := newTestClient(t, mux)
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	v, err := c.DailyVitals(ctx, Date{2021, 10, 25})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if len(v.Errors) != 4 {
+		t.Errorf("Errors = %v, want all four metrics", v.Errors)
+	}
+}
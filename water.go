@@ -0,0 +1,169 @@
+package fitbit
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+// WaterLog is the water logged on one day. Amounts are in fl oz for the
+// en_US unit system and ml otherwise.
+type WaterLog struct {
+	Units   UnitSystem
+	Total   float64
+	Entries []WaterLogEntry
+}
+
+// WaterLogEntry is a single logged amount of water.
+type WaterLogEntry struct {
+	LogID  int64   `json:"logId"`
+	Amount float64 `json:"amount"`
+}
+
+// WaterLog returns the water logged on date.
+func (c *Client) WaterLog(ctx context.Context, date Date) (WaterLog, error) {
+	var resp struct {
+		Summary struct {
+			Water float64 `json:"water"`
+		} `json:"summary"`
+		Water []WaterLogEntry `json:"water"`
+	}
+	err := c.get(ctx, fmt.Sprintf("/user/-/foods/log/water/date/%s.json", date), &resp)
+	if err != nil {
+		return WaterLog{}, err
+	}
+
+	log := WaterLog{Units: c.unitSystem(), Total: resp.Summary.Water, Entries: resp.Water}
+	if log.Entries == nil {
+		log.Entries = []WaterLogEntry{}
+	}
+	return log, nil
+}
+
+// WaterUnit is the unit of a water amount sent to Fitbit. Unlike most
+// endpoints the water ones take the unit explicitly rather than from
+// Accept-Language.
+type WaterUnit string
+
+const (
+	WaterUnitMl   WaterUnit = "ml"
+	WaterUnitFlOz WaterUnit = "fl oz"
+	WaterUnitCup  WaterUnit = "cup"
+)
+
+func (u WaterUnit) valid() bool {
+	switch u {
+	case WaterUnitMl, WaterUnitFlOz, WaterUnitCup:
+		return true
+	}
+	return false
+}
+
+// waterParams returns the parameters for amount of water in unit, where
+// a zero unit leaves the choice to Fitbit's unit system default.
+func waterParams(name string, amount float64, unit WaterUnit) (url.Values, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("fitbit: water %s must be positive", name)
+	}
+	params := url.Values{name: {strconv.FormatFloat(amount, 'f', -1, 64)}}
+	if unit != "" {
+		if !unit.valid() {
+			return nil, fmt.Errorf("fitbit: invalid water unit %q", unit)
+		}
+		params.Set("unit", string(unit))
+	}
+	return params, nil
+}
+
+// LogWater logs amount of water in unit on date. The created entry's
+// amount is in the client's unit system, converted by Fitbit.
+func (c *Client) LogWater(ctx context.Context, date Date, amount float64, unit WaterUnit) (WaterLogEntry, error) {
+	var resp struct {
+		WaterLog WaterLogEntry `json:"waterLog"`
+	}
+	if date.IsZero() {
+		return resp.WaterLog, errors.New("fitbit: water log date is required")
+	}
+	params, err := waterParams("amount", amount, unit)
+	if err != nil {
+		return resp.WaterLog, err
+	}
+	params.Set("date", date.String())
+
+	err = c.postForm(ctx, "", "/user/-/foods/log/water.json", params, &resp)
+	return resp.WaterLog, err
+}
+
+// UpdateWaterLog changes the amount of the water log entry with the
+// given id to amount in unit. As with LogWater the returned amount is
+// Fitbit's, in the client's unit system. It returns an error matching
+// ErrNotFound if there is no such entry.
+func (c *Client) UpdateWaterLog(ctx context.Context, logID int64, amount float64, unit WaterUnit) (WaterLogEntry, error) {
+	var resp struct {
+		WaterLog WaterLogEntry `json:"waterLog"`
+	}
+	params, err := waterParams("amount", amount, unit)
+	if err != nil {
+		return resp.WaterLog, err
+	}
+
+	err = c.postForm(ctx, "", fmt.Sprintf("/user/-/foods/log/water/%d.json", logID), params, &resp)
+	return resp.WaterLog, err
+}
+
+// DeleteWaterLog deletes the water log entry with the given id. It
+// returns an error matching ErrNotFound if there is no such entry.
+func (c *Client) DeleteWaterLog(ctx context.Context, logID int64) error {
+	return c.delete(ctx, "", fmt.Sprintf("/user/-/foods/log/water/%d.json", logID))
+}
+
+// WaterGoal is the user's daily water goal, in fl oz for the en_US unit
+// system and ml otherwise. StartDate is zero if Fitbit doesn't report
+// one.
+type WaterGoal struct {
+	Units     UnitSystem
+	Goal      float64
+	StartDate Date
+}
+
+type waterGoalResponse struct {
+	Goal struct {
+		Goal      *float64 `json:"goal"`
+		StartDate Date     `json:"startDate"`
+	} `json:"goal"`
+}
+
+func (r waterGoalResponse) goal(units UnitSystem) (WaterGoal, error) {
+	if r.Goal.Goal == nil {
+		return WaterGoal{}, ErrNoGoal
+	}
+	return WaterGoal{Units: units, Goal: *r.Goal.Goal, StartDate: r.Goal.StartDate}, nil
+}
+
+// WaterGoal returns the user's water goal, or ErrNoGoal if they haven't
+// set one.
+func (c *Client) WaterGoal(ctx context.Context) (WaterGoal, error) {
+	var resp waterGoalResponse
+	if err := c.get(ctx, "/user/-/foods/log/water/goal.json", &resp); err != nil {
+		return WaterGoal{}, err
+	}
+	return resp.goal(c.unitSystem())
+}
+
+// SetWaterGoal sets the user's daily water goal to target in unit and
+// returns the goal as Fitbit stored it, in the client's unit system.
+func (c *Client) SetWaterGoal(ctx context.Context, target float64, unit WaterUnit) (WaterGoal, error) {
+	params, err := waterParams("target", target, unit)
+	if err != nil {
+		return WaterGoal{}, err
+	}
+
+	var resp waterGoalResponse
+	if err := c.postForm(ctx, "", "/user/-/foods/log/water/goal.json", params, &resp); err != nil {
+		return WaterGoal{}, err
+	}
+	return resp.goal(c.unitSystem())
+}
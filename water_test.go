@@ -0,0 +1,293 @@
+package fitbit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeWater fakes the water endpoints, keeping ml and answering in the
+// request's units as Fitbit does.
+type fakeWater struct {
+	entries fakeStore[int64, fakeWaterEntry]
+
+	mu   sync.Mutex
+	goal float64 // ml, or 0 if unset
+}
+
+type fakeWaterEntry struct {
+	id     int64
+	date   string
+	amount float64
+}
+
+// amountParam returns the request's amount parameter in ml.
+func (f *fakeWater) amountParam(r *http.Request, name string) (float64, bool) {
+	if r.ParseForm() != nil {
+		return 0, false
+	}
+	amount, err := strconv.ParseFloat(r.PostForm.Get(name), 64)
+	if err != nil || amount <= 0 {
+		return 0, false
+	}
+	switch r.PostForm.Get("unit") {
+	case "", "ml":
+		return amount, true
+	case "fl oz":
+		return roundTenth(amount * 29.5735), true
+	case "cup":
+		return roundTenth(amount * 236.588), true
+	}
+	return 0, false
+}
+
+// reported returns ml of water in the units of the request.
+func (f *fakeWater) reported(r *http.Request, ml float64) float64 {
+	if r.Header.Get("Accept-Language") == string(UnitSystemUS) {
+		return roundTenth(ml / 29.5735)
+	}
+	return ml
+}
+
+func roundTenth(x float64) float64 {
+	return math.Round(x*10) / 10
+}
+
+func (f *fakeWater) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1/user/-/foods/log/water/date/{date}", func(w http.ResponseWriter, r *http.Request) {
+		date := strings.TrimSuffix(r.PathValue("date"), ".json")
+		list := []WaterLogEntry{}
+		var total float64
+		for _, e := range f.entries.list(func(e fakeWaterEntry) bool { return e.date == date }) {
+			list = append(list, WaterLogEntry{LogID: e.id, Amount: f.reported(r, e.amount)})
+			total += e.amount
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"summary": map[string]float64{"water": f.reported(r, total)},
+			"water":   list,
+		})
+	})
+	mux.HandleFunc("POST /1/user/-/foods/log/water.json", func(w http.ResponseWriter, r *http.Request) {
+		amount, ok := f.amountParam(r, "amount")
+		if _, err := ParseDate(r.PostForm.Get("date")); !ok || err != nil {
+			writeError(w, http.StatusBadRequest, "validation", "amount", "Invalid water log")
+			return
+		}
+		e := fakeWaterEntry{id: f.entries.newID(), date: r.PostForm.Get("date"), amount: amount}
+		f.entries.put(e.id, e)
+		writeJSON(w, http.StatusCreated, map[string]WaterLogEntry{"waterLog": {LogID: e.id, Amount: f.reported(r, amount)}})
+	})
+	mux.HandleFunc("GET /1/user/-/foods/log/water/goal.json", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		goal := map[string]interface{}{}
+		if f.goal != 0 {
+			goal = map[string]interface{}{"goal": f.reported(r, f.goal), "startDate": "2021-10-25"}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"goal": goal})
+	})
+	mux.HandleFunc("POST /1/user/-/foods/log/water/goal.json", func(w http.ResponseWriter, r *http.Request) {
+		target, ok := f.amountParam(r, "target")
+		if !ok {
+			writeError(w, http.StatusBadRequest, "validation", "target", "Invalid water goal")
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.goal = target
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"goal": map[string]interface{}{"goal": f.reported(r, target), "startDate": "2021-10-25"},
+		})
+	})
+	mux.HandleFunc("POST /1/user/-/foods/log/water/{id}", func(w http.ResponseWriter, r *http.Request) {
+		amount, ok := f.amountParam(r, "amount")
+		if !ok {
+			writeError(w, http.StatusBadRequest, "validation", "amount", "Invalid water log")
+			return
+		}
+		e, found := f.entries.get(pathID(r))
+		if !found {
+			writeError(w, http.StatusNotFound, "not_found", "logId", "Water log not found")
+			return
+		}
+		e.amount = amount
+		f.entries.put(e.id, e)
+		writeJSON(w, http.StatusOK, map[string]WaterLogEntry{"waterLog": {LogID: e.id, Amount: f.reported(r, amount)}})
+	})
+	mux.HandleFunc("DELETE /1/user/-/foods/log/water/{id}", serveDelete(&f.entries, "logId", "Water log not found"))
+	return mux
+}
+
+func TestWaterLogLifecycle(t *testing.T) {
+	f := &fakeWater{}
+	c := newTestClient(t, f.handler())
+	c.UnitSystem = UnitSystemMetric
+	day := Date{2021, 10, 25}
+
+	total := func() float64 {
+		t.Helper()
+		log, err := c.WaterLog(t.Context(), day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if log.Units != UnitSystemMetric {
+			t.Errorf("Units = %q, want METRIC", log.Units)
+		}
+		return log.Total
+	}
+
+	first, err := c.LogWater(t.Context(), day, 500, WaterUnitMl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fitbit answers in the client's units.
+	second, err := c.LogWater(t.Context(), day, 8, WaterUnitFlOz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Amount != 500 || second.Amount != 236.6 {
+		t.Errorf("logged %v and %v ml, want 500 and 236.6", first.Amount, second.Amount)
+	}
+	if got := total(); got != 736.6 {
+		t.Errorf("total after logging = %v, want 736.6", got)
+	}
+
+	updated, err := c.UpdateWaterLog(t.Context(), first.LogID, 750, WaterUnitMl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.LogID != first.LogID || updated.Amount != 750 {
+		t.Errorf("updated = %+v", updated)
+	}
+	if got := total(); got != 986.6 {
+		t.Errorf("total after updating = %v, want 986.6", got)
+	}
+
+	if err := c.DeleteWaterLog(t.Context(), second.LogID); err != nil {
+		t.Fatal(err)
+	}
+	if got := total(); got != 750 {
+		t.Errorf("total after deleting = %v, want 750", got)
+	}
+	if err := c.DeleteWaterLog(t.Context(), second.LogID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting it again = %v, want ErrNotFound", err)
+	}
+	if _, err := c.UpdateWaterLog(t.Context(), second.LogID, 100, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("updating a deleted log = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUpdateWaterLogUnits(t *testing.T) {
+	tests := []struct {
+		units  UnitSystem
+		amount float64
+		unit   WaterUnit
+		want   float64 // as Fitbit reports it, in units
+	}{
+		// Fitbit rounds its conversions, so a fl oz amount updated on a
+		// metric account doesn't come back as amount*29.5735.
+		{UnitSystemMetric, 8, WaterUnitFlOz, 236.6},
+		{UnitSystemMetric, 2, WaterUnitCup, 473.2},
+		{UnitSystemMetric, 750, WaterUnitMl, 750},
+		{UnitSystemUK, 8, WaterUnitFlOz, 236.6},
+		{UnitSystemUK, 750, WaterUnitMl, 750},
+		{UnitSystemUS, 500, WaterUnitMl, 16.9},
+		{UnitSystemUS, 8, WaterUnitFlOz, 8},
+		{UnitSystemUS, 1, WaterUnitCup, 8},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v %s", tt.units, tt.amount, tt.unit), func(t *testing.T) {
+			day := Date{2021, 10, 25}
+			f := &fakeWater{}
+			f.entries.put(7, fakeWaterEntry{id: 7, date: day.String(), amount: 500})
+			f.entries.nextID = 7
+			c := newTestClient(t, f.handler())
+			c.UnitSystem = tt.units
+
+			updated, err := c.UpdateWaterLog(t.Context(), 7, tt.amount, tt.unit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.LogID != 7 || updated.Amount != tt.want {
+				t.Errorf("updated = %+v, want amount %v", updated, tt.want)
+			}
+
+			log, err := c.WaterLog(t.Context(), day)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if log.Units != tt.units || log.Total != tt.want {
+				t.Errorf("log = %+v, want a total of %v %s", log, tt.want, tt.units)
+			}
+		})
+	}
+}
+
+func TestSetWaterGoalUnits(t *testing.T) {
+	tests := []struct {
+		units  UnitSystem
+		target float64
+		unit   WaterUnit
+		want   float64 // as Fitbit reports it, in units
+	}{
+		{UnitSystemMetric, 2000, WaterUnitMl, 2000},
+		// A fl oz goal on a metric account comes back in ml, as Fitbit
+		// rounded it.
+		{UnitSystemMetric, 64, WaterUnitFlOz, 1892.7},
+		{UnitSystemMetric, 8, WaterUnitCup, 1892.7},
+		{UnitSystemUK, 64, WaterUnitFlOz, 1892.7},
+		{UnitSystemUK, 2000, WaterUnitMl, 2000},
+		{UnitSystemUS, 64, WaterUnitFlOz, 64},
+		{UnitSystemUS, 2000, WaterUnitMl, 67.6},
+		{UnitSystemUS, 8, WaterUnitCup, 64},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v %s", tt.units, tt.target, tt.unit), func(t *testing.T) {
+			f := &fakeWater{}
+			c := newTestClient(t, f.handler())
+			c.UnitSystem = tt.units
+
+			if _, err := c.WaterGoal(t.Context()); !errors.Is(err, ErrNoGoal) {
+				t.Fatalf("goal before setting one = %v, want ErrNoGoal", err)
+			}
+
+			want := WaterGoal{Units: tt.units, Goal: tt.want, StartDate: Date{2021, 10, 25}}
+			goal, err := c.SetWaterGoal(t.Context(), tt.target, tt.unit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if goal != want {
+				t.Errorf("SetWaterGoal = %+v, want %+v", goal, want)
+			}
+			if goal, err = c.WaterGoal(t.Context()); err != nil || goal != want {
+				t.Errorf("WaterGoal = %+v, %v, want %+v", goal, err, want)
+			}
+		})
+	}
+}
+
+func TestSetWaterGoalInvalid(t *testing.T) {
+	rec := &requestRecorder{}
+	c := newTestClient(t, rec)
+	for _, tt := range []struct {
+		target float64
+		unit   WaterUnit
+	}{
+		{0, WaterUnitMl},
+		{-250, WaterUnitMl},
+		{2000, "liter"},
+	} {
+		if _, err := c.SetWaterGoal(t.Context(), tt.target, tt.unit); err == nil {
+			t.Errorf("SetWaterGoal(%v, %q) succeeded", tt.target, tt.unit)
+		}
+	}
+	if n := len(rec.reqs); n != 0 {
+		t.Errorf("sent %d requests, want none", n)
+	}
+}
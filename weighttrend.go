@@ -0,0 +1,98 @@
+package fitbit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// WeightTrendPoint is the smoothed weight for a day with a reading.
+type WeightTrendPoint struct {
+	Date Date
+	// Weight is the mean of the day's readings.
+	Weight float64
+	// EWMA is the exponentially weighted moving average of Weight.
+	EWMA float64
+	// RollingAverage is the mean of the daily weights in the window
+	// ending on Date, and RollingDays how many days with readings it
+	// covers. Near the start of the series the window isn't full and
+	// RollingDays is smaller than the window size.
+	RollingAverage float64
+	RollingDays    int
+}
+
+type dailyWeight struct {
+	date   Date
+	weight float64
+}
+
+// WeightTrend smooths weigh-ins into a point per day with readings.
+// alpha is the daily EWMA factor, in (0, 1]; windowDays is the rolling
+// average's window in days.
+func WeightTrend(logs []WeightLog, alpha float64, windowDays int) ([]WeightTrendPoint, error) {
+	sums := map[Date]float64{}
+	counts := map[Date]int{}
+	for _, l := range logs {
+		sums[l.Date] += l.Weight
+		counts[l.Date]++
+	}
+
+	days := make([]dailyWeight, 0, len(sums))
+	for d, sum := range sums {
+		days = append(days, dailyWeight{date: d, weight: sum / float64(counts[d])})
+	}
+	return weightTrend(days, alpha, windowDays)
+}
+
+// SeriesWeightTrend is WeightTrend for a weight time series. As Fitbit
+// carries values forward into days without a measurement, only points
+// marked Measured are used if any are.
+func SeriesWeightTrend(s BodySeries, alpha float64, windowDays int) ([]WeightTrendPoint, error) {
+	anyMeasured := false
+	for _, p := range s.Points {
+		anyMeasured = anyMeasured || p.Measured
+	}
+
+	var days []dailyWeight
+	for _, p := range s.Points {
+		if !anyMeasured || p.Measured {
+			days = append(days, dailyWeight{date: p.DateTime, weight: p.Value})
+		}
+	}
+	return weightTrend(days, alpha, windowDays)
+}
+
+func weightTrend(days []dailyWeight, alpha float64, windowDays int) ([]WeightTrendPoint, error) {
+	// Written so that NaN fails too.
+	if !(alpha > 0 && alpha <= 1) {
+		return nil, fmt.Errorf("fitbit: weight trend alpha %v is not in (0, 1]", alpha)
+	}
+	if windowDays < 1 {
+		windowDays = 1
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].date.Before(days[j].date) })
+
+	points := make([]WeightTrendPoint, len(days))
+	first := 0
+	var windowSum float64
+	for i, d := range days {
+		p := WeightTrendPoint{Date: d.date, Weight: d.weight, EWMA: d.weight}
+		if i > 0 {
+			gap := days[i-1].date.DaysUntil(d.date)
+			a := 1 - math.Pow(1-alpha, float64(gap))
+			p.EWMA = points[i-1].EWMA + a*(d.weight-points[i-1].EWMA)
+		}
+
+		windowSum += d.weight
+		windowStart := d.date.AddDays(1 - windowDays)
+		for days[first].date.Before(windowStart) {
+			windowSum -= days[first].weight
+			first++
+		}
+		p.RollingDays = i - first + 1
+		p.RollingAverage = windowSum / float64(p.RollingDays)
+
+		points[i] = p
+	}
+	return points, nil
+}
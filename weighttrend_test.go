@@ -0,0 +1,77 @@
+package fitbit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeightTrendAlpha(t *testing.T) {
+	logs := []WeightLog{{Weight: 80, Date: Date{2022, 3, 1}}}
+	for _, alpha := range []float64{0, -0.1, 1.01, math.NaN(), math.Inf(1)} {
+		if _, err := WeightTrend(logs, alpha, 7); err == nil {
+			t.Errorf("alpha %v: no error", alpha)
+		}
+		if _, err := SeriesWeightTrend(BodySeries{}, alpha, 7); err == nil {
+			t.Errorf("SeriesWeightTrend alpha %v: no error", alpha)
+		}
+	}
+	for _, alpha := range []float64{0.1, 1} {
+		if _, err := WeightTrend(logs, alpha, 7); err != nil {
+			t.Errorf("alpha %v: %v", alpha, err)
+		}
+	}
+}
+
+func TestWeightTrend(t *testing.T) {
+	logs := []WeightLog{
+		// Two readings on the first day average to 80.
+		{Weight: 79.5, Date: Date{2022, 3, 1}},
+		{Weight: 80.5, Date: Date{2022, 3, 1}},
+		{Weight: 81, Date: Date{2022, 3, 2}},
+		// A two day gap.
+		{Weight: 78, Date: Date{2022, 3, 4}},
+		{Weight: 79, Date: Date{2022, 3, 5}},
+	}
+	points, err := WeightTrend(logs, 0.5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []WeightTrendPoint{
+		// The start of the series: the window has only the first day.
+		{Date: Date{2022, 3, 1}, Weight: 80, EWMA: 80, RollingAverage: 80, RollingDays: 1},
+		{Date: Date{2022, 3, 2}, Weight: 81, EWMA: 80.5, RollingAverage: 80.5, RollingDays: 2},
+		// The gap decays the average as two days: a = 1 - 0.5². The
+		// window (3/2 to 3/4) has two days with readings.
+		{Date: Date{2022, 3, 4}, Weight: 78, EWMA: 80.5 + 0.75*(78-80.5), RollingAverage: 79.5, RollingDays: 2},
+		{Date: Date{2022, 3, 5}, Weight: 79, EWMA: 78.625 + 0.5*(79-78.625), RollingAverage: 78.5, RollingDays: 2},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(points), len(want))
+	}
+	for i, p := range points {
+		w := want[i]
+		if p.Date != w.Date || p.RollingDays != w.RollingDays ||
+			math.Abs(p.Weight-w.Weight) > 1e-9 ||
+			math.Abs(p.EWMA-w.EWMA) > 1e-9 ||
+			math.Abs(p.RollingAverage-w.RollingAverage) > 1e-9 {
+			t.Errorf("points[%d] = %+v, want %+v", i, p, w)
+		}
+	}
+}
+
+func TestSeriesWeightTrendMeasured(t *testing.T) {
+	s := BodySeries{Points: []BodySeriesPoint{
+		{DateTime: Date{2022, 3, 1}, Value: 80, Measured: true},
+		// Carried forward by Fitbit.
+		{DateTime: Date{2022, 3, 2}, Value: 80},
+		{DateTime: Date{2022, 3, 3}, Value: 82, Measured: true},
+	}}
+	points, err := SeriesWeightTrend(s, 1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || points[1].RollingDays != 2 || points[1].RollingAverage != 81 || points[1].EWMA != 82 {
+		t.Errorf("points = %+v", points)
+	}
+}
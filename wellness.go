@@ -0,0 +1,32 @@
+package fitbit
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// maxWellnessRangeDays caps a range request for HRV, SpO2 and friends.
+const maxWellnessRangeDays = 30
+
+// getWellnessRange calls decode with the url of each chunk of
+// [start, end] for resource, in date order.
+func (c *Client) getWellnessRange(
+	ctx context.Context,
+	resource string,
+	start, end Date,
+	decode func(urlStr string) error,
+) error {
+	chunks, err := splitDateRange(start, end, maxWellnessRangeDays)
+	if err != nil {
+		return err
+	}
+
+	for _, chunk := range chunks {
+		urlStr := fmt.Sprintf("/user/-/%s/date/%s/%s.json", resource, chunk.Start, chunk.End)
+		if err := decode(urlStr); err != nil {
+			return err
+		}
+	}
+	return nil
+}